@@ -0,0 +1,150 @@
+// Copyright (c) 2015, J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD-style license that can be found in the LICENSE file.
+
+package cmdapp
+
+import (
+	"flag"
+	"fmt"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/pkg/errors"
+)
+
+// aliasPrefix is the prefix of the configuration keys
+// that define command aliases.
+const aliasPrefix = "alias."
+
+// aliases returns the command aliases
+// defined in the configuration file.
+func aliases() map[string]string {
+	cfgMutex.Lock()
+	defer cfgMutex.Unlock()
+	al := make(map[string]string)
+	for k, v := range cfgValues {
+		if strings.HasPrefix(k, aliasPrefix) {
+			al[normName(k[len(aliasPrefix):])] = v
+		}
+	}
+	return al
+}
+
+// expandAlias replaces the first argument
+// with the expansion of an alias,
+// if the argument is not a command name.
+func expandAlias(args []string) ([]string, error) {
+	nm := normName(args[0])
+	_, isCmd := lookup(nm)
+	if isCmd {
+		return args, nil
+	}
+	exp, ok := aliases()[nm]
+	if !ok {
+		return args, nil
+	}
+	words, err := checkAlias(nm, exp)
+	if err != nil {
+		return nil, err
+	}
+	return append(words, args[1:]...), nil
+}
+
+// checkAlias checks that an alias expands to a command,
+// and returns the words of the expansion.
+func checkAlias(name, exp string) ([]string, error) {
+	words, err := splitWords(exp)
+	if err != nil {
+		return nil, errors.Errorf("alias %s: %v", name, err)
+	}
+	if len(words) == 0 {
+		return nil, errors.Errorf("alias %s: empty expansion", name)
+	}
+	c, ok := lookup(normName(words[0]))
+	if !ok || !c.Runnable() {
+		return nil, errors.Errorf("alias %s: unknown command %s", name, words[0])
+	}
+	return words, nil
+}
+
+// aliasCmd is the alias command.
+type aliasCmd struct{}
+
+func init() {
+	addBuiltin(aliasCmd{})
+}
+
+const aliasLong = `
+Command alias manages the command aliases, stored in the configuration file.
+An alias is a name that expands to a command with its flags and arguments.
+Arguments given after the alias are added after the expansion.
+
+The subcommands are:
+
+    set <name> <expansion>
+      Defines an alias. The expansion must start with a command name, and
+      it should be quoted if it has more than a word, for example:
+
+          alias set co "checkout -q"
+
+    list
+      Prints the defined aliases.
+
+    rm <name>
+      Removes an alias.
+`
+
+func (a aliasCmd) Name() string              { return "alias" }
+func (a aliasCmd) Args() string              { return "set <name> <expansion> | list | rm <name>" }
+func (a aliasCmd) Short() string             { return "manages command aliases" }
+func (a aliasCmd) Long() string              { return aliasLong }
+func (a aliasCmd) Register(fs *flag.FlagSet) {}
+func (a aliasCmd) Runnable() bool            { return true }
+
+func (a aliasCmd) Run(args []string) error {
+	if len(args) == 0 {
+		return errors.New("expecting a subcommand")
+	}
+	switch args[0] {
+	case "set":
+		if len(args) < 3 {
+			return errors.New("set: expecting a name and an expansion")
+		}
+		nm := normName(args[1])
+		exp := strings.Join(args[2:], " ")
+		if c, isCmd := lookup(nm); isCmd {
+			return errors.Errorf("set: %s is a command name", nameConflict(args[1], c.Name()))
+		}
+		if strings.ContainsAny(nm, " \t=#") {
+			return errors.Errorf("set: invalid alias name %q", nm)
+		}
+		if _, err := checkAlias(nm, exp); err != nil {
+			return err
+		}
+		return setUserConfig(aliasPrefix+nm, exp, false)
+	case "list":
+		al := aliases()
+		names := make([]string, 0, len(al))
+		for nm := range al {
+			names = append(names, nm)
+		}
+		sort.Strings(names)
+		tw := tabwriter.NewWriter(Output(), 0, 4, 2, ' ', 0)
+		for _, nm := range names {
+			fmt.Fprintf(tw, "%s\t%s\n", nm, al[nm])
+		}
+		return tw.Flush()
+	case "rm":
+		if len(args) != 2 {
+			return errors.New("rm: expecting an alias name")
+		}
+		nm := normName(args[1])
+		if _, ok := aliases()[nm]; !ok {
+			return errors.Errorf("rm: unknown alias %s", nm)
+		}
+		return setUserConfig(aliasPrefix+nm, "", true)
+	}
+	return errors.Errorf("unknown subcommand %s", args[0])
+}
@@ -0,0 +1,87 @@
+// Copyright (c) 2015, J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD-style license that can be found in the LICENSE file.
+
+package cmdapp
+
+import (
+	"bytes"
+	"io"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestAlias(t *testing.T) {
+	defer func(name string) { ConfigFile = name }(ConfigFile)
+	cfgMutex.Lock()
+	old := cfgValues
+	cfgMutex.Unlock()
+	defer func() {
+		cfgMutex.Lock()
+		cfgValues = old
+		cfgMutex.Unlock()
+	}()
+	ConfigFile = filepath.Join(t.TempDir(), "config")
+	if err := LoadConfig(); err != nil {
+		t.Fatal(err)
+	}
+
+	var got string
+	var out bytes.Buffer
+	a := NewApp("aliasapp", "a test application")
+	a.Stdout, a.Stderr = &out, io.Discard
+	a.Add(&mountCmd{name: "greet", run: func(c *mountCmd, args []string) error {
+		got = strings.Join(append([]string{strconv.Itoa(c.n)}, args...), " ")
+		return nil
+	}})
+
+	// the steps are run in order,
+	// as they share the configuration
+	tests := []struct {
+		args []string
+		code int
+		ran  string // arguments of greet
+		out  string
+	}{
+		{args: []string{"alias", "set", "g", "greet -n 2"}},
+		{args: []string{"g", "x", "y"}, ran: "2 x y"},
+		{args: []string{"G"}, ran: "2"},
+		{args: []string{"alias", "set", "hi", `greet "a b"`}},
+		{args: []string{"hi"}, ran: "1 a b"},
+		{args: []string{"alias", "list"}, out: "g   greet -n 2\nhi  greet \"a b\"\n"},
+		{args: []string{"alias", "set", "greet", "help"}, code: 1},
+		{args: []string{"alias", "set", "a=b", "greet"}, code: 1},
+		{args: []string{"alias", "set", "bad", "no-such-command"}, code: 1},
+		{args: []string{"alias", "set", "bad", `greet "x`}, code: 1},
+		{args: []string{"alias", "set", "bad"}, code: 1},
+		{args: []string{"alias", "rm", "g"}},
+		{args: []string{"alias", "list"}, out: "hi  greet \"a b\"\n"},
+		{args: []string{"alias", "rm", "g"}, code: 1},
+		{args: []string{"alias"}, code: 1},
+		{args: []string{"alias", "other"}, code: 1},
+	}
+	for _, test := range tests {
+		got = ""
+		out.Reset()
+		if code := a.Dispatch(test.args); code != test.code {
+			t.Errorf("%q: exit code %d, want %d", test.args, code, test.code)
+		}
+		if got != test.ran {
+			t.Errorf("%q: greet run with %q, want %q", test.args, got, test.ran)
+		}
+		if test.out != "" && out.String() != test.out {
+			t.Errorf("%q: output %q, want %q", test.args, out.String(), test.out)
+		}
+	}
+
+	// an alias to a command
+	// that is no longer defined
+	if err := setUserConfig(aliasPrefix+"old", "removed-command", false); err != nil {
+		t.Fatal(err)
+	}
+	if code := a.Dispatch([]string{"old"}); code != 1 {
+		t.Errorf("alias to an unknown command: exit code %d, want 1", code)
+	}
+}
@@ -12,6 +12,7 @@
 // the list of commands should be set up.
 //
 // In most simple case, the Run function will execute the required command:
+//
 //	import "github.com/js-arias/cmdapp"
 //
 //	// initialize commands...
@@ -19,74 +20,601 @@
 //	func main() {
 //		cmdapp.Run()
 //	}
+//
+// Commands should log using the Logger function.
+// With the -log-file flag,
+// given before the command name,
+// all log messages are written to a file,
+// as JSON lines.
 package cmdapp
 
 import (
+	"context"
 	"flag"
 	"fmt"
+	"io"
 	"os"
-	"strings"
+	"sort"
 	"sync"
+
+	"github.com/pkg/errors"
 )
 
 // Short is a short description of the application.
 var Short string
 
+// Version is the version of the application,
+// used in the User-Agent of HTTP requests.
+var Version string
+
+// A registry is a set of commands.
 // commands is the list of available commands and help topics.
+// sorted is the same list sorted by name,
+// it is replaced (never modified) when a command is added,
+// so it can be used without holding the mutex,
+// and version is updated.
+// builtins is the list of framework commands
+// that can be replaced by application commands.
+// def is the name of the default command.
+type registry struct {
+	commands map[string]Command
+	sorted   []Command
+	version  int
+	builtins map[string]bool
+	def      string
+}
+
+// newRegistry returns an empty registry.
+func newRegistry() *registry {
+	return &registry{
+		commands: make(map[string]Command),
+		builtins: make(map[string]bool),
+	}
+}
+
+// reg is the registry of the running application.
+// lastVersion is the last version assigned to a registry,
+// so the versions are unique
+// among all the registries.
+// The mutex guards all the registries.
 var (
-	mutex    sync.Mutex
-	commands = make(map[string]Command)
+	mutex       sync.RWMutex
+	reg         = newRegistry()
+	lastVersion int
 )
 
 // Add adds a new command to the application.
 // Command names should be unique,
 // otherwise it will trigger a panic.
+// An application command can replace
+// a framework command of the same name
+// (other than help).
+// If a policy is set with SetPolicy,
+// a command that breaks it
+// will trigger a panic.
 func Add(c Command) {
-	name := strings.ToLower(c.Name())
+	add(c)
+	if err := checkPolicy(c); err != nil {
+		panic(fmt.Sprintf("cmdapp: policy: %v", err))
+	}
+}
+
+// add adds a command to the registry.
+func add(c Command) {
 	mutex.Lock()
 	defer mutex.Unlock()
-	if _, dup := commands[name]; dup {
-		msg := fmt.Sprintf("cmdapp: Repeated command name: %s %s", name, c.Short())
+	reg.add(c)
+}
+
+// add adds a command to a registry.
+// The mutex must be held.
+func (r *registry) add(c Command) {
+	name := normName(c.Name())
+	if r.builtins[name] {
+		delete(r.builtins, name)
+	} else if prev, dup := r.commands[name]; dup {
+		msg := fmt.Sprintf("cmdapp: Repeated command name: %s %s", nameConflict(c.Name(), prev.Name()), c.Short())
 		panic(msg)
 	}
-	commands[name] = c
+	r.commands[name] = c
+
+	i := sort.Search(len(r.sorted), func(i int) bool {
+		return normName(r.sorted[i].Name()) >= name
+	})
+	s := make([]Command, 0, len(r.sorted)+1)
+	s = append(s, r.sorted[:i]...)
+	s = append(s, c)
+	if i < len(r.sorted) && normName(r.sorted[i].Name()) == name {
+		i++
+	}
+	s = append(s, r.sorted[i:]...)
+	r.sorted = s
+	lastVersion++
+	r.version = lastVersion
+}
+
+// addBuiltin adds a framework command.
+func addBuiltin(c Command) {
+	mutex.Lock()
+	defer mutex.Unlock()
+	reg.add(c)
+	reg.builtins[normName(c.Name())] = true
+	framework = append(framework, c)
+}
+
+// framework are the framework commands
+// added to the default application,
+// used to set up the registry of a new App.
+var framework []Command
+
+// sortedCommands returns the registered commands sorted by name.
+// The returned slice must not be modified.
+func sortedCommands() []Command {
+	mutex.RLock()
+	defer mutex.RUnlock()
+	return reg.sorted
+}
+
+// lookup returns a command by its name.
+func lookup(name string) (Command, bool) {
+	name = normName(name)
+	mutex.RLock()
+	defer mutex.RUnlock()
+	c, ok := reg.commands[name]
+	return c, ok
+}
+
+// Stderr is the writer used for the error output
+// of the application:
+// error and usage messages,
+// and console log messages.
+// By default it is the standard error.
+var Stderr io.Writer = os.Stderr
+
+// stderr is a writer that writes on Stderr.
+type stderr struct{}
+
+func (stderr) Write(p []byte) (int, error) {
+	return Stderr.Write(p)
 }
 
 // Name stores the application name, the default is based on the arguments of
 // the program.
 var Name = os.Args[0]
 
+// appFlagSet is the flag set of the application flags
+// used by Run.
+var appFlagSet *flag.FlagSet
+
+// appFlags returns the flag set of the application flags,
+// the flags given before the command name.
+// As the flags are set to its default values,
+// it should be called only once.
+func appFlags() *flag.FlagSet {
+	fs := flag.NewFlagSet(Name, flag.ContinueOnError)
+	fs.Usage = func() { printUsage(Stderr) }
+	fs.SetOutput(stderr{})
+	registerLogFlags(fs)
+	registerDepsFlags(fs)
+	registerTermFlags(fs)
+	registerExecFlags(fs)
+	registerRandFlags(fs)
+	registerRecordFlags(fs)
+	registerWarnFlags(fs)
+	registerTimeoutFlags(fs)
+	registerGraceFlags(fs)
+	registerOutputFlags(fs)
+	registerPorcelainFlags(fs)
+	registerPlanFlags(fs)
+	registerCheckpointFlags(fs)
+	registerBrowserFlags(fs)
+	registerHTTPFlags(fs)
+	registerCacheFlags(fs)
+	registerOfflineFlags(fs)
+	registerHintFlags(fs)
+	registerReportFlags(fs)
+	registerGlobalFlags(fs)
+	return fs
+}
+
 // Run runs the application.
+//
+// The application flags are parsed
+// in its own flag set,
+// so flag.CommandLine is not used.
 func Run() {
-	flag.Usage = usage
-	flag.Parse()
+	RunContext(context.Background())
+}
+
+// RunContext runs the application
+// with the given context
+// as the parent of the context of the command.
+// If the context is cancelled,
+// the context of the command is cancelled.
+func RunContext(ctx context.Context) {
+	fs := appFlags()
+	appFlagSet = fs
+	if err := bindEnv(fs, ""); err != nil {
+		fmt.Fprintf(Stderr, "%s: %v\n", Name, err)
+		Exit(1)
+		return
+	}
+	if err := fs.Parse(os.Args[1:]); err != nil {
+		// usage is already reported by the flag set
+		Exit(1)
+		return
+	}
+
+	rec, err := startRecord(fs)
+	if err != nil {
+		fmt.Fprintf(Stderr, "%s: %v\n", Name, err)
+		Exit(1)
+		return
+	}
+	if rec != nil {
+		OnExit(rec.finish)
+	}
+
+	if err := LoadConfig(); err != nil {
+		if !fixesConfig(fs.Args()) {
+			fmt.Fprintf(Stderr, "%s: %v\n", Name, err)
+			Exit(1)
+			return
+		}
+		// help and config are run,
+		// so the user can fix the configuration
+		Warn("%v", err)
+	}
+	lf, err := openLog()
+	if err != nil {
+		fmt.Fprintf(Stderr, "%s: %v\n", Name, err)
+		Exit(1)
+		return
+	}
+	if lf != nil {
+		OnExit(func(int) { lf.Close() })
+	}
+	done, err := openOutputFile()
+	if err != nil {
+		fmt.Fprintf(Stderr, "%s: %v\n", Name, err)
+		Exit(1)
+		return
+	}
+	if done != nil {
+		OnExit(done)
+	}
+	if done := openReport(); done != nil {
+		OnExit(done)
+	}
+	if err := firstRun(); err != nil {
+		fmt.Fprintf(Stderr, "%s: %v\n", Name, err)
+		Exit(1)
+		return
+	}
+
+	code := dispatch(ctx, fs.Args(), os.Args[1:])
+	if code != 0 {
+		Exit(code)
+		return
+	}
+	finish(0)
+}
+
+// Dispatch runs the command given by the arguments,
+// that should not include the application flags,
+// and returns the exit code,
+// without calling the exit function.
+//
+// It is intended to embed or fuzz the dispatcher,
+// applications should use Run.
+// As the state of the package is shared,
+// it is run one at a time
+// with Call and the Dispatch method of App.
+func Dispatch(args []string) int {
+	appMutex.Lock()
+	defer appMutex.Unlock()
+	return dispatch(context.Background(), args, nil)
+}
 
-	args := flag.Args()
+// dispatch runs the command given by the arguments
+// and returns the exit code.
+// The cmdLine is the command line of the process
+// used to run the application again
+// with administrator privileges,
+// it is nil if the command is not run
+// from the command line.
+func dispatch(ctx context.Context, args, cmdLine []string) int {
 	if len(args) < 1 {
-		usage()
+		def := defaultCommand()
+		if def == "" {
+			printUsage(Stderr)
+			return 1
+		}
+		args = []string{def}
 	}
 
-	mutex.Lock()
-	c, ok := commands[args[0]]
-	mutex.Unlock()
+	// '-' reads a script from the standard input
+	if args[0] == "-" && len(args) == 1 {
+		args = []string{"run-script", "-"}
+	}
+	args, err := expandAlias(args)
+	if err != nil {
+		fmt.Fprintf(Stderr, "%s: %v\n", Name, err)
+		return 1
+	}
+
+	c, ok := lookup(args[0])
+	if !ok {
+		if path, ok := findPlugin(args[0]); ok {
+			return runPlugin(path, args[1:])
+		}
+	}
 	if !ok || !c.Runnable() {
-		fmt.Fprintf(os.Stderr, "%s: unknown subcommand %s\nRun '%s help' for usage.\n", Name, args[0], Name)
-		os.Exit(1)
+		fmt.Fprintf(Stderr, "%s: unknown subcommand %s%s\n", Name, args[0], didYouMean(normName(args[0]), commandNames()))
+		if d, ok := deprecation(args[0], ""); ok {
+			fmt.Fprintf(Stderr, "%s: %s\n", Name, depNote(fmt.Sprintf("command %q", args[0]), d, d.Replacement))
+		}
+		fmt.Fprintf(Stderr, "Run '%s help' for usage.\n", Name)
+		return 1
+	}
+
+	resetWarnings()
+	fs, err := parseArgs(c.Name(), c, args[1:], nil)
+	if err != nil {
+		if err != flag.ErrHelp {
+			fmt.Fprintf(Stderr, "%s: %s: %v\n", Name, c.Name(), err)
+		}
+		if _, ok := err.(*usageError); !ok && err != flag.ErrHelp {
+			return 1
+		}
+		printCmdUsage(Stderr, c, args[1:])
+		return ExitUsage
+	}
+	warnDeprecated(c, fs)
+
+	// the command of a group is run
+	// as any other command
+	top, path := c, c.Name()
+	if g, ok := c.(*Group); ok {
+		c, path, fs, err = g.leaf(path, fs.Args(), nil)
+		if err != nil {
+			logger.Info("done", "command", path, "error", err.Error())
+			return errHandler(top, err)
+		}
+		if c == nil {
+			// help of the command
+			return 0
+		}
+	}
+
+	if code, elevated := elevateCommand(c, cmdLine); elevated {
+		return code
 	}
+	startReport(path, fs.Args())
+	parent, stopSignals := signalContext(ctx)
+	done := startContext(parent, c)
+	stopGrace := watchGrace(Context())
+	if withDeps {
+		err = runDeps(c)
+	}
+	if err == nil {
+		logger.Debug("run", "command", path, "args", fs.Args())
+		startCheckpoints(path, args)
+		err = runCommand(c, fs.Args())
+		if err == nil {
+			endCheckpoints()
+		}
+	}
+	// the grace period is stopped
+	// before the context is cancelled,
+	// as the command already returned
+	stopGrace()
+	err = done(err)
+	stopSignals()
+	if se, ok := context.Cause(parent).(*signalError); ok {
+		err = &signalError{sig: se.sig, err: err}
+	}
+	if err == nil && Strict && Warnings() > 0 {
+		err = errors.Errorf("%s (strict mode)", Plural(Warnings(), "warning", ""))
+	}
+	if err != nil {
+		logger.Info("done", "command", path, "error", err.Error())
+		reportError(err)
+		return errHandler(top, err)
+	}
+	if n := Warnings(); n > 0 {
+		fmt.Fprintf(Stderr, "%s\n", Mark(Warning, fmt.Sprintf("%s: %s: completed with %s", Name, path, Plural(n, "warning", ""))))
+	}
+	showHint(path)
+	logger.Info("done", "command", path)
+	return 0
+}
 
-	fs := flag.NewFlagSet(c.Name(), flag.ExitOnError)
-	fs.Usage = func() { Usage(c) }
+// parseArgs parses the flags and arguments of a command,
+// with the flags inherited from the groups of the command,
+// and the values of the environment variables,
+// and validates the arguments.
+// The path of the command names the flag set.
+// Errors of the flags and the arguments
+// are returned as a *usageError,
+// except flag.ErrHelp,
+// returned if the help is requested.
+func parseArgs(path string, c Command, args []string, inherited []*flag.FlagSet) (*flag.FlagSet, error) {
+	fs := flag.NewFlagSet(path, flag.ContinueOnError)
+	fs.SetOutput(io.Discard) // flag errors are returned
 	c.Register(fs)
-	fs.Parse(args[1:])
-	err := c.Run(fs.Args())
+	for i := len(inherited) - 1; i >= 0; i-- {
+		inheritFlags(fs, inherited[i])
+	}
+	if err := bindEnv(fs, c.Name()); err != nil {
+		return nil, err
+	}
+	if err := fs.Parse(negativeArgs(fs, args)); err != nil {
+		if err == flag.ErrHelp {
+			return nil, err
+		}
+		return nil, &usageError{err}
+	}
+	if v, ok := c.(ValidatorCommand); ok {
+		if err := v.Validate(fs.Args()); err != nil {
+			return nil, &usageError{err}
+		}
+	}
+	return fs, nil
+}
+
+// invoke parses the flags and arguments of a command
+// and runs it.
+// It is used to run commands within other commands.
+func invoke(c Command, args []string) error {
+	fs, err := parseArgs(c.Name(), c, args, nil)
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "%s: %s: %v\n", Name, c.Name(), err)
-		os.Exit(1)
+		return err
 	}
+	logger.Debug("run", "command", c.Name(), "args", fs.Args())
+	return runCommand(c, fs.Args())
 }
 
-// usage printd application's help and exists.
-func usage() {
-	printUsage(os.Stderr)
-	os.Exit(1)
+// runCommand runs a command,
+// checking its privileges,
+// acquiring the application lock if the command is exclusive,
+// and closing the command after it is run.
+// The command is run through the middlewares set with Use.
+// The command output is flushed when the command finishes.
+func runCommand(c Command, args []string) (err error) {
+	defer func() {
+		if ferr := Flush(); ferr != nil && err == nil {
+			err = errors.Wrap(ferr, "output")
+		}
+	}()
+	if err := checkPrivileges(c); err != nil {
+		return err
+	}
+	if e, ok := c.(ExclusiveCommand); ok && e.Exclusive() {
+		unlock, err := Lock()
+		if err != nil {
+			return err
+		}
+		unlock = sync.OnceFunc(unlock)
+		defer onForcedExit(unlock)()
+		defer unlock()
+	}
+	if cl, ok := c.(CloserCommand); ok {
+		var cerr error
+		closeCmd := sync.OnceFunc(func() { cerr = cl.Close() })
+		defer onForcedExit(closeCmd)()
+		defer func() {
+			closeCmd()
+			if cerr == nil {
+				return
+			}
+			if err == nil {
+				err = errors.Wrap(cerr, "close")
+				return
+			}
+			err = errors.Errorf("%v; close: %v", err, cerr)
+		}()
+	}
+	return chain(c)(Context(), c, args)
+}
+
+// errHandler is the function
+// used to report the error of a command.
+var errHandler = defaultErrHandler
+
+// SetErrorHandler sets the function used to report
+// the error returned by a command.
+// The handler returns the exit code of the application,
+// if the code is 0 the application finish without error.
+//
+// The default handler prints the application name,
+// the command name and the error in the standard error
+// (a list of errors, as Errors, is printed as a numbered list),
+// and returns the exit code of the error
+// (see ExitCoder),
+// by default 1.
+func SetErrorHandler(h func(c Command, err error) int) {
+	if h == nil {
+		h = defaultErrHandler
+	}
+	errHandler = h
+}
+
+func defaultErrHandler(c Command, err error) int {
+	if gitHubActions() {
+		return githubErrHandler(c, err)
+	}
+	l, ok := err.(interface{ Unwrap() []error })
+	if !ok || len(l.Unwrap()) < 2 {
+		fmt.Fprintf(Stderr, "%s\n", Mark(Failure, fmt.Sprintf("%s: %s: %v", Name, c.Name(), err)))
+		return exitCode(err)
+	}
+	list := l.Unwrap()
+	fmt.Fprintf(Stderr, "%s\n", Mark(Failure, fmt.Sprintf("%s: %s: %d errors:", Name, c.Name(), len(list))))
+	for i, e := range list {
+		fmt.Fprintf(Stderr, "    %d. %v\n", i+1, e)
+	}
+	return exitCode(err)
+}
+
+// exit is the function used to finish the application.
+var exit = os.Exit
+
+// exitHooks are the functions called
+// when the application finishes.
+var (
+	exitMutex sync.Mutex
+	exitHooks []func(code int)
+)
+
+// OnExit adds a function to be called
+// when the application finishes,
+// either when Run returns
+// or when the application exits with Exit,
+// for example to restore the terminal state.
+// The function receives the exit code.
+// Functions are called in reverse order,
+// and only once.
+func OnExit(f func(code int)) {
+	exitMutex.Lock()
+	exitHooks = append(exitHooks, f)
+	exitMutex.Unlock()
+}
+
+// finish flushes the output
+// and runs the exit hooks.
+func finish(code int) {
+	Flush()
+	exitMutex.Lock()
+	hooks := exitHooks
+	exitHooks = nil
+	exitMutex.Unlock()
+	for i := len(hooks) - 1; i >= 0; i-- {
+		hooks[i](code)
+	}
+}
+
+// Exit finishes the application with the given exit code.
+// Commands should use it instead of os.Exit,
+// as it flushes the output
+// and calls the functions added with OnExit
+// before calling the exit function.
+func Exit(code int) {
+	finish(code)
+	exit(code)
+}
+
+// SetExitFunc sets the function used to finish the application
+// with an exit code,
+// after the output is flushed
+// and the exit hooks are called.
+// By default it is os.Exit.
+//
+// If the function returns,
+// Run returns immediately.
+func SetExitFunc(f func(code int)) {
+	if f == nil {
+		f = os.Exit
+	}
+	exit = f
 }
@@ -0,0 +1,66 @@
+// Copyright (c) 2015, J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD-style license that can be found in the LICENSE file.
+
+package cmdapp
+
+import (
+	"flag"
+	"io"
+	"testing"
+
+	"github.com/pkg/errors"
+)
+
+// validCmd is a command
+// that requires a single argument.
+type validCmd struct {
+	n   int
+	ran bool
+}
+
+func (c *validCmd) Name() string              { return "valid-test" }
+func (c *validCmd) Args() string              { return "[-n <number>] <item>" }
+func (c *validCmd) Short() string             { return "a command that validates its arguments" }
+func (c *validCmd) Long() string              { return "" }
+func (c *validCmd) Register(fs *flag.FlagSet) { fs.IntVar(&c.n, "n", 0, "a number") }
+func (c *validCmd) Runnable() bool            { return true }
+func (c *validCmd) Run(args []string) error {
+	c.ran = true
+	return nil
+}
+
+func (c *validCmd) Validate(args []string) error {
+	if len(args) != 1 {
+		return errors.New("expecting an item")
+	}
+	return nil
+}
+
+func TestDispatchExitCode(t *testing.T) {
+	tests := []struct {
+		args []string
+		code int
+		ran  bool
+	}{
+		{args: []string{"valid-test", "x"}, ran: true},
+		{args: []string{"valid-test", "-n", "3", "x"}, ran: true},
+		{args: []string{"valid-test"}, code: ExitUsage},
+		{args: []string{"valid-test", "x", "y"}, code: ExitUsage},
+		{args: []string{"valid-test", "-m", "x"}, code: ExitUsage},
+		{args: []string{"valid-test", "-n", "many", "x"}, code: ExitUsage},
+		{args: []string{"valid-test", "-n"}, code: ExitUsage},
+	}
+	for _, test := range tests {
+		c := &validCmd{}
+		a := NewApp("testapp", "a test application")
+		a.Stdout, a.Stderr = io.Discard, io.Discard
+		a.Add(c)
+		if code := a.Dispatch(test.args); code != test.code {
+			t.Errorf("%v: exit code %d, want %d", test.args, code, test.code)
+		}
+		if c.ran != test.ran {
+			t.Errorf("%v: run %v, want %v", test.args, c.ran, test.ran)
+		}
+	}
+}
@@ -0,0 +1,188 @@
+// Copyright (c) 2015, J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD-style license that can be found in the LICENSE file.
+
+package cmdapp
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sync"
+)
+
+// An App is a command line application
+// with its own commands,
+// name,
+// and writers,
+// for example to host several applications
+// in a test binary.
+//
+// The package level functions
+// (Add, Run, Dispatch, ...)
+// use the default application,
+// defined by the Name and Short variables.
+//
+// Applications are not independent.
+// Only the commands, name and writers
+// belong to the application,
+// the rest of the state of the package
+// (configuration, aliases, environment bindings,
+// middlewares, hints, policy, ...)
+// is shared by all the applications.
+// While an application is run,
+// the state of the package is replaced
+// by the one of the application,
+// so the applications,
+// Dispatch and Call,
+// are run one at a time.
+// An application should not be run
+// while Run is running.
+type App struct {
+	// Name is the name of the application.
+	Name string
+
+	// Short is a short description of the application.
+	Short string
+
+	// Stdout is the writer for the standard output
+	// of the application,
+	// written by the commands with Output.
+	// If nil,
+	// the standard output of the process is used.
+	Stdout io.Writer
+
+	// Stderr is the writer for the error output
+	// of the application.
+	// If nil,
+	// the standard error of the process is used.
+	Stderr io.Writer
+
+	reg *registry
+}
+
+// NewApp returns a new application
+// with the framework commands
+// (as help).
+func NewApp(name, short string) *App {
+	a := &App{
+		Name:  name,
+		Short: short,
+		reg:   newRegistry(),
+	}
+
+	mutex.Lock()
+	defer mutex.Unlock()
+	a.reg.add(help{})
+	for _, c := range framework {
+		a.reg.add(c)
+		a.reg.builtins[normName(c.Name())] = true
+	}
+	return a
+}
+
+// Add adds a new command to the application.
+// As the package level Add,
+// command names should be unique,
+// otherwise it will trigger a panic.
+func (a *App) Add(c Command) {
+	mutex.Lock()
+	a.reg.add(c)
+	mutex.Unlock()
+	if err := checkPolicy(c); err != nil {
+		panic(fmt.Sprintf("cmdapp: policy: %v", err))
+	}
+}
+
+// Mount adds the commands of an application
+// as a group of the default application,
+// so 'host prefix <command>' runs a command
+// of the mounted application,
+// for example to embed a shared toolbox
+// in several applications.
+// The help topics and guides of the application
+// are also added to the group,
+// but not its framework commands
+// (as help).
+//
+// The group is built when Mount is called,
+// commands added later to the application
+// are not mounted.
+// Mounted commands run with the state
+// of the host application
+// (configuration, aliases, environment bindings, ...).
+func Mount(prefix string, a *App) {
+	Add(a.group(prefix))
+}
+
+// Mount adds the commands of an application
+// as a group of the application.
+// See the package level Mount.
+func (a *App) Mount(prefix string, sub *App) {
+	a.Add(sub.group(prefix))
+}
+
+// group returns a group
+// with the commands of the application.
+func (a *App) group(prefix string) *Group {
+	g := &Group{
+		Cmd:  prefix,
+		Desc: a.Short,
+		Text: fmt.Sprintf("The commands of %s.", a.Name),
+	}
+	mutex.RLock()
+	defer mutex.RUnlock()
+	for _, c := range a.reg.sorted {
+		name := normName(c.Name())
+		if name == "help" || a.reg.builtins[name] {
+			continue
+		}
+		g.Add(c)
+	}
+	return g
+}
+
+// appMutex serializes the applications,
+// Dispatch and Call,
+// as the state of the package is replaced
+// while an application is run.
+var appMutex sync.Mutex
+
+// Dispatch runs the command of the application
+// given by the arguments,
+// that should not include the application flags,
+// and returns the exit code.
+//
+// Applications are run one at a time.
+func (a *App) Dispatch(args []string) int {
+	return a.DispatchContext(context.Background(), args)
+}
+
+// DispatchContext is like Dispatch
+// with the given context
+// as the parent of the context of the command.
+func (a *App) DispatchContext(ctx context.Context, args []string) int {
+	appMutex.Lock()
+	defer appMutex.Unlock()
+
+	mutex.Lock()
+	prevReg := reg
+	reg = a.reg
+	mutex.Unlock()
+	prevName, prevShort, prevErr := Name, Short, Stderr
+	Name, Short = a.Name, a.Short
+	if a.Stderr != nil {
+		Stderr = a.Stderr
+	}
+	defer func() {
+		mutex.Lock()
+		reg = prevReg
+		mutex.Unlock()
+		Name, Short, Stderr = prevName, prevShort, prevErr
+	}()
+
+	if a.Stdout != nil {
+		defer redirectOutput(a.Stdout)()
+	}
+	return dispatch(ctx, args, nil)
+}
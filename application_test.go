@@ -0,0 +1,234 @@
+// Copyright (c) 2015, J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD-style license that can be found in the LICENSE file.
+
+package cmdapp
+
+import (
+	"bytes"
+	"flag"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestAppDispatchConcurrent(t *testing.T) {
+	a := NewApp("testapp", "a test application")
+	var out, errOut bytes.Buffer
+	a.Stdout, a.Stderr = &out, &errOut
+
+	oldErr := Stderr
+	Stderr = io.Discard
+	defer func() { Stderr = oldErr }()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			a.Dispatch([]string{"help"})
+		}()
+		go func() {
+			defer wg.Done()
+			Dispatch([]string{"no-such-command"})
+		}()
+	}
+	wg.Wait()
+
+	if !strings.Contains(out.String(), "testapp") {
+		t.Errorf("application output %q: want the application name", out.String())
+	}
+}
+
+func TestAppCommands(t *testing.T) {
+	a := NewApp("testapp", "a test application")
+	a.Add(&Group{Cmd: "only-in-app", Desc: "a group"})
+	if _, ok := a.reg.commands["only-in-app"]; !ok {
+		t.Errorf("command not added to the application")
+	}
+	if _, ok := lookup("only-in-app"); ok {
+		t.Errorf("command added to the default application")
+	}
+}
+
+// mountCmd is a command used to test Mount.
+type mountCmd struct {
+	name string
+	n    int
+	run  func(c *mountCmd, args []string) error
+}
+
+func (c *mountCmd) Name() string  { return c.name }
+func (c *mountCmd) Args() string  { return "[-n <number>] <item>" }
+func (c *mountCmd) Short() string { return c.name + " an item" }
+func (c *mountCmd) Long() string  { return "The command " + c.name + " of the toolbox." }
+func (c *mountCmd) Register(fs *flag.FlagSet) {
+	fs.IntVar(&c.n, "n", 1, "number of items")
+}
+func (c *mountCmd) Runnable() bool          { return true }
+func (c *mountCmd) Run(args []string) error { return c.run(c, args) }
+
+func TestMount(t *testing.T) {
+	var got []string
+	tools := NewApp("toolbox", "a shared toolbox")
+	tools.Add(&mountCmd{name: "add", run: func(c *mountCmd, args []string) error {
+		got = append([]string{strconv.Itoa(c.n)}, args...)
+		return nil
+	}})
+	tools.Add(&Guide{Topic: "toolbox-guide", Title: "using the toolbox", Desc: "how to use the toolbox", Text: "Use the toolbox."})
+
+	var out bytes.Buffer
+	host := NewApp("host", "a host application")
+	host.Stdout, host.Stderr = &out, io.Discard
+	host.Add(&mountCmd{name: "doc", run: func(c *mountCmd, args []string) error {
+		for _, w := range []func(io.Writer) error{WriteMarkdown, WriteMan, WriteCommandTable, WriteJSON} {
+			if err := w(Output()); err != nil {
+				return err
+			}
+		}
+		return WriteCompletion(Output(), args[0])
+	}})
+	host.Mount("tools", tools)
+
+	if code := host.Dispatch([]string{"tools", "add", "-n", "3", "x"}); code != 0 {
+		t.Fatalf("tools add: exit code %d", code)
+	}
+	if strings.Join(got, " ") != "3 x" {
+		t.Errorf("tools add: got %q, want %q", got, "3 x")
+	}
+
+	tests := []struct {
+		args []string
+		want []string
+	}{
+		{[]string{"help", "tools"}, []string{"host tools <command>", "add", "toolbox-guide"}},
+		{[]string{"help", "tools", "add"}, []string{"host tools add [-n <number>] <item>", "The command add of the toolbox."}},
+		{[]string{"help", "tools", "toolbox-guide"}, []string{"Use the toolbox."}},
+		{[]string{"doc", "bash"}, []string{
+			"### tools add",
+			".SS \"host tools add",
+			"| tools add | `host tools add",
+			`"name": "add"`,
+			`"tools add"|"tools add "*)`,
+			`"tools"|"tools "*)`,
+		}},
+		{[]string{"doc", "fish"}, []string{"-n '__fish_seen_subcommand_from tools' -a add", "-n '__fish_seen_subcommand_from tools; and __fish_seen_subcommand_from add' -o n"}},
+		{[]string{"doc", "powershell"}, []string{"$cmdpath -eq 'tools add'"}},
+	}
+	for _, test := range tests {
+		out.Reset()
+		if code := host.Dispatch(test.args); code != 0 {
+			t.Errorf("%v: exit code %d", test.args, code)
+			continue
+		}
+		for _, w := range test.want {
+			if !strings.Contains(out.String(), w) {
+				t.Errorf("%v: output without %q:\n%s", test.args, w, out.String())
+			}
+		}
+	}
+
+	for _, args := range [][]string{{"tools", "help"}, {"tools", "completion", "bash"}} {
+		if code := host.Dispatch(args); code != ExitUsage {
+			t.Errorf("%v: exit code %d, want %d", args, code, ExitUsage)
+		}
+	}
+}
+
+// exampleCmd is a command with examples.
+type exampleCmd struct {
+	mountCmd
+}
+
+func (c *exampleCmd) Examples() []Example {
+	return []Example{{Desc: "a quoted argument", Argv: []string{"it's"}}}
+}
+
+func TestDocShell(t *testing.T) {
+	t.Setenv("SHELL", "/usr/bin/fish")
+	t.Chdir(t.TempDir())
+
+	var out bytes.Buffer
+	a := NewApp("docapp", "a test application")
+	a.Stdout, a.Stderr = &out, io.Discard
+	a.Add(&exampleCmd{mountCmd{name: "quote", run: func(c *mountCmd, args []string) error {
+		return nil
+	}}})
+	a.Add(&mountCmd{name: "doc", run: func(c *mountCmd, args []string) error {
+		if err := WriteMarkdown(Output()); err != nil {
+			return err
+		}
+		return WriteMan(Output())
+	}})
+
+	posix := QuoteArgs("sh", []string{"it's"})
+	fish := QuoteArgs("fish", []string{"it's"})
+	if posix == fish {
+		t.Fatalf("same quoting for sh and fish: %s", posix)
+	}
+
+	if code := a.Dispatch([]string{"doc"}); code != 0 {
+		t.Fatalf("doc: exit code %d", code)
+	}
+	if code := a.Dispatch([]string{"help", "documentation"}); code != 0 {
+		t.Fatalf("help documentation: exit code %d", code)
+	}
+	b, err := os.ReadFile("doc.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, doc := range []string{out.String(), string(b)} {
+		if !strings.Contains(doc, "quote "+posix) {
+			t.Errorf("documentation without %q:\n%s", "quote "+posix, doc)
+		}
+	}
+
+	out.Reset()
+	if code := a.Dispatch([]string{"help", "quote"}); code != 0 {
+		t.Fatalf("help quote: exit code %d", code)
+	}
+	if !strings.Contains(out.String(), "quote "+fish) {
+		t.Errorf("help without %q:\n%s", "quote "+fish, out.String())
+	}
+}
+
+func TestAppStdout(t *testing.T) {
+	defer func(m Buffering) { OutputMode = m }(OutputMode)
+	tests := []struct {
+		mode  Buffering
+		write []string
+		want  string
+	}{
+		{mode: LineBuffered, write: []string{"one\n", "two"}, want: "one\ntwo"},
+		{mode: Unbuffered, write: []string{"one\n", "two"}, want: "one\ntwo"},
+		{mode: FullyBuffered, write: []string{"one\n", "two"}, want: "one\ntwo"},
+	}
+	for _, test := range tests {
+		OutputMode = test.mode
+		var out bytes.Buffer
+		a := NewApp("testapp", "a test application")
+		a.Stdout, a.Stderr = &out, io.Discard
+		std := os.Stdout
+		var swapped bool
+		a.Add(&mountCmd{name: "write", run: func(c *mountCmd, args []string) error {
+			swapped = os.Stdout != std
+			for _, w := range test.write {
+				io.WriteString(Output(), w)
+			}
+			return nil
+		}})
+
+		if code := a.Dispatch([]string{"write"}); code != 0 {
+			t.Fatalf("mode %d: exit code %d", test.mode, code)
+		}
+		if swapped {
+			t.Errorf("mode %d: standard output of the process replaced", test.mode)
+		}
+		if out.String() != test.want {
+			t.Errorf("mode %d: output %q, want %q", test.mode, out.String(), test.want)
+		}
+	}
+}
@@ -0,0 +1,445 @@
+// Copyright (c) 2015, J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD-style license that can be found in the LICENSE file.
+
+// Package archive extracts tar,
+// tar.gz,
+// and zip archives
+// for cmdapp applications,
+// for example downloaded data bundles.
+//
+// Entries that would be written outside the destination directory
+// (absolute paths, paths with '..',
+// links that point outside the directory,
+// also through other links of the archive,
+// or entries written through a link)
+// are rejected with ErrUnsafePath,
+// before any file is written.
+//
+// The progress is reported with cmdapp.NewProgress,
+// and if cmdapp.DryRun is set,
+// the entries are listed in cmdapp.Output
+// instead of being extracted.
+package archive
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/js-arias/cmdapp"
+	"github.com/pkg/errors"
+)
+
+// ErrUnsafePath is the error returned
+// when an entry of an archive
+// would be written outside the destination directory.
+var ErrUnsafePath = errors.New("unsafe path")
+
+// An entry is an entry of an archive.
+type entry struct {
+	name string
+	mode fs.FileMode
+	link string // target of a link
+	hard bool   // link is a hard link
+}
+
+// Extract extracts an archive in a directory.
+// The format of the archive
+// (tar, tar.gz, or zip)
+// is detected from its content.
+//
+// All the entries are checked
+// before any file is written,
+// so an unsafe archive is not partially extracted.
+func Extract(name, dir string) error {
+	entries, err := List(name)
+	if err != nil {
+		return err
+	}
+	if cmdapp.DryRun {
+		for _, e := range entries {
+			fmt.Fprintln(cmdapp.Output(), filepath.Join(dir, filepath.FromSlash(e)))
+		}
+		return nil
+	}
+
+	cmdapp.Logger().Info("archive: extract", "file", name, "dir", dir)
+	p := cmdapp.NewProgress()
+	p.Start(filepath.Base(name))
+	defer p.Done()
+
+	err = walk(name, p, func(e entry, r io.Reader) error {
+		return write(dir, e, r)
+	})
+	if err != nil {
+		return errors.Wrapf(err, "extract %s", name)
+	}
+	return nil
+}
+
+// List returns the names of the entries of an archive.
+// It returns an error wrapping ErrUnsafePath
+// if an entry is unsafe.
+func List(name string) ([]string, error) {
+	var names []string
+	c := &checker{links: make(map[string]string)}
+	err := walk(name, nil, func(e entry, r io.Reader) error {
+		if err := c.check(e); err != nil {
+			return err
+		}
+		names = append(names, e.name)
+		return nil
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "extract %s", name)
+	}
+	return names, nil
+}
+
+// A checker checks the entries of an archive,
+// in order,
+// keeping the symbolic links of the previous entries,
+// as a link target can go through other links.
+type checker struct {
+	// links are the targets of the symbolic links,
+	// by the clean path of the link.
+	links map[string]string
+}
+
+// check returns an error
+// if an entry is unsafe.
+func (c *checker) check(e entry) error {
+	p, ok := clean(e.name)
+	if !ok {
+		return errors.Wrap(ErrUnsafePath, e.name)
+	}
+	if c.throughLink(parent(p)) {
+		return errors.Wrapf(ErrUnsafePath, "%s: write through link", e.name)
+	}
+	switch {
+	case e.hard:
+		t, ok := clean(e.link)
+		if !ok || c.throughLink(t) {
+			return errors.Wrapf(ErrUnsafePath, "%s: link to %s", e.name, e.link)
+		}
+	case e.link != "":
+		link := filepath.ToSlash(e.link)
+		if strings.HasPrefix(link, "/") || filepath.IsAbs(e.link) || filepath.VolumeName(e.link) != "" {
+			return errors.Wrapf(ErrUnsafePath, "%s: link to %s", e.name, e.link)
+		}
+		// symbolic links are relative to the directory of the link
+		if _, ok := c.resolve(parent(p), link, 0); !ok {
+			return errors.Wrapf(ErrUnsafePath, "%s: link to %s", e.name, e.link)
+		}
+		c.links[p] = link
+	case !e.mode.IsDir():
+		// a file replaces a link
+		delete(c.links, p)
+	}
+	return nil
+}
+
+// maxLinks is the maximum number of links
+// followed when a path is resolved.
+const maxLinks = 255
+
+// resolve returns the path
+// of a relative path from a directory,
+// following the symbolic links of the archive
+// as the operating system does,
+// and false if the path goes outside the destination directory.
+// Paths are relative to the destination directory,
+// using slashes,
+// and the destination directory is the empty path.
+func (c *checker) resolve(dir, rel string, depth int) (string, bool) {
+	if depth > maxLinks {
+		return "", false
+	}
+	cur := dir
+	for _, n := range strings.Split(rel, "/") {
+		switch n {
+		case "", ".":
+			continue
+		case "..":
+			if cur == "" {
+				return "", false
+			}
+			cur = parent(cur)
+			continue
+		}
+		next := path.Join(cur, n)
+		if t, ok := c.links[next]; ok {
+			r, ok := c.resolve(cur, t, depth+1)
+			if !ok {
+				return "", false
+			}
+			cur = r
+			continue
+		}
+		cur = next
+	}
+	return cur, true
+}
+
+// throughLink reports whether a path,
+// or any of its parents,
+// is a symbolic link of the archive.
+func (c *checker) throughLink(p string) bool {
+	for ; p != ""; p = parent(p) {
+		if _, ok := c.links[p]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// clean returns the clean path of a name,
+// with slashes,
+// and false if the name is outside the destination directory.
+// The destination directory is the empty path.
+func clean(name string) (string, bool) {
+	p, ok := inside(".", name)
+	if !ok {
+		return "", false
+	}
+	p = filepath.ToSlash(p)
+	if p == "." {
+		p = ""
+	}
+	return p, true
+}
+
+// parent returns the parent of a clean path.
+func parent(p string) string {
+	d := path.Dir(p)
+	if d == "." || d == "/" {
+		return ""
+	}
+	return d
+}
+
+// inside returns the path of a name in a directory,
+// and false if the path is outside the directory.
+func inside(dir, name string) (string, bool) {
+	if name == "" || strings.HasPrefix(name, "/") || strings.HasPrefix(name, `\`) {
+		return "", false
+	}
+	name = filepath.FromSlash(name)
+	if filepath.IsAbs(name) || filepath.VolumeName(name) != "" {
+		return "", false
+	}
+	p := filepath.Join(dir, name)
+	rel, err := filepath.Rel(dir, p)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", false
+	}
+	return p, true
+}
+
+// write writes an entry in the destination directory.
+func write(dir string, e entry, r io.Reader) error {
+	p, ok := inside(dir, e.name)
+	if !ok {
+		return errors.Wrap(ErrUnsafePath, e.name)
+	}
+	if err := noLinks(dir, filepath.Dir(p)); err != nil {
+		return errors.Wrap(err, e.name)
+	}
+	if e.mode.IsDir() {
+		return os.MkdirAll(p, 0755)
+	}
+	if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+		return err
+	}
+
+	// never write through an existing link
+	if fi, err := os.Lstat(p); err == nil && fi.Mode()&fs.ModeSymlink != 0 {
+		if err := os.Remove(p); err != nil {
+			return err
+		}
+	}
+
+	switch {
+	case e.hard:
+		src, _ := inside(dir, e.link)
+		if err := noLinks(dir, src); err != nil {
+			return errors.Wrap(err, e.name)
+		}
+		in, err := os.Open(src)
+		if err != nil {
+			return err
+		}
+		defer in.Close()
+		r = in
+	case e.mode&fs.ModeSymlink != 0:
+		os.Remove(p)
+		return os.Symlink(filepath.FromSlash(e.link), p)
+	case !e.mode.IsRegular():
+		cmdapp.Warn("extract: %s: unsupported file type, skipped", e.name)
+		return nil
+	}
+
+	f, err := os.OpenFile(p, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, e.mode.Perm()|0600)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(f, r); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// noLinks returns an error
+// if a path inside a directory
+// goes through a symbolic link,
+// as links created by the archive
+// could point outside the directory.
+func noLinks(dir, p string) error {
+	rel, err := filepath.Rel(dir, p)
+	if err != nil || rel == "." {
+		return err
+	}
+	p = dir
+	for _, c := range strings.Split(rel, string(filepath.Separator)) {
+		p = filepath.Join(p, c)
+		fi, err := os.Lstat(p)
+		if os.IsNotExist(err) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if fi.Mode()&fs.ModeSymlink != 0 {
+			return errors.Wrapf(ErrUnsafePath, "write through link %s", rel)
+		}
+	}
+	return nil
+}
+
+// walk calls a function for each entry of an archive.
+// If p is not nil,
+// the progress is updated with the read data.
+func walk(name string, p cmdapp.Progress, fn func(entry, io.Reader) error) error {
+	f, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	fi, err := f.Stat()
+	if err != nil {
+		return err
+	}
+
+	br := bufio.NewReader(f)
+	magic, _ := br.Peek(4)
+	if bytes.HasPrefix(magic, []byte("PK\x03\x04")) || bytes.HasPrefix(magic, []byte("PK\x05\x06")) {
+		return walkZip(f, fi.Size(), p, fn)
+	}
+
+	var r io.Reader = br
+	if p != nil {
+		r = &progressReader{r: br, p: p, total: fi.Size()}
+	}
+	if bytes.HasPrefix(magic, []byte{0x1f, 0x8b}) {
+		gz, err := gzip.NewReader(r)
+		if err != nil {
+			return err
+		}
+		defer gz.Close()
+		r = gz
+	}
+	return walkTar(tar.NewReader(r), fn)
+}
+
+// walkTar calls a function for each entry of a tar archive.
+func walkTar(tr *tar.Reader, fn func(entry, io.Reader) error) error {
+	for {
+		h, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		e := entry{name: h.Name, mode: h.FileInfo().Mode()}
+		switch h.Typeflag {
+		case tar.TypeSymlink:
+			e.link = h.Linkname
+		case tar.TypeLink:
+			e.link, e.hard = h.Linkname, true
+		case tar.TypeXGlobalHeader:
+			continue
+		}
+		if err := fn(e, tr); err != nil {
+			return err
+		}
+	}
+}
+
+// walkZip calls a function for each entry of a zip archive.
+func walkZip(f *os.File, size int64, p cmdapp.Progress, fn func(entry, io.Reader) error) error {
+	zr, err := zip.NewReader(f, size)
+	if err != nil {
+		return err
+	}
+	var total int64
+	for _, zf := range zr.File {
+		total += int64(zf.CompressedSize64)
+	}
+
+	var n int64
+	for _, zf := range zr.File {
+		e := entry{name: zf.Name, mode: zf.Mode()}
+		rc, err := zf.Open()
+		if err != nil {
+			return errors.Wrap(err, zf.Name)
+		}
+		var r io.Reader = rc
+		if e.mode&fs.ModeSymlink != 0 {
+			// the content of a link is its target
+			b, err := io.ReadAll(io.LimitReader(rc, 4096))
+			if err != nil {
+				rc.Close()
+				return errors.Wrap(err, zf.Name)
+			}
+			e.link = string(b)
+		}
+		err = fn(e, r)
+		rc.Close()
+		if err != nil {
+			return err
+		}
+		if p != nil {
+			n += int64(zf.CompressedSize64)
+			p.Update(n, total)
+		}
+	}
+	return nil
+}
+
+// progressReader updates a progress
+// with the bytes read.
+type progressReader struct {
+	r     io.Reader
+	p     cmdapp.Progress
+	n     int64
+	total int64
+}
+
+func (r *progressReader) Read(b []byte) (int, error) {
+	n, err := r.r.Read(b)
+	r.n += int64(n)
+	r.p.Update(r.n, r.total)
+	return n, err
+}
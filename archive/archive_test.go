@@ -0,0 +1,283 @@
+// Copyright (c) 2015, J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD-style license that can be found in the LICENSE file.
+
+package archive
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/js-arias/cmdapp"
+	"github.com/pkg/errors"
+)
+
+// A testEntry is an entry of a test archive.
+type testEntry struct {
+	name string
+	typ  byte // tar type flag
+	link string
+	body string
+}
+
+// writeArchive writes an archive
+// in the given format:
+// tar, tgz, or zip.
+func writeArchive(t *testing.T, format string, entries []testEntry) string {
+	t.Helper()
+	name := filepath.Join(t.TempDir(), "test."+format)
+	f, err := os.Create(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	if format == "zip" {
+		zw := zip.NewWriter(f)
+		for _, e := range entries {
+			h := &zip.FileHeader{Name: e.name, Method: zip.Deflate}
+			body := e.body
+			switch e.typ {
+			case tar.TypeDir:
+				h.SetMode(fs.ModeDir | 0755)
+			case tar.TypeSymlink:
+				h.SetMode(fs.ModeSymlink | 0777)
+				body = e.link
+			default:
+				h.SetMode(0644)
+			}
+			w, err := zw.CreateHeader(h)
+			if err != nil {
+				t.Fatal(err)
+			}
+			io.WriteString(w, body)
+		}
+		if err := zw.Close(); err != nil {
+			t.Fatal(err)
+		}
+		return name
+	}
+
+	var w io.Writer = f
+	if format == "tgz" {
+		gz := gzip.NewWriter(f)
+		defer gz.Close()
+		w = gz
+	}
+	tw := tar.NewWriter(w)
+	for _, e := range entries {
+		h := &tar.Header{Name: e.name, Typeflag: e.typ, Linkname: e.link, Mode: 0644, Size: int64(len(e.body))}
+		if e.typ == tar.TypeDir {
+			h.Mode = 0755
+		}
+		if err := tw.WriteHeader(h); err != nil {
+			t.Fatal(err)
+		}
+		io.WriteString(tw, e.body)
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return name
+}
+
+func TestExtract(t *testing.T) {
+	defer func(w io.Writer) { cmdapp.Stderr = w }(cmdapp.Stderr)
+	cmdapp.Stderr = io.Discard
+
+	tests := []struct {
+		name    string
+		formats []string
+		entries []testEntry
+		unsafe  bool
+		links   bool // the archive creates symbolic links
+		files   map[string]string
+	}{
+		{
+			name:    "files",
+			formats: []string{"tar", "tgz", "zip"},
+			entries: []testEntry{
+				{name: "a/", typ: tar.TypeDir},
+				{name: "a/b.txt", typ: tar.TypeReg, body: "b"},
+				{name: "c.txt", typ: tar.TypeReg, body: "c"},
+			},
+			files: map[string]string{"a/b.txt": "b", "c.txt": "c"},
+		},
+		{
+			name:    "zip slip",
+			formats: []string{"tar", "tgz", "zip"},
+			entries: []testEntry{
+				{name: "a.txt", typ: tar.TypeReg, body: "a"},
+				{name: "../evil.txt", typ: tar.TypeReg, body: "evil"},
+			},
+			unsafe: true,
+		},
+		{
+			name:    "inner parent",
+			formats: []string{"tar", "zip"},
+			entries: []testEntry{
+				{name: "a/../../evil.txt", typ: tar.TypeReg, body: "evil"},
+			},
+			unsafe: true,
+		},
+		{
+			name:    "absolute path",
+			formats: []string{"tar", "zip"},
+			entries: []testEntry{
+				{name: "/tmp/evil.txt", typ: tar.TypeReg, body: "evil"},
+			},
+			unsafe: true,
+		},
+		{
+			name:    "hard link",
+			formats: []string{"tar"},
+			entries: []testEntry{
+				{name: "a.txt", typ: tar.TypeReg, body: "a"},
+				{name: "b.txt", typ: tar.TypeLink, link: "a.txt"},
+			},
+			files: map[string]string{"a.txt": "a", "b.txt": "a"},
+		},
+		{
+			name:    "hard link outside",
+			formats: []string{"tar"},
+			entries: []testEntry{
+				{name: "passwd", typ: tar.TypeLink, link: "../../etc/passwd"},
+			},
+			unsafe: true,
+		},
+		{
+			name:    "hard link absolute",
+			formats: []string{"tar"},
+			entries: []testEntry{
+				{name: "passwd", typ: tar.TypeLink, link: "/etc/passwd"},
+			},
+			unsafe: true,
+		},
+		{
+			name:    "hard link through link",
+			formats: []string{"tar"},
+			entries: []testEntry{
+				{name: "l", typ: tar.TypeSymlink, link: "."},
+				{name: "passwd", typ: tar.TypeLink, link: "l/x"},
+			},
+			unsafe: true,
+		},
+		{
+			name:    "symbolic link",
+			formats: []string{"tar", "zip"},
+			entries: []testEntry{
+				{name: "a/", typ: tar.TypeDir},
+				{name: "a/b.txt", typ: tar.TypeReg, body: "b"},
+				{name: "c/", typ: tar.TypeDir},
+				{name: "c/l", typ: tar.TypeSymlink, link: "../a/b.txt"},
+			},
+			links: true,
+			files: map[string]string{"a/b.txt": "b", "c/l": "b"},
+		},
+		{
+			name:    "symbolic link outside",
+			formats: []string{"tar", "zip"},
+			entries: []testEntry{
+				{name: "l", typ: tar.TypeSymlink, link: "../evil"},
+			},
+			unsafe: true,
+		},
+		{
+			name:    "symbolic link absolute",
+			formats: []string{"tar", "zip"},
+			entries: []testEntry{
+				{name: "l", typ: tar.TypeSymlink, link: "/etc"},
+			},
+			unsafe: true,
+		},
+		{
+			name:    "link chain",
+			formats: []string{"tar", "zip"},
+			entries: []testEntry{
+				{name: "x/", typ: tar.TypeDir},
+				{name: "x/y", typ: tar.TypeSymlink, link: ".."},
+				{name: "l", typ: tar.TypeSymlink, link: "x/y/../.."},
+			},
+			unsafe: true,
+		},
+		{
+			name:    "link chain inside",
+			formats: []string{"tar"},
+			entries: []testEntry{
+				{name: "x/", typ: tar.TypeDir},
+				{name: "x/y", typ: tar.TypeSymlink, link: ".."},
+				{name: "a.txt", typ: tar.TypeReg, body: "a"},
+				{name: "l", typ: tar.TypeSymlink, link: "x/y/a.txt"},
+			},
+			links: true,
+			files: map[string]string{"a.txt": "a", "l": "a"},
+		},
+		{
+			name:    "link loop",
+			formats: []string{"tar"},
+			entries: []testEntry{
+				{name: "a", typ: tar.TypeSymlink, link: "b"},
+				{name: "b", typ: tar.TypeSymlink, link: "a"},
+				{name: "l", typ: tar.TypeSymlink, link: "a"},
+			},
+			unsafe: true,
+		},
+		{
+			name:    "write through link",
+			formats: []string{"tar", "zip"},
+			entries: []testEntry{
+				{name: "d", typ: tar.TypeSymlink, link: "."},
+				{name: "d/a.txt", typ: tar.TypeReg, body: "a"},
+			},
+			unsafe: true,
+		},
+	}
+	for _, test := range tests {
+		for _, format := range test.formats {
+			name := writeArchive(t, format, test.entries)
+			root := t.TempDir()
+			dir := filepath.Join(root, "dest", "data")
+			if err := os.MkdirAll(dir, 0755); err != nil {
+				t.Fatal(err)
+			}
+			err := Extract(name, dir)
+			if test.unsafe {
+				if errors.Cause(err) != ErrUnsafePath {
+					t.Errorf("%s %s: error %v, want %v", test.name, format, err, ErrUnsafePath)
+				}
+				ents, _ := os.ReadDir(dir)
+				if len(ents) > 0 {
+					t.Errorf("%s %s: unsafe archive partially extracted", test.name, format)
+				}
+				if ents, _ := os.ReadDir(root); len(ents) != 1 {
+					t.Errorf("%s %s: files written outside the directory", test.name, format)
+				}
+				continue
+			}
+			if test.links && runtime.GOOS == "windows" {
+				continue
+			}
+			if err != nil {
+				t.Errorf("%s %s: unexpected error: %v", test.name, format, err)
+				continue
+			}
+			for f, want := range test.files {
+				b, err := os.ReadFile(filepath.Join(dir, filepath.FromSlash(f)))
+				if err != nil {
+					t.Errorf("%s %s: %v", test.name, format, err)
+					continue
+				}
+				if string(b) != want {
+					t.Errorf("%s %s: file %s: got %q, want %q", test.name, format, f, b, want)
+				}
+			}
+		}
+	}
+}
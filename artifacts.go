@@ -0,0 +1,71 @@
+// Copyright (c) 2015, J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD-style license that can be found in the LICENSE file.
+
+package cmdapp
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/pkg/errors"
+)
+
+// completionFile is the file name
+// of the completion script of each shell.
+var completionFile = map[string]string{
+	"bash":       "%s.bash",
+	"zsh":        "_%s",
+	"fish":       "%s.fish",
+	"powershell": "%s.ps1",
+}
+
+// GenerateArtifacts writes into a directory
+// the release artifacts of the application:
+// the manual page,
+// the completion scripts of all shells
+// (in the completions sub-directory),
+// the Markdown documentation,
+// and the JSON description of the application.
+func GenerateArtifacts(dir string) error {
+	name := appName()
+	if err := os.MkdirAll(filepath.Join(dir, "completions"), 0755); err != nil {
+		return errors.Wrap(err, "cmdapp: artifacts")
+	}
+	if err := writeFile(filepath.Join(dir, name+".1"), WriteMan); err != nil {
+		return err
+	}
+	for _, sh := range Shells {
+		sh := sh
+		fn := filepath.Join(dir, "completions", fmt.Sprintf(completionFile[sh], name))
+		err := writeFile(fn, func(w io.Writer) error {
+			return WriteCompletion(w, sh)
+		})
+		if err != nil {
+			return err
+		}
+	}
+	if err := writeFile(filepath.Join(dir, name+".md"), WriteMarkdown); err != nil {
+		return err
+	}
+	return writeFile(filepath.Join(dir, name+".json"), WriteJSON)
+}
+
+// writeFile creates a file
+// with the content produced by a generator.
+func writeFile(name string, gen func(io.Writer) error) error {
+	f, err := os.Create(name)
+	if err != nil {
+		return errors.Wrap(err, "cmdapp: artifacts")
+	}
+	if err := gen(f); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return errors.Wrap(err, "cmdapp: artifacts")
+	}
+	return nil
+}
@@ -0,0 +1,78 @@
+// Copyright (c) 2015, J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD-style license that can be found in the LICENSE file.
+
+package cmdapp
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+
+	"github.com/pkg/errors"
+)
+
+// noBrowser is set by the -no-browser flag.
+var noBrowser bool
+
+// registerBrowserFlags sets the browser flags
+// of the application.
+func registerBrowserFlags(fs *flag.FlagSet) {
+	fs.BoolVar(&noBrowser, "no-browser", false, "print URLs instead of opening them in the browser")
+}
+
+// browserCommand returns the program
+// that opens an URL in the browser of the user,
+// or nil if there is no browser.
+func browserCommand(url string) []string {
+	switch runtime.GOOS {
+	case "darwin":
+		return []string{"open", url}
+	case "windows":
+		return []string{"rundll32", "url.dll,FileProtocolHandler", url}
+	case "js", "wasip1", "ios", "android":
+		return nil
+	}
+	if os.Getenv("WSL_DISTRO_NAME") != "" {
+		if _, err := exec.LookPath("wslview"); err == nil {
+			return []string{"wslview", url}
+		}
+		return []string{"cmd.exe", "/c", "start", "", url}
+	}
+	if os.Getenv("DISPLAY") == "" && os.Getenv("WAYLAND_DISPLAY") == "" {
+		return nil
+	}
+	if _, err := exec.LookPath("xdg-open"); err != nil {
+		return nil
+	}
+	return []string{"xdg-open", url}
+}
+
+// OpenBrowser opens an URL in the browser of the user,
+// for example to show online documentation
+// or to complete a login.
+//
+// If the -no-browser flag is set,
+// or there is no browser
+// (for example in a remote session),
+// the URL is printed in the error output,
+// so the user can open it.
+func OpenBrowser(url string) error {
+	var args []string
+	if !noBrowser {
+		args = browserCommand(url)
+	}
+	if args == nil {
+		fmt.Fprintf(Stderr, "Open this URL in your browser:\n\n    %s\n\n", url)
+		return nil
+	}
+	logger.Info("browser", "url", url)
+	cmd := exec.Command(args[0], args[1:]...)
+	if err := cmd.Start(); err != nil {
+		return errors.Wrap(err, "browser")
+	}
+	go cmd.Wait()
+	return nil
+}
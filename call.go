@@ -0,0 +1,140 @@
+// Copyright (c) 2015, J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD-style license that can be found in the LICENSE file.
+
+package cmdapp
+
+import (
+	"bytes"
+	"context"
+	"sort"
+
+	"github.com/pkg/errors"
+)
+
+// A ResultCommand is a command
+// that returns a structured result
+// when it is called with Call.
+type ResultCommand interface {
+	Command
+
+	// Result returns the result
+	// of the last run of the command.
+	Result() interface{}
+}
+
+// Options are the flags and arguments
+// used to call a command with Call.
+type Options struct {
+	// Flags are the values of the command flags,
+	// by flag name.
+	Flags map[string]string
+
+	// Args are the arguments of the command
+	// (after the flags).
+	Args []string
+
+	// Progress receives the progress
+	// reported by the command.
+	// If nil,
+	// the progress is ignored.
+	Progress Progress
+}
+
+// A Result is the result of a command run with Call.
+type Result struct {
+	// Value is the result of a ResultCommand,
+	// nil for other commands.
+	Value interface{}
+
+	// Stdout is the standard output of the command.
+	Stdout []byte
+
+	// Stderr is the error output of the command.
+	Stderr []byte
+}
+
+// Call runs a command from a Go program,
+// without parsing a command line,
+// and returns the result and the captured output of the command.
+//
+// Flags are set directly by name,
+// and the arguments are passed as given.
+// The context is checked before running the command,
+// and it is the parent of the context of the command.
+//
+// Calls are serialized,
+// with Dispatch and the applications,
+// because the output of the commands
+// is captured during the call,
+// so Call should not be used by a command
+// run with Dispatch or an App.
+func Call(ctx context.Context, name string, opts Options) (Result, error) {
+	appMutex.Lock()
+	defer appMutex.Unlock()
+
+	c, ok := lookup(name)
+	if !ok || !c.Runnable() {
+		return Result{}, errors.Errorf("cmdapp: unknown subcommand %s", name)
+	}
+
+	// the flags are given as a command line,
+	// so they are parsed and validated
+	// as in the other commands
+	var names []string
+	for nm := range opts.Flags {
+		names = append(names, nm)
+	}
+	sort.Strings(names)
+	args := make([]string, 0, len(names)+len(opts.Args)+1)
+	for _, nm := range names {
+		args = append(args, "-"+nm+"="+opts.Flags[nm])
+	}
+	args = append(append(args, "--"), opts.Args...)
+	if _, err := parseArgs(c.Name(), c, args, nil); err != nil {
+		return Result{}, errors.Wrap(err, c.Name())
+	}
+	if err := ctx.Err(); err != nil {
+		return Result{}, err
+	}
+
+	progMutex.Lock()
+	inCall, callProgress = true, opts.Progress
+	progMutex.Unlock()
+	defer func() {
+		progMutex.Lock()
+		inCall, callProgress = false, nil
+		progMutex.Unlock()
+	}()
+
+	var res Result
+	out, err := captureOutput(func() error {
+		var errBuf bytes.Buffer
+		oldErr := Stderr
+		Stderr = &errBuf
+		defer func() {
+			Stderr = oldErr
+			res.Stderr = errBuf.Bytes()
+		}()
+
+		logger.Debug("call", "command", c.Name(), "args", opts.Args)
+		done := startContext(ctx, c)
+		return done(runCommand(c, opts.Args))
+	})
+	res.Stdout = out
+	if r, ok := c.(ResultCommand); ok && err == nil {
+		res.Value = r.Result()
+	}
+	return res, err
+}
+
+// captureOutput runs a function
+// and returns the data written to Output,
+// even if an output file is set.
+func captureOutput(f func() error) ([]byte, error) {
+	var buf bytes.Buffer
+	restore := redirectOutput(&buf)
+	err := f()
+	restore()
+	return buf.Bytes(), err
+}
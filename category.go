@@ -0,0 +1,108 @@
+// Copyright (c) 2015, J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD-style license that can be found in the LICENSE file.
+
+package cmdapp
+
+import (
+	"fmt"
+	"io"
+	"sort"
+)
+
+// A CategoryCommand is a command
+// that declares a category,
+// for example "repository" or "networking".
+// The help output lists the commands
+// grouped by category.
+type CategoryCommand interface {
+	Command
+
+	// Category returns the category of the command.
+	Category() string
+}
+
+// CategoryOrder is the order of the categories
+// in the help output.
+// Categories not in the list
+// are shown after them,
+// in alphabetical order,
+// and the commands without a category
+// are shown at the end.
+var CategoryOrder []string
+
+// category returns the category of a command.
+func category(c Command) string {
+	if cc, ok := c.(CategoryCommand); ok {
+		return cc.Category()
+	}
+	return ""
+}
+
+// byCategory returns the categories
+// of a list of commands,
+// in the order of the help output,
+// and the commands of each category.
+// If no command has a category,
+// it returns nil.
+func byCategory(cmds []Command) ([]string, map[string][]Command) {
+	m := make(map[string][]Command)
+	for _, c := range cmds {
+		cat := category(c)
+		m[cat] = append(m[cat], c)
+	}
+	if len(m) == 1 && m[""] != nil {
+		return nil, nil
+	}
+
+	rank := make(map[string]int, len(CategoryOrder))
+	for i, cat := range CategoryOrder {
+		rank[cat] = i + 1
+	}
+	cats := make([]string, 0, len(m))
+	for cat := range m {
+		cats = append(cats, cat)
+	}
+	sort.Slice(cats, func(i, j int) bool {
+		a, b := cats[i], cats[j]
+		if (a == "") != (b == "") {
+			return b == ""
+		}
+		ra, rb := rank[a], rank[b]
+		if ra != rb {
+			if ra == 0 || rb == 0 {
+				return ra != 0
+			}
+			return ra < rb
+		}
+		return a < b
+	})
+	return cats, m
+}
+
+// printCommandList prints a list of commands,
+// grouped by category
+// if the commands have categories.
+func printCommandList(w io.Writer, cmds []Command) {
+	cats, m := byCategory(cmds)
+	if cats == nil {
+		fmt.Fprintf(w, "The commands are:\n")
+		for _, c := range cmds {
+			fmt.Fprintf(w, "    %-16s %s\n", c.Name(), listText(c))
+		}
+		return
+	}
+	for i, cat := range cats {
+		if i > 0 {
+			fmt.Fprintf(w, "\n")
+		}
+		if cat == "" {
+			fmt.Fprintf(w, "Other commands:\n")
+		} else {
+			fmt.Fprintf(w, "%s commands:\n", capitalize(cat))
+		}
+		for _, c := range m[cat] {
+			fmt.Fprintf(w, "    %-16s %s\n", c.Name(), listText(c))
+		}
+	}
+}
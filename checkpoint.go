@@ -0,0 +1,188 @@
+// Copyright (c) 2015, J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD-style license that can be found in the LICENSE file.
+
+package cmdapp
+
+import (
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// resume is set by the -resume flag.
+var resume bool
+
+// registerCheckpointFlags sets the checkpoint flags
+// of the application.
+func registerCheckpointFlags(fs *flag.FlagSet) {
+	fs.BoolVar(&resume, "resume", false, "resume the command from its last checkpoint")
+}
+
+// A checkpointFile is the file
+// with the checkpoints of a command.
+type checkpointFile struct {
+	Args   []string                   `json:"args"`
+	States map[string]json.RawMessage `json:"states"`
+}
+
+// ckpt is the checkpoint state
+// of the running command.
+var (
+	ckptMutex sync.Mutex
+	ckptName  string
+	ckptArgs  []string
+	ckptSaved *checkpointFile
+	ckptRead  bool
+)
+
+// startCheckpoints sets the command
+// whose checkpoints are stored,
+// by its path from the application.
+// The arguments include the command path.
+func startCheckpoints(name string, args []string) {
+	ckptMutex.Lock()
+	defer ckptMutex.Unlock()
+	ckptName = normName(name)
+	ckptArgs = append([]string(nil), args...)
+	ckptSaved = nil
+	ckptRead = false
+}
+
+// endCheckpoints removes the checkpoints
+// of a command that finished without errors.
+func endCheckpoints() {
+	ckptMutex.Lock()
+	defer ckptMutex.Unlock()
+	if ckptName == "" {
+		return
+	}
+	if name, err := checkpointPath(ckptName); err == nil {
+		os.Remove(name)
+	}
+	ckptName = ""
+}
+
+// checkpointPath returns the path
+// of the checkpoint file of a command.
+func checkpointPath(cmd string) (string, error) {
+	dir, err := DataDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "checkpoints", cmd+".json"), nil
+}
+
+// Checkpoint stores the state of the running command
+// with a key,
+// so an interrupted run of the command
+// can be resumed with the -resume flag.
+// The state is stored as JSON
+// in the data directory of the application.
+//
+// The checkpoints are removed
+// when the command finishes without errors.
+func Checkpoint(key string, state interface{}) error {
+	data, err := json.Marshal(state)
+	if err != nil {
+		return errors.Wrapf(err, "checkpoint %s", key)
+	}
+
+	ckptMutex.Lock()
+	defer ckptMutex.Unlock()
+	if ckptName == "" {
+		return errors.Errorf("checkpoint %s: no command is running", key)
+	}
+	if ckptSaved == nil {
+		ckptSaved = &checkpointFile{
+			Args:   ckptArgs,
+			States: make(map[string]json.RawMessage),
+		}
+	}
+	ckptSaved.States[key] = data
+
+	name, err := checkpointPath(ckptName)
+	if err != nil {
+		return errors.Wrapf(err, "checkpoint %s", key)
+	}
+	b, err := json.Marshal(ckptSaved)
+	if err != nil {
+		return errors.Wrapf(err, "checkpoint %s", key)
+	}
+	if err := WriteSecret(name, b); err != nil {
+		return errors.Wrapf(err, "checkpoint %s", key)
+	}
+	return nil
+}
+
+// Resume reads the state stored with a key
+// by the last run of the running command.
+// It returns false
+// if the -resume flag is not set,
+// if there is no checkpoint with the key,
+// or if the command was run with different arguments.
+func Resume(key string, state interface{}) (bool, error) {
+	if !resume {
+		return false, nil
+	}
+	ckptMutex.Lock()
+	defer ckptMutex.Unlock()
+	if ckptName == "" {
+		return false, nil
+	}
+	if !ckptRead {
+		ckptRead = true
+		if err := readCheckpoints(); err != nil {
+			return false, err
+		}
+	}
+	if ckptSaved == nil {
+		return false, nil
+	}
+	data, ok := ckptSaved.States[key]
+	if !ok {
+		return false, nil
+	}
+	if err := json.Unmarshal(data, state); err != nil {
+		return false, errors.Wrapf(err, "resume %s", key)
+	}
+	logger.Info("resume", "command", ckptName, "key", key)
+	return true, nil
+}
+
+// readCheckpoints reads the checkpoints
+// of the running command.
+// Checkpoints stored with different arguments
+// are discarded.
+// The checkpoint mutex must be held.
+func readCheckpoints() error {
+	name, err := checkpointPath(ckptName)
+	if err != nil {
+		return errors.Wrap(err, "resume")
+	}
+	b, err := os.ReadFile(name)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return errors.Wrap(err, "resume")
+	}
+	var f checkpointFile
+	if err := json.Unmarshal(b, &f); err != nil {
+		return errors.Wrapf(err, "resume: %s", name)
+	}
+	if !reflect.DeepEqual(f.Args, ckptArgs) {
+		Warn("checkpoint of %s ignored: the arguments changed", ckptName)
+		return nil
+	}
+	if f.States == nil {
+		f.States = make(map[string]json.RawMessage)
+	}
+	ckptSaved = &f
+	return nil
+}
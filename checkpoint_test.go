@@ -0,0 +1,120 @@
+// Copyright (c) 2015, J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD-style license that can be found in the LICENSE file.
+
+package cmdapp
+
+import (
+	"flag"
+	"io"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/pkg/errors"
+)
+
+// ckptCmd is a command that processes
+// a number of items,
+// and stores a checkpoint after each item.
+type ckptCmd struct {
+	fail int // item that fails, 0 for none
+	done []string
+}
+
+func (c *ckptCmd) Name() string              { return "count" }
+func (c *ckptCmd) Args() string              { return "<number>" }
+func (c *ckptCmd) Short() string             { return "processes items with checkpoints" }
+func (c *ckptCmd) Long() string              { return "" }
+func (c *ckptCmd) Register(fs *flag.FlagSet) {}
+func (c *ckptCmd) Runnable() bool            { return true }
+
+func (c *ckptCmd) Run(args []string) error {
+	n, err := strconv.Atoi(args[0])
+	if err != nil {
+		return err
+	}
+	next := 1
+	if _, err := Resume("next", &next); err != nil {
+		return err
+	}
+	for i := next; i <= n; i++ {
+		if i == c.fail {
+			return errors.Errorf("item %d failed", i)
+		}
+		c.done = append(c.done, strconv.Itoa(i))
+		if err := Checkpoint("next", i+1); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func TestCheckpoint(t *testing.T) {
+	defer func(r bool) { resume = r }(resume)
+
+	type step struct {
+		args   []string
+		fail   int
+		resume bool
+		code   int
+		want   string
+	}
+	tests := []struct {
+		name  string
+		steps []step
+	}{
+		{
+			name: "resume",
+			steps: []step{
+				{args: []string{"count", "5"}, fail: 3, code: 1, want: "1 2"},
+				{args: []string{"count", "5"}, fail: 4, resume: true, code: 1, want: "3"},
+				{args: []string{"count", "5"}, resume: true, want: "4 5"},
+			},
+		},
+		{
+			name: "without resume",
+			steps: []step{
+				{args: []string{"count", "5"}, fail: 3, code: 1, want: "1 2"},
+				{args: []string{"count", "5"}, want: "1 2 3 4 5"},
+			},
+		},
+		{
+			name: "changed arguments",
+			steps: []step{
+				{args: []string{"count", "5"}, fail: 3, code: 1, want: "1 2"},
+				{args: []string{"count", "6"}, resume: true, want: "1 2 3 4 5 6"},
+			},
+		},
+		{
+			name: "removed after success",
+			steps: []step{
+				{args: []string{"count", "5"}, fail: 3, code: 1, want: "1 2"},
+				{args: []string{"count", "5"}, resume: true, want: "3 4 5"},
+				{args: []string{"count", "5"}, resume: true, want: "1 2 3 4 5"},
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Setenv("XDG_DATA_HOME", t.TempDir())
+		c := &ckptCmd{}
+		a := NewApp("ckptapp", "a test application")
+		a.Stdout, a.Stderr = io.Discard, io.Discard
+		a.Add(c)
+
+		for i, s := range test.steps {
+			resume = s.resume
+			c.fail, c.done = s.fail, nil
+			if code := a.Dispatch(s.args); code != s.code {
+				t.Errorf("%s: step %d: exit code %d, want %d", test.name, i, code, s.code)
+			}
+			if got := strings.Join(c.done, " "); got != s.want {
+				t.Errorf("%s: step %d: processed %q, want %q", test.name, i, got, s.want)
+			}
+		}
+	}
+
+	if err := Checkpoint("next", 1); err == nil {
+		t.Errorf("checkpoint without a running command: expecting an error")
+	}
+}
@@ -0,0 +1,137 @@
+// Copyright (c) 2015, J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD-style license that can be found in the LICENSE file.
+
+package cmdapp
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// ErrNoClipboard is the error returned
+// when the system clipboard is not available,
+// for example in a system without a graphical session.
+var ErrNoClipboard = errors.New("clipboard not available")
+
+// clipboardTool returns the program
+// used to copy or paste
+// in the system clipboard.
+func clipboardTool(paste bool) ([]string, error) {
+	var tools [][]string
+	switch runtime.GOOS {
+	case "darwin":
+		tools = [][]string{{"pbcopy"}}
+		if paste {
+			tools = [][]string{{"pbpaste"}}
+		}
+	case "windows":
+		tools = [][]string{{"clip"}}
+		if paste {
+			tools = [][]string{{"powershell", "-NoProfile", "-Command", "Get-Clipboard -Raw"}}
+		}
+	default:
+		if os.Getenv("WAYLAND_DISPLAY") != "" {
+			if paste {
+				tools = append(tools, []string{"wl-paste", "--no-newline"})
+			} else {
+				tools = append(tools, []string{"wl-copy"})
+			}
+		}
+		if os.Getenv("DISPLAY") != "" {
+			if paste {
+				tools = append(tools, []string{"xclip", "-selection", "clipboard", "-o"}, []string{"xsel", "--clipboard", "--output"})
+			} else {
+				tools = append(tools, []string{"xclip", "-selection", "clipboard"}, []string{"xsel", "--clipboard", "--input"})
+			}
+		}
+		if os.Getenv("WSL_DISTRO_NAME") != "" {
+			if paste {
+				tools = append(tools, []string{"powershell.exe", "-NoProfile", "-Command", "Get-Clipboard -Raw"})
+			} else {
+				tools = append(tools, []string{"clip.exe"})
+			}
+		}
+	}
+	for _, t := range tools {
+		if _, err := exec.LookPath(t[0]); err == nil {
+			return t, nil
+		}
+	}
+	return nil, ErrNoClipboard
+}
+
+// CopyToClipboard copies a text
+// to the system clipboard.
+// It returns ErrNoClipboard
+// if the clipboard is not available.
+func CopyToClipboard(text string) error {
+	t, err := clipboardTool(false)
+	if err != nil {
+		return err
+	}
+	cmd := exec.Command(t[0], t[1:]...)
+	cmd.Stdin = strings.NewReader(text)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return errors.Wrapf(err, "clipboard: %s: %s", t[0], strings.TrimSpace(stderr.String()))
+	}
+	return nil
+}
+
+// PasteFromClipboard returns the text
+// in the system clipboard.
+// It returns ErrNoClipboard
+// if the clipboard is not available.
+func PasteFromClipboard() (string, error) {
+	t, err := clipboardTool(true)
+	if err != nil {
+		return "", err
+	}
+	cmd := exec.Command(t[0], t[1:]...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return "", errors.Wrapf(err, "clipboard: %s: %s", t[0], strings.TrimSpace(stderr.String()))
+	}
+	s := string(out)
+	if runtime.GOOS == "windows" || strings.HasSuffix(t[0], ".exe") {
+		s = strings.Replace(s, "\r\n", "\n", -1)
+	}
+	return s, nil
+}
+
+// CopyFlag sets the -copy flag of a command
+// that prints a value that users usually paste,
+// as a token or an identifier.
+// The value should be printed with PrintCopy.
+func CopyFlag(fs *flag.FlagSet, p *bool) {
+	fs.BoolVar(p, "copy", false, "copy the value to the clipboard")
+}
+
+// PrintCopy prints a value in the Output,
+// and, if clip is true,
+// copies it to the clipboard.
+// If the clipboard is not available,
+// a warning is shown,
+// as the value is already printed.
+func PrintCopy(clip bool, value string) {
+	fmt.Fprintln(Output(), value)
+	if !clip {
+		return
+	}
+	if err := CopyToClipboard(value); err != nil {
+		Warn("%v", err)
+		return
+	}
+	fmt.Fprintf(Stderr, "%s\n", Mark(Notice, "copied to the clipboard"))
+}
@@ -0,0 +1,111 @@
+// Copyright (c) 2015, J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD-style license that can be found in the LICENSE file.
+
+package cmdapp
+
+import (
+	"sync"
+	"time"
+)
+
+// A Clock provides the current time.
+type Clock interface {
+	// Now returns the current time.
+	Now() time.Time
+
+	// Sleep pauses for the given duration.
+	Sleep(d time.Duration)
+}
+
+// clock is the clock used by the application.
+var (
+	clockMutex sync.Mutex
+	clock      Clock = realClock{}
+)
+
+// SetClock sets the clock used by the framework
+// and the commands,
+// for example a FakeClock in tests.
+// If nil,
+// the system clock is used.
+func SetClock(c Clock) {
+	if c == nil {
+		c = realClock{}
+	}
+	clockMutex.Lock()
+	clock = c
+	clockMutex.Unlock()
+}
+
+// getClock returns the current clock.
+func getClock() Clock {
+	clockMutex.Lock()
+	defer clockMutex.Unlock()
+	return clock
+}
+
+// Now returns the current time
+// of the application clock.
+func Now() time.Time {
+	return getClock().Now()
+}
+
+// Since returns the time elapsed since t
+// in the application clock.
+func Since(t time.Time) time.Duration {
+	return Now().Sub(t)
+}
+
+// Sleep pauses for the given duration
+// in the application clock.
+func Sleep(d time.Duration) {
+	getClock().Sleep(d)
+}
+
+// realClock is the system clock.
+type realClock struct{}
+
+func (realClock) Now() time.Time        { return time.Now() }
+func (realClock) Sleep(d time.Duration) { time.Sleep(d) }
+
+// A FakeClock is a clock
+// that only advances when it is told to.
+// It is safe for concurrent use.
+type FakeClock struct {
+	mu sync.Mutex
+	t  time.Time
+}
+
+// NewFakeClock returns a fake clock
+// set at the given time.
+func NewFakeClock(t time.Time) *FakeClock {
+	return &FakeClock{t: t}
+}
+
+// Now returns the time of the clock.
+func (c *FakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.t
+}
+
+// Sleep advances the clock,
+// without pausing.
+func (c *FakeClock) Sleep(d time.Duration) {
+	c.Advance(d)
+}
+
+// Advance advances the clock by the given duration.
+func (c *FakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	c.t = c.t.Add(d)
+	c.mu.Unlock()
+}
+
+// Set sets the time of the clock.
+func (c *FakeClock) Set(t time.Time) {
+	c.mu.Lock()
+	c.t = t
+	c.mu.Unlock()
+}
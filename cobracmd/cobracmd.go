@@ -0,0 +1,208 @@
+// Copyright (c) 2015, J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD-style license that can be found in the LICENSE file.
+
+// Package cobracmd adapts cobra commands
+// to be hosted by a cmdapp application,
+// so an application can migrate from cobra
+// one command at a time.
+//
+// A cobra command is added with:
+//
+//	cmdapp.Add(cobracmd.New(cobraCmd))
+//
+// Only the given command is added,
+// its sub-commands are ignored.
+package cobracmd
+
+import (
+	"context"
+	"encoding/csv"
+	"flag"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// A Command is a cobra command
+// hosted as a cmdapp command.
+type Command struct {
+	c *cobra.Command
+}
+
+// New returns a cmdapp command
+// that runs a cobra command.
+func New(c *cobra.Command) *Command {
+	return &Command{c: c}
+}
+
+// Cobra returns the wrapped cobra command.
+func (cc *Command) Cobra() *cobra.Command { return cc.c }
+
+func (cc *Command) Name() string   { return cc.c.Name() }
+func (cc *Command) Short() string  { return cc.c.Short }
+func (cc *Command) Runnable() bool { return cc.c.Runnable() }
+
+// Args returns the arguments of the command
+// as given in the cobra Use field.
+func (cc *Command) Args() string {
+	use := strings.TrimSpace(cc.c.Use)
+	i := strings.IndexAny(use, " \t")
+	if i < 0 {
+		return ""
+	}
+	return strings.TrimSpace(use[i:])
+}
+
+// Long returns the long description of the command,
+// followed by the cobra examples.
+func (cc *Command) Long() string {
+	long := cc.c.Long
+	if long == "" {
+		long = cc.c.Short
+	}
+	if cc.c.Example != "" {
+		long += "\n\nExamples:\n\n" + cc.c.Example
+	}
+	return long
+}
+
+// Register sets the flags of the cobra command,
+// including the inherited persistent flags,
+// and its shorthands.
+// The flags are reset to their defaults,
+// as the command can be run more than once,
+// for example in a script.
+func (cc *Command) Register(fs *flag.FlagSet) {
+	// merge the persistent flags of the parents
+	cc.c.InheritedFlags()
+
+	pfs := cc.c.Flags()
+	pfs.VisitAll(func(f *pflag.Flag) {
+		resetFlag(f)
+		fs.Var(flagValue{pfs, f.Name}, f.Name, f.Usage)
+		if f.Shorthand != "" && fs.Lookup(f.Shorthand) == nil {
+			fs.Var(flagValue{pfs, f.Name}, f.Shorthand, f.Usage)
+		}
+	})
+}
+
+// Run runs the cobra command,
+// with its hooks,
+// as cobra does.
+func (cc *Command) Run(args []string) error {
+	c := cc.c
+	if !c.Runnable() {
+		return errors.Errorf("%s: not runnable", c.Name())
+	}
+	if c.Context() == nil {
+		c.SetContext(context.Background())
+	}
+	if err := c.ValidateArgs(args); err != nil {
+		return err
+	}
+	if err := c.ValidateRequiredFlags(); err != nil {
+		return err
+	}
+	if err := c.ValidateFlagGroups(); err != nil {
+		return err
+	}
+
+	for p := c; p != nil; p = p.Parent() {
+		if p.PersistentPreRunE != nil {
+			if err := p.PersistentPreRunE(c, args); err != nil {
+				return err
+			}
+			break
+		}
+		if p.PersistentPreRun != nil {
+			p.PersistentPreRun(c, args)
+			break
+		}
+	}
+	if c.PreRunE != nil {
+		if err := c.PreRunE(c, args); err != nil {
+			return err
+		}
+	} else if c.PreRun != nil {
+		c.PreRun(c, args)
+	}
+
+	if c.RunE != nil {
+		if err := c.RunE(c, args); err != nil {
+			return err
+		}
+	} else {
+		c.Run(c, args)
+	}
+
+	if c.PostRunE != nil {
+		if err := c.PostRunE(c, args); err != nil {
+			return err
+		}
+	} else if c.PostRun != nil {
+		c.PostRun(c, args)
+	}
+	for p := c; p != nil; p = p.Parent() {
+		if p.PersistentPostRunE != nil {
+			return p.PersistentPostRunE(c, args)
+		}
+		if p.PersistentPostRun != nil {
+			p.PersistentPostRun(c, args)
+			return nil
+		}
+	}
+	return nil
+}
+
+// resetFlag sets a cobra flag to its default value,
+// and marks it as unchanged.
+func resetFlag(f *pflag.Flag) {
+	defer func() { f.Changed = false }()
+	sv, ok := f.Value.(pflag.SliceValue)
+	if !ok {
+		f.Value.Set(f.DefValue)
+		return
+	}
+	// the default of a slice is in the form '[a,b]'
+	def := strings.TrimSuffix(strings.TrimPrefix(f.DefValue, "["), "]")
+	if def == "" {
+		sv.Replace(nil)
+		return
+	}
+	vals, err := csv.NewReader(strings.NewReader(def)).Read()
+	if err != nil {
+		return
+	}
+	sv.Replace(vals)
+}
+
+// flagValue is a flag value
+// that sets a cobra flag,
+// so cobra records it as changed.
+type flagValue struct {
+	fs   *pflag.FlagSet
+	name string
+}
+
+func (v flagValue) String() string {
+	if v.fs == nil {
+		return ""
+	}
+	return v.fs.Lookup(v.name).Value.String()
+}
+
+func (v flagValue) Set(s string) error {
+	return v.fs.Set(v.name, s)
+}
+
+// IsBoolFlag reports whether the flag is a boolean flag,
+// so it can be used without a value.
+func (v flagValue) IsBoolFlag() bool {
+	if v.fs == nil {
+		return false
+	}
+	return v.fs.Lookup(v.name).Value.Type() == "bool"
+}
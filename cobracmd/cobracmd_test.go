@@ -0,0 +1,107 @@
+// Copyright (c) 2015, J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD-style license that can be found in the LICENSE file.
+
+package cobracmd
+
+import (
+	"io"
+	"reflect"
+	"testing"
+
+	"github.com/js-arias/cmdapp"
+	"github.com/spf13/cobra"
+)
+
+// greet holds the values read by a cobra command
+// on each run.
+type greet struct {
+	name    string
+	loud    bool
+	tags    []string
+	count   int
+	changed bool
+}
+
+func newGreet(g *greet) *cobra.Command {
+	var name string
+	var loud bool
+	var tags []string
+	var count int
+	c := &cobra.Command{
+		Use:   "greet [<who>]",
+		Short: "a greeting",
+		RunE: func(c *cobra.Command, args []string) error {
+			*g = greet{
+				name:    name,
+				loud:    loud,
+				tags:    append([]string(nil), tags...),
+				count:   count,
+				changed: c.Flags().Changed("name"),
+			}
+			return nil
+		},
+	}
+	c.Flags().StringVarP(&name, "name", "n", "world", "name to greet")
+	c.Flags().BoolVar(&loud, "loud", false, "greet loudly")
+	c.Flags().StringSliceVar(&tags, "tag", []string{"a", "b"}, "tags")
+	c.Flags().IntVar(&count, "count", 1, "number of greetings")
+	return c
+}
+
+func TestRunTwice(t *testing.T) {
+	def := greet{name: "world", tags: []string{"a", "b"}, count: 1}
+	tests := []struct {
+		name  string
+		first []string
+		want  greet
+	}{
+		{
+			name:  "defaults",
+			first: []string{"greet"},
+			want:  def,
+		},
+		{
+			name:  "string and bool",
+			first: []string{"greet", "-name", "you", "-loud"},
+			want:  greet{name: "you", loud: true, tags: []string{"a", "b"}, count: 1, changed: true},
+		},
+		{
+			name:  "shorthand",
+			first: []string{"greet", "-n", "you"},
+			want:  greet{name: "you", tags: []string{"a", "b"}, count: 1, changed: true},
+		},
+		{
+			name:  "slice",
+			first: []string{"greet", "-tag", "x", "-tag", "y"},
+			want:  greet{name: "world", tags: []string{"x", "y"}, count: 1},
+		},
+		{
+			name:  "int",
+			first: []string{"greet", "-count", "3"},
+			want:  greet{name: "world", tags: []string{"a", "b"}, count: 3},
+		},
+	}
+	for _, test := range tests {
+		var g greet
+		a := cmdapp.NewApp("testapp", "a test application")
+		a.Stdout, a.Stderr = io.Discard, io.Discard
+		a.Add(New(newGreet(&g)))
+
+		if code := a.Dispatch(test.first); code != 0 {
+			t.Fatalf("%s: first run: exit code %d", test.name, code)
+		}
+		if !reflect.DeepEqual(g, test.want) {
+			t.Errorf("%s: first run: got %+v, want %+v", test.name, g, test.want)
+		}
+
+		// a second run without flags
+		// must see the defaults
+		if code := a.Dispatch([]string{"greet"}); code != 0 {
+			t.Fatalf("%s: second run: exit code %d", test.name, code)
+		}
+		if !reflect.DeepEqual(g, def) {
+			t.Errorf("%s: second run: got %+v, want %+v", test.name, g, def)
+		}
+	}
+}
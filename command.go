@@ -8,10 +8,11 @@
 package cmdapp
 
 import (
+	"context"
 	"flag"
 	"fmt"
 	"io"
-	"os"
+	"sort"
 	"strings"
 	"unicode"
 	"unicode/utf8"
@@ -44,20 +45,276 @@ type Command interface {
 	Runnable() bool
 }
 
-// Usage prints the usage message and exits the program.
+// An Example is an usage example of a command.
+type Example struct {
+	// Desc is a short description of the example.
+	Desc string `json:"desc,omitempty"`
+
+	// Args are the command's arguments used in the example.
+	Args string `json:"args"`
+
+	// Argv are the command's arguments used in the example,
+	// as a list.
+	// If set,
+	// they are quoted for the shell of the user
+	// (or for a POSIX shell in generated documentation)
+	// and Args is ignored.
+	Argv []string `json:"argv,omitempty"`
+}
+
+// line returns the arguments of an example
+// quoted for a shell.
+func (x Example) line(shell string) string {
+	if len(x.Argv) == 0 {
+		return x.Args
+	}
+	return QuoteArgs(shell, x.Argv)
+}
+
+// An Exampler is a command that provides usage examples,
+// shown in 'help <this-command>' output.
+type Exampler interface {
+	Command
+
+	// Examples returns the usage examples of the command.
+	Examples() []Example
+}
+
+// A SeeAlsoCommand is a command
+// that references related commands and help topics,
+// shown in 'help <this-command>' output.
+type SeeAlsoCommand interface {
+	Command
+
+	// SeeAlso returns the names of the related commands
+	// and help topics.
+	SeeAlso() []string
+}
+
+// A CloserCommand is a command
+// that must release resources after it is run.
+type CloserCommand interface {
+	Command
+
+	// Close is called after the command is run,
+	// even if Run returns an error,
+	// or if the application is terminated
+	// at the end of the grace period,
+	// in which case Run might be still running.
+	Close() error
+}
+
+// A ValidatorCommand is a command
+// that checks its arguments before it is run.
+type ValidatorCommand interface {
+	Command
+
+	// Validate checks the arguments unparsed by the flag package.
+	// If it returns an error,
+	// the command is not run,
+	// and the usage message is printed.
+	Validate(args []string) error
+}
+
+// A CommandContext is a command
+// that receives the context of the command when it is run,
+// so it can respect cancellation and deadlines.
+// RunContext is used instead of Run,
+// that can just call RunContext
+// with the value of Context.
+type CommandContext interface {
+	Command
+
+	// RunContext runs the command.
+	// The context is cancelled
+	// if the application receives an interrupt signal
+	// or the command timeout is reached.
+	RunContext(ctx context.Context, args []string) error
+}
+
+// ExitUsage is the exit code used
+// when a command is called with invalid arguments:
+// invalid flags,
+// or arguments rejected by Validate.
+const ExitUsage = 2
+
+// Usage prints the usage message and exits the program
+// using the function set with SetExitFunc.
 func Usage(c Command) {
-	fmt.Fprintf(os.Stderr, "usage: %s %s %s\n\n", Name, c.Name(), c.Args())
-	fmt.Fprintf(os.Stderr, "Type '%s help %s' for more information.\n", Name, c.Name())
-	os.Exit(1)
+	printCmdUsage(Stderr, c, nil)
+	Exit(1)
+}
+
+// printCmdUsage prints the usage message of a command.
+func printCmdUsage(w io.Writer, c Command, args []string) {
+	printPathUsage(w, c.Name(), c, args)
+}
+
+// printPathUsage prints the usage message of a command
+// with the given path,
+// for example "remote add" for a command of a group,
+// and the examples of the command
+// most relevant to the arguments used.
+func printPathUsage(w io.Writer, path string, c Command, args []string) {
+	fmt.Fprintf(w, "usage: %s %s %s\n\n", Name, path, c.Args())
+	if e, ok := c.(Exampler); ok {
+		ex := usageExamples(e.Examples(), args)
+		if len(ex) > 0 {
+			fmt.Fprintf(w, "Examples:\n")
+			for _, x := range ex {
+				fmt.Fprintf(w, "    %s %s %s\n", Name, path, x.line(UserShell()))
+			}
+			fmt.Fprintf(w, "\n")
+		}
+	}
+	fmt.Fprintf(w, "Type '%s help %s' for more information.\n", Name, path)
+}
+
+// maxUsageExamples is the number of examples
+// shown with the usage message.
+const maxUsageExamples = 3
+
+// usageExamples returns the examples
+// shown with the usage message,
+// the examples that share more flags and arguments
+// with the arguments used are the first ones.
+func usageExamples(ex []Example, args []string) []Example {
+	used := make(map[string]bool, len(args))
+	for _, a := range args {
+		used[argWord(a)] = true
+	}
+	score := make([]int, len(ex))
+	for i, x := range ex {
+		words := x.Argv
+		if len(words) == 0 {
+			words = strings.Fields(x.Args)
+		}
+		for _, w := range words {
+			if w := argWord(w); w != "" && used[w] {
+				score[i]++
+			}
+		}
+	}
+	idx := make([]int, len(ex))
+	for i := range idx {
+		idx[i] = i
+	}
+	sort.SliceStable(idx, func(i, j int) bool {
+		return score[idx[i]] > score[idx[j]]
+	})
+	if len(idx) > maxUsageExamples {
+		idx = idx[:maxUsageExamples]
+	}
+	sel := make([]Example, 0, len(idx))
+	for _, i := range idx {
+		sel = append(sel, ex[i])
+	}
+	return sel
+}
+
+// argWord returns the word of an argument
+// used to compare it with the examples,
+// for flags it is the flag name.
+func argWord(a string) string {
+	if strings.HasPrefix(a, "-") {
+		a = strings.TrimLeft(a, "-")
+		if i := strings.Index(a, "="); i >= 0 {
+			a = a[:i]
+		}
+	}
+	return a
 }
 
-// documentation prints command documentation.
+// docShell is the shell used to quote
+// the examples of the generated documentation
+// (as doc.go or the Markdown documentation),
+// so it does not depend on the shell
+// of the user that generates it.
+const docShell = "sh"
+
+// documentation prints command documentation,
+// with the examples quoted for the user shell.
 func documentation(w io.Writer, c Command) {
-	fmt.Fprintf(w, "%s\n\n", capitalize(c.Short()))
+	pathDocumentation(w, c.Name(), c, UserShell())
+}
+
+// pathDocumentation prints the documentation
+// of a command with the given path,
+// with the examples quoted for the given shell.
+func pathDocumentation(w io.Writer, path string, c Command, shell string) {
+	fmt.Fprintf(w, "%s\n\n", capitalize(title(c)))
 	if c.Runnable() {
-		fmt.Fprintf(w, "Usage:\n\n    %s %s %s\n\n", Name, c.Name(), c.Args())
+		fmt.Fprintf(w, "Usage:\n\n    %s %s %s\n\n", Name, path, c.Args())
+	}
+	if p := privilegesText(c); p != "" {
+		fmt.Fprintf(w, "%s\n\n", p)
+	}
+	if d, ok := deprecation(c.Name(), ""); ok {
+		fmt.Fprintf(w, "%s.\n\n", capitalize(depNote(fmt.Sprintf("command %q", c.Name()), d, d.Replacement)))
+	}
+	fmt.Fprintf(w, "%s\n\n", strings.TrimSpace(longText(c)))
+	if g, ok := c.(*Group); ok {
+		printGroupCommands(w, path, g)
+	}
+	printEnv(w, c)
+	if _, ok := c.(ExitStatusCommand); ok {
+		printExitStatus(w, c)
+	}
+	if e, ok := c.(Exampler); ok {
+		printPathExamples(w, path, e, shell)
+	}
+	if s, ok := c.(SeeAlsoCommand); ok && len(s.SeeAlso()) > 0 {
+		fmt.Fprintf(w, "See also: %s.\n\n", strings.Join(s.SeeAlso(), ", "))
+	}
+}
+
+// printExitStatus prints the exit codes of a command.
+func printExitStatus(w io.Writer, c Command) {
+	fmt.Fprintf(w, "Exit status:\n\n")
+	for _, s := range exitStatus(c) {
+		fmt.Fprintf(w, "    %-3d  %s\n", s.Code, s.Desc)
+	}
+	fmt.Fprintf(w, "\n")
+}
+
+// printEnv prints the environment variables
+// read by a command.
+func printEnv(w io.Writer, c Command) {
+	vars := commandEnv(c)
+	if len(vars) == 0 {
+		return
+	}
+	fmt.Fprintf(w, "Environment variables:\n\n")
+	for _, v := range vars {
+		fmt.Fprintf(w, "    %s\n      %s\n\n", v.Name, capitalize(envDesc(v)))
+	}
+}
+
+// printPathExamples prints the usage examples
+// of a command with the given path,
+// quoted for the given shell.
+func printPathExamples(w io.Writer, path string, e Exampler, shell string) {
+	ex := e.Examples()
+	if len(ex) == 0 {
+		return
+	}
+	fmt.Fprintf(w, "Examples:\n\n")
+	for _, x := range ex {
+		if x.Desc != "" {
+			fmt.Fprintf(w, "    # %s\n", x.Desc)
+		}
+		fmt.Fprintf(w, "    %s %s %s\n\n", Name, path, x.line(shell))
+	}
+}
+
+// title returns the title of a command,
+// for guides it is the guide title,
+// for other commands is the short description.
+func title(c Command) string {
+	if g, ok := c.(*Guide); ok {
+		return g.Title
 	}
-	fmt.Fprintf(w, "%s\n\n", strings.TrimSpace(c.Long()))
+	return shortText(c)
 }
 
 // capitalize set the first rune of a string as upper case.
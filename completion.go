@@ -0,0 +1,192 @@
+// Copyright (c) 2015, J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD-style license that can be found in the LICENSE file.
+
+package cmdapp
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Shells is the list of shells
+// with completion scripts.
+var Shells = []string{"bash", "zsh", "fish", "powershell"}
+
+// WriteCompletion writes the completion script
+// of the application for a shell.
+// Valid shells are listed in Shells.
+func WriteCompletion(w io.Writer, shell string) error {
+	bw := bufio.NewWriter(w)
+	switch shell {
+	case "bash":
+		bashCompletion(bw)
+	case "zsh":
+		zshCompletion(bw)
+	case "fish":
+		fishCompletion(bw)
+	case "powershell":
+		psCompletion(bw)
+	default:
+		return errors.Errorf("cmdapp: completion: unknown shell: %s", shell)
+	}
+	return bw.Flush()
+}
+
+// completionWords returns the names of the commands and help topics,
+// and the completion words of each command path,
+// the flags of a command,
+// or the commands and flags of a group.
+// The paths are sorted
+// with the deepest paths first.
+func completionWords() (names, paths []string, words map[string][]string) {
+	words = make(map[string][]string)
+	for _, c := range sortedCommands() {
+		names = append(names, c.Name())
+	}
+	walkCommands(func(path string, c Command, parents []*Group) {
+		if !c.Runnable() {
+			return
+		}
+		var w []string
+		if g, ok := c.(*Group); ok {
+			w = g.names()
+		}
+		for _, f := range pathFlags(c, parents) {
+			w = append(w, "-"+f.Name)
+		}
+		if len(w) == 0 {
+			return
+		}
+		paths = append(paths, path)
+		words[path] = w
+	})
+	sort.SliceStable(paths, func(i, j int) bool {
+		return strings.Count(paths[i], " ") > strings.Count(paths[j], " ")
+	})
+	return names, paths, words
+}
+
+// funcName returns a valid shell function name
+// for the application.
+func funcName() string {
+	return "_" + strings.Map(func(r rune) rune {
+		if r == '-' || r == '.' || r == ' ' {
+			return '_'
+		}
+		return r
+	}, appName())
+}
+
+func bashCompletion(w io.Writer) {
+	name := appName()
+	names, paths, words := completionWords()
+	fn := funcName()
+	fmt.Fprintf(w, "# bash completion for %s\n\n", name)
+	fmt.Fprintf(w, "%s() {\n", fn)
+	fmt.Fprintf(w, "\tlocal cur=${COMP_WORDS[COMP_CWORD]}\n")
+	fmt.Fprintf(w, "\tif [ $COMP_CWORD -eq 1 ]; then\n")
+	fmt.Fprintf(w, "\t\tCOMPREPLY=($(compgen -W %q -- \"$cur\"))\n", strings.Join(names, " "))
+	fmt.Fprintf(w, "\t\treturn\n\tfi\n")
+	fmt.Fprintf(w, "\tlocal cmdpath=\"\" w\n")
+	fmt.Fprintf(w, "\tfor w in \"${COMP_WORDS[@]:1:COMP_CWORD-1}\"; do\n")
+	fmt.Fprintf(w, "\t\tcase $w in\n\t\t-*) ;;\n\t\t*) cmdpath=\"${cmdpath:+$cmdpath }$w\" ;;\n\t\tesac\n")
+	fmt.Fprintf(w, "\tdone\n")
+	fmt.Fprintf(w, "\tcase $cmdpath in\n")
+	fmt.Fprintf(w, "\thelp|\"help \"*)\n\t\tCOMPREPLY=($(compgen -W %q -- \"$cur\"))\n\t\t;;\n", strings.Join(names, " "))
+	for _, p := range paths {
+		fmt.Fprintf(w, "\t%q|%q*)\n\t\tCOMPREPLY=($(compgen -W %q -- \"$cur\"))\n\t\t;;\n", p, p+" ", strings.Join(words[p], " "))
+	}
+	fmt.Fprintf(w, "\tesac\n}\n\n")
+	fmt.Fprintf(w, "complete -o default -F %s %s\n", fn, name)
+}
+
+func zshCompletion(w io.Writer) {
+	name := appName()
+	names, paths, words := completionWords()
+	fn := funcName()
+	fmt.Fprintf(w, "#compdef %s\n\n", name)
+	fmt.Fprintf(w, "%s() {\n", fn)
+	fmt.Fprintf(w, "\tif (( CURRENT == 2 )); then\n")
+	fmt.Fprintf(w, "\t\tcompadd -- %s\n", strings.Join(names, " "))
+	fmt.Fprintf(w, "\t\treturn\n\tfi\n")
+	fmt.Fprintf(w, "\tlocal cmdpath=\"\" w\n")
+	fmt.Fprintf(w, "\tfor w in ${words[2,CURRENT-1]}; do\n")
+	fmt.Fprintf(w, "\t\t[[ $w == -* ]] || cmdpath=\"${cmdpath:+$cmdpath }$w\"\n")
+	fmt.Fprintf(w, "\tdone\n")
+	fmt.Fprintf(w, "\tcase $cmdpath in\n")
+	fmt.Fprintf(w, "\thelp|\"help \"*)\n\t\tcompadd -- %s\n\t\treturn\n\t\t;;\n", strings.Join(names, " "))
+	for _, p := range paths {
+		fmt.Fprintf(w, "\t%q|%q*)\n\t\tcompadd -- %s\n\t\t;;\n", p, p+" ", strings.Join(words[p], " "))
+	}
+	fmt.Fprintf(w, "\tesac\n\t_files\n}\n\n")
+	fmt.Fprintf(w, "compdef %s %s\n", fn, name)
+}
+
+func fishCompletion(w io.Writer) {
+	name := appName()
+	fmt.Fprintf(w, "# fish completion for %s\n\n", name)
+	for _, c := range sortedCommands() {
+		fmt.Fprintf(w, "complete -c %s -f -n __fish_use_subcommand -a %s -d %s\n", name, c.Name(), fishQuote(shortText(c)))
+	}
+	walkCommands(func(path string, c Command, parents []*Group) {
+		if !c.Runnable() {
+			return
+		}
+		if len(parents) > 0 {
+			fmt.Fprintf(w, "complete -c %s -f -n '%s' -a %s -d %s\n", name, fishSeen(strings.Fields(path)[:len(parents)]), c.Name(), fishQuote(shortText(c)))
+		}
+		for _, f := range pathFlags(c, parents) {
+			fmt.Fprintf(w, "complete -c %s -n '%s' -o %s -d %s\n", name, fishSeen(strings.Fields(path)), f.Name, fishQuote(f.Usage))
+		}
+	})
+}
+
+// fishSeen returns a fish condition
+// that is true if all the given subcommands
+// are in the command line.
+func fishSeen(cmds []string) string {
+	cond := make([]string, 0, len(cmds))
+	for _, c := range cmds {
+		cond = append(cond, "__fish_seen_subcommand_from "+c)
+	}
+	return strings.Join(cond, "; and ")
+}
+
+func psCompletion(w io.Writer) {
+	name := appName()
+	names, paths, words := completionWords()
+	fmt.Fprintf(w, "# powershell completion for %s\n\n", name)
+	fmt.Fprintf(w, "Register-ArgumentCompleter -Native -CommandName %s -ScriptBlock {\n", psQuote(name))
+	fmt.Fprintf(w, "\tparam($wordToComplete, $commandAst, $cursorPosition)\n")
+	fmt.Fprintf(w, "\t$words = @($commandAst.CommandElements | ForEach-Object { $_.ToString() })\n")
+	fmt.Fprintf(w, "\t$candidates = @(%s)\n", psList(names))
+	fmt.Fprintf(w, "\t$last = $words.Count - 1\n")
+	fmt.Fprintf(w, "\tif ($wordToComplete -ne '') { $last-- }\n")
+	fmt.Fprintf(w, "\tif ($last -ge 1) {\n")
+	fmt.Fprintf(w, "\t\t$cmdpath = @($words[1..$last] | Where-Object { $_ -notlike '-*' }) -join ' '\n")
+	fmt.Fprintf(w, "\t\tif ($cmdpath -eq 'help' -or $cmdpath -like 'help *') { }\n")
+	for _, p := range paths {
+		fmt.Fprintf(w, "\t\telseif ($cmdpath -eq %s -or $cmdpath -like %s) { $candidates = @(%s) }\n", psQuote(p), psQuote(p+" *"), psList(words[p]))
+	}
+	fmt.Fprintf(w, "\t\telse { $candidates = @() }\n")
+	fmt.Fprintf(w, "\t}\n")
+	fmt.Fprintf(w, "\t$candidates | Where-Object { $_ -like \"$wordToComplete*\" } | ForEach-Object {\n")
+	fmt.Fprintf(w, "\t\t[System.Management.Automation.CompletionResult]::new($_, $_, 'ParameterValue', $_)\n")
+	fmt.Fprintf(w, "\t}\n}\n")
+}
+
+// psList returns a comma separated list
+// of quoted powershell strings.
+func psList(ls []string) string {
+	q := make([]string, 0, len(ls))
+	for _, s := range ls {
+		q = append(q, psQuote(s))
+	}
+	return strings.Join(q, ", ")
+}
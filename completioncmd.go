@@ -0,0 +1,132 @@
+// Copyright (c) 2015, J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD-style license that can be found in the LICENSE file.
+
+package cmdapp
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/pkg/errors"
+)
+
+// completionCmd is the completion command.
+type completionCmd struct {
+	install bool
+}
+
+func init() {
+	addBuiltin(&completionCmd{})
+}
+
+const completionLong = `
+Command completion prints the completion script of the application for a
+shell. Valid shells are bash, zsh, fish, and powershell. If no shell is
+given, the shell of the user is detected.
+
+The flags are:
+
+    -install
+      Writes the completion script in the completion directory of the
+      shell, instead of printing it. If a completion script already
+      exists, it is kept as a backup, with the extension '.bak'.
+`
+
+func (cc *completionCmd) Name() string   { return "completion" }
+func (cc *completionCmd) Args() string   { return "[-install] [bash|zsh|fish|powershell]" }
+func (cc *completionCmd) Short() string  { return "prints the shell completion script" }
+func (cc *completionCmd) Long() string   { return completionLong }
+func (cc *completionCmd) Runnable() bool { return true }
+
+func (cc *completionCmd) Register(fs *flag.FlagSet) {
+	fs.BoolVar(&cc.install, "install", false, "install the completion script")
+}
+
+func (cc *completionCmd) Run(args []string) error {
+	if len(args) > 1 {
+		return errors.New("too many arguments")
+	}
+	var shell string
+	if len(args) == 1 {
+		shell = args[0]
+	} else {
+		shell = UserShell()
+		if shell == "" {
+			return errors.New("unable to detect the shell")
+		}
+	}
+	if !cc.install {
+		return WriteCompletion(Output(), shell)
+	}
+
+	name, note, err := completionPath(shell)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(name), 0755); err != nil {
+		return err
+	}
+	if _, err := os.Stat(name); err == nil {
+		if err := os.Rename(name, name+".bak"); err != nil {
+			return err
+		}
+	}
+	f, err := os.Create(name)
+	if err != nil {
+		return err
+	}
+	if err := WriteCompletion(f, shell); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	fmt.Fprintf(Output(), "%s completion installed in %s\n", shell, name)
+	if note != "" {
+		fmt.Fprintln(Output(), note)
+	}
+	return nil
+}
+
+// completionPath returns the path
+// in which the completion script of a shell is installed,
+// and a note for the user,
+// if additional steps are required.
+func completionPath(shell string) (name, note string, err error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", "", err
+	}
+	app := appName()
+	switch shell {
+	case "bash":
+		dir := os.Getenv("XDG_DATA_HOME")
+		if dir == "" {
+			dir = filepath.Join(home, ".local", "share")
+		}
+		return filepath.Join(dir, "bash-completion", "completions", app), "", nil
+	case "zsh":
+		dir := os.Getenv("ZDOTDIR")
+		if dir == "" {
+			dir = home
+		}
+		name = filepath.Join(dir, ".zfunc", "_"+app)
+		note = fmt.Sprintf("Add 'fpath+=(%s)' before 'compinit' in your .zshrc file.", filepath.Dir(name))
+		return name, note, nil
+	case "fish":
+		dir := os.Getenv("XDG_CONFIG_HOME")
+		if dir == "" {
+			dir = filepath.Join(home, ".config")
+		}
+		return filepath.Join(dir, "fish", "completions", app+".fish"), "", nil
+	case "powershell":
+		name = filepath.Join(home, "Documents", "PowerShell", app+"-completion.ps1")
+		note = fmt.Sprintf("Add '. %s' to your PowerShell $PROFILE file.", psQuote(name))
+		return name, note, nil
+	}
+	return "", "", errors.Errorf("unknown shell: %s", shell)
+}
@@ -0,0 +1,320 @@
+// Copyright (c) 2015, J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD-style license that can be found in the LICENSE file.
+
+package cmdapp
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// A KeyType is the type of the value of a configuration key.
+type KeyType int
+
+// Valid key types.
+const (
+	StringKey KeyType = iota
+	IntKey
+	BoolKey
+	DurationKey
+)
+
+var keyTypeNames = []string{"string", "int", "bool", "duration"}
+
+func (t KeyType) String() string {
+	if t < 0 || int(t) >= len(keyTypeNames) {
+		return "string"
+	}
+	return keyTypeNames[t]
+}
+
+// A Key is a key of the configuration schema.
+type Key struct {
+	// Name is the name of the key.
+	Name string
+
+	// Type is the type of the value.
+	Type KeyType
+
+	// Default is the value used
+	// if the key is not set.
+	Default string
+
+	// Desc is a short description of the key.
+	Desc string
+
+	// Secret is true if the value should be masked
+	// when it is shown.
+	Secret bool
+}
+
+// config is the schema and the values
+// of the application configuration.
+var (
+	cfgMutex  sync.Mutex
+	cfgSchema = make(map[string]Key)
+	cfgValues = make(map[string]string)
+	cfgOnce   sync.Once
+)
+
+// DefineConfig adds keys to the configuration schema
+// of the application.
+// Key names should be unique,
+// otherwise it will trigger a panic.
+func DefineConfig(keys ...Key) {
+	cfgOnce.Do(func() { addBuiltin(&configCmd{}) })
+	cfgMutex.Lock()
+	defer cfgMutex.Unlock()
+	for _, k := range keys {
+		k.Name = strings.ToLower(k.Name)
+		if _, dup := cfgSchema[k.Name]; dup {
+			panic(fmt.Sprintf("cmdapp: Repeated config key: %s", k.Name))
+		}
+		if k.Default != "" {
+			if err := checkValue(k, k.Default); err != nil {
+				panic(fmt.Sprintf("cmdapp: config key %s: invalid default: %v", k.Name, err))
+			}
+		}
+		cfgSchema[k.Name] = k
+	}
+}
+
+// ConfigKeys returns the keys of the configuration schema,
+// sorted by name.
+func ConfigKeys() []Key {
+	cfgMutex.Lock()
+	defer cfgMutex.Unlock()
+	keys := make([]Key, 0, len(cfgSchema))
+	for _, k := range cfgSchema {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i].Name < keys[j].Name })
+	return keys
+}
+
+// ConfigFile is the path of the configuration file.
+// If empty,
+// the file "config" in the application directory
+// of the user configuration directory is used.
+var ConfigFile string
+
+// configPath returns the path of the configuration file.
+func configPath() (string, error) {
+	if ConfigFile != "" {
+		return ConfigFile, nil
+	}
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", errors.Wrap(err, "config")
+	}
+	return filepath.Join(dir, appName(), "config"), nil
+}
+
+// projectConfigPath returns the path
+// of the project configuration file,
+// the file '.<app>.conf' in the project root.
+// It returns false if the working directory
+// is not inside a project.
+func projectConfigPath() (string, bool) {
+	nm := "." + appName() + ".conf"
+	dir, err := FindRoot(append([]string{nm}, RootMarkers...)...)
+	if err != nil {
+		return "", false
+	}
+	return filepath.Join(dir, nm), true
+}
+
+// LoadConfig reads and validates the configuration file.
+// If the file does not exist,
+// the default values are used.
+//
+// If the working directory is inside a project
+// (see Root),
+// the project configuration file
+// '.<app>.conf' in the project root,
+// where <app> is the application name,
+// is read after the user configuration file,
+// and its values replace the user values.
+// The project file has the same format
+// as the user configuration file,
+// and it can be committed with the project
+// to share default values.
+//
+// The configuration file has a key-value pair per line,
+// in the form 'key = value'.
+// Values can be quoted with double quotes.
+// Blank lines,
+// and lines starting with '#' are ignored.
+// Keys starting with 'alias.' define command aliases.
+//
+// If there is no user configuration directory
+// (for example in WASM),
+// the default values are used.
+//
+// Run reads the configuration
+// before the command is run,
+// if the configuration is not valid
+// the application fails,
+// except for the help and config commands,
+// that are run after a warning,
+// so the user can fix the configuration.
+func LoadConfig() error {
+	vals := make(map[string]string)
+	if name, err := configPath(); err == nil {
+		if err := readConfig(name, vals); err != nil {
+			return err
+		}
+	}
+	if name, ok := projectConfigPath(); ok {
+		if err := readConfig(name, vals); err != nil {
+			return err
+		}
+	}
+	cfgMutex.Lock()
+	cfgValues = vals
+	cfgMutex.Unlock()
+	return nil
+}
+
+// fixesConfig reports whether a command line
+// runs a command that is run
+// even if the configuration is not valid,
+// so the user can read the help
+// and fix the configuration:
+// help and config.
+func fixesConfig(args []string) bool {
+	if len(args) == 0 {
+		return true
+	}
+	switch normName(args[0]) {
+	case "help", "config":
+		return true
+	}
+	return false
+}
+
+// readConfig reads a configuration file
+// and adds its values to a map.
+// If the file does not exist,
+// the map is unchanged.
+func readConfig(name string, vals map[string]string) error {
+	f, err := os.Open(name)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return errors.Wrap(err, "config")
+	}
+	defer f.Close()
+
+	v, err := parseConfig(f, name)
+	if err != nil {
+		return err
+	}
+	for k, val := range v {
+		vals[k] = val
+	}
+	return nil
+}
+
+// parseConfig reads and validates a configuration file.
+func parseConfig(f io.Reader, name string) (map[string]string, error) {
+	cfgMutex.Lock()
+	defer cfgMutex.Unlock()
+
+	vals := make(map[string]string)
+	s := bufio.NewScanner(f)
+	for ln := 1; s.Scan(); ln++ {
+		line := strings.TrimSpace(s.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		i := strings.Index(line, "=")
+		if i < 0 {
+			return nil, errors.Errorf("%s:%d: expecting 'key = value'", name, ln)
+		}
+		key := strings.ToLower(strings.TrimSpace(line[:i]))
+		val := strings.TrimSpace(line[i+1:])
+		if len(val) > 1 && strings.HasPrefix(val, `"`) {
+			uq, err := strconv.Unquote(val)
+			if err != nil {
+				return nil, errors.Errorf("%s:%d: invalid quoted value for key '%s'", name, ln, key)
+			}
+			val = uq
+		}
+
+		if strings.HasPrefix(key, aliasPrefix) {
+			vals[key] = val
+			continue
+		}
+		k, ok := cfgSchema[key]
+		if !ok {
+			return nil, errors.Errorf("%s:%d: %s", name, ln, unknownKey(key))
+		}
+		if err := checkValue(k, val); err != nil {
+			return nil, errors.Errorf("%s:%d: key '%s': %v", name, ln, key, err)
+		}
+		vals[key] = val
+	}
+	if err := s.Err(); err != nil {
+		return nil, errors.Wrap(err, "config")
+	}
+	return vals, nil
+}
+
+// unknownKey returns the message of an unknown key,
+// with suggestions of similar keys.
+// It must be called with cfgMutex locked.
+func unknownKey(key string) string {
+	names := make([]string, 0, len(cfgSchema))
+	for nm := range cfgSchema {
+		names = append(names, nm)
+	}
+	sort.Strings(names)
+	msg := fmt.Sprintf("unknown key '%s'", key)
+	if s := suggest(key, names); len(s) > 0 {
+		msg += fmt.Sprintf(", did you mean '%s'?", s[0])
+	}
+	return msg
+}
+
+// checkValue checks that a value is valid for a key.
+func checkValue(k Key, val string) error {
+	var err error
+	switch k.Type {
+	case IntKey:
+		_, err = strconv.ParseInt(val, 10, 64)
+	case BoolKey:
+		_, err = strconv.ParseBool(val)
+	case DurationKey:
+		_, err = time.ParseDuration(val)
+	}
+	if err != nil {
+		return errors.Errorf("invalid %s value %q", k.Type, val)
+	}
+	return nil
+}
+
+// ConfigValue returns the value of a configuration key.
+// If the key is not set,
+// it returns its default value.
+func ConfigValue(key string) string {
+	key = strings.ToLower(key)
+	cfgMutex.Lock()
+	defer cfgMutex.Unlock()
+	if v, ok := cfgValues[key]; ok {
+		return v
+	}
+	return cfgSchema[key].Default
+}
@@ -0,0 +1,228 @@
+// Copyright (c) 2015, J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD-style license that can be found in the LICENSE file.
+
+package cmdapp
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func init() {
+	DefineConfig(
+		Key{Name: "test.name", Desc: "a string key"},
+		Key{Name: "test.count", Type: IntKey, Default: "1"},
+		Key{Name: "test.debug", Type: BoolKey},
+		Key{Name: "test.wait", Type: DurationKey},
+	)
+}
+
+func TestParseConfig(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want map[string]string
+		err  string
+	}{
+		{name: "empty", in: "", want: map[string]string{}},
+		{
+			name: "values",
+			in:   "# comment\n\ntest.name = john\nTEST.COUNT=3\ntest.debug = true\ntest.wait = 2s\n",
+			want: map[string]string{"test.name": "john", "test.count": "3", "test.debug": "true", "test.wait": "2s"},
+		},
+		{
+			name: "quoted",
+			in:   `test.name = "a = \"b\"\n"`,
+			want: map[string]string{"test.name": "a = \"b\"\n"},
+		},
+		{name: "alias", in: "alias.h = help -web", want: map[string]string{"alias.h": "help -web"}},
+		{name: "no equal", in: "test.name", err: "expecting 'key = value'"},
+		{name: "unknown key", in: "test.nam = x", err: "did you mean 'test.name'?"},
+		{name: "invalid int", in: "test.count = many", err: "invalid int value"},
+		{name: "invalid bool", in: "test.debug = maybe", err: "invalid bool value"},
+		{name: "invalid duration", in: "test.wait = 2", err: "invalid duration value"},
+		{name: "invalid quote", in: `test.name = "abc`, err: "invalid quoted value"},
+	}
+	for _, test := range tests {
+		got, err := parseConfig(strings.NewReader(test.in), "config")
+		if test.err != "" {
+			if err == nil || !strings.Contains(err.Error(), test.err) {
+				t.Errorf("%s: error %v, want %q", test.name, err, test.err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", test.name, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%s: got %v, want %v", test.name, got, test.want)
+		}
+	}
+}
+
+func TestFixesConfig(t *testing.T) {
+	tests := []struct {
+		args []string
+		want bool
+	}{
+		{nil, true},
+		{[]string{"help", "config"}, true},
+		{[]string{"config", "edit"}, true},
+		{[]string{"doctor"}, false},
+	}
+	for _, test := range tests {
+		if got := fixesConfig(test.args); got != test.want {
+			t.Errorf("fixesConfig(%q) = %v, want %v", test.args, got, test.want)
+		}
+	}
+}
+
+func TestEditConfigInvalid(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the editor is a shell script")
+	}
+	dir := t.TempDir()
+	editor := filepath.Join(dir, "editor")
+	if err := os.WriteFile(editor, []byte("#!/bin/sh\necho 'test.count = many' >> \"$1\"\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("VISUAL", editor)
+
+	name := filepath.Join(dir, "config")
+	orig := "test.name = john\n"
+	if err := os.WriteFile(name, []byte(orig), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := editConfig(name, SecretMode); err == nil {
+		t.Fatalf("invalid edit: expecting error")
+	}
+	b, err := os.ReadFile(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != orig {
+		t.Errorf("invalid edit saved: %q", b)
+	}
+}
+
+// FuzzParseConfig checks that the values of a valid configuration
+// written as in the configuration files
+// are read with the same values.
+func FuzzParseConfig(f *testing.F) {
+	f.Add("test.name = john\ntest.count = 3\n")
+	f.Add("# comment\n\ntest.debug = true\ntest.wait = 1m30s\n")
+	f.Add(`test.name = "a = \"b\"\n"`)
+	f.Add("alias.h = help -web\n")
+	f.Fuzz(func(t *testing.T, in string) {
+		vals, err := parseConfig(strings.NewReader(in), "config")
+		if err != nil {
+			return
+		}
+		var b strings.Builder
+		for k, v := range vals {
+			fmt.Fprintf(&b, "%s = %s\n", k, strconv.Quote(v))
+		}
+		got, err := parseConfig(strings.NewReader(b.String()), "config")
+		if err != nil {
+			t.Fatalf("parse %q: %v", b.String(), err)
+		}
+		if !reflect.DeepEqual(got, vals) {
+			t.Fatalf("parse %q = %q, want %q", b.String(), got, vals)
+		}
+	})
+}
+
+func TestProjectConfig(t *testing.T) {
+	defer func(name string) { ConfigFile = name }(ConfigFile)
+	cfgMutex.Lock()
+	old := cfgValues
+	cfgMutex.Unlock()
+	defer func() {
+		cfgMutex.Lock()
+		cfgValues = old
+		cfgMutex.Unlock()
+	}()
+
+	tests := []struct {
+		user    string
+		project string
+		want    string
+	}{
+		{user: "test.name = user\n", want: "user"},
+		{project: "test.name = project\n", want: "project"},
+		{user: "test.name = user\n", project: "test.name = project\n", want: "project"},
+		{user: "test.name = user\n", project: "test.count = 2\n", want: "user"},
+	}
+	for _, test := range tests {
+		dir := t.TempDir()
+		ConfigFile = filepath.Join(dir, "config")
+		if err := os.WriteFile(ConfigFile, []byte(test.user), 0600); err != nil {
+			t.Fatal(err)
+		}
+		root := filepath.Join(dir, "project")
+		sub := filepath.Join(root, "sub")
+		if err := os.MkdirAll(filepath.Join(root, ".git"), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.MkdirAll(sub, 0755); err != nil {
+			t.Fatal(err)
+		}
+		if test.project != "" {
+			if err := os.WriteFile(filepath.Join(root, "."+appName()+".conf"), []byte(test.project), 0644); err != nil {
+				t.Fatal(err)
+			}
+		}
+		t.Chdir(sub)
+
+		if err := LoadConfig(); err != nil {
+			t.Fatalf("user %q, project %q: %v", test.user, test.project, err)
+		}
+		if v := ConfigValue("test.name"); v != test.want {
+			t.Errorf("user %q, project %q: got %q, want %q", test.user, test.project, v, test.want)
+		}
+	}
+}
+
+func TestConfigFileMode(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("no unix permissions")
+	}
+	defer func(name string) { ConfigFile = name }(ConfigFile)
+	dir := t.TempDir()
+	ConfigFile = filepath.Join(dir, "config")
+	root := filepath.Join(dir, "project")
+	if err := os.MkdirAll(filepath.Join(root, ".git"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Chdir(root)
+	defer LoadConfig()
+
+	tests := []struct {
+		flag string
+		name string
+		want os.FileMode
+	}{
+		{flag: "-global", name: ConfigFile, want: SecretMode},
+		{flag: "-local", name: filepath.Join(root, "."+appName()+".conf"), want: SharedMode},
+	}
+	for _, test := range tests {
+		if code := Dispatch([]string{"config", test.flag, "set", "test.name", "x"}); code != 0 {
+			t.Fatalf("config %s set: exit code %d", test.flag, code)
+		}
+		fi, err := os.Stat(test.name)
+		if err != nil {
+			t.Fatalf("config %s set: %v", test.flag, err)
+		}
+		if fi.Mode().Perm() != test.want {
+			t.Errorf("config %s set: mode %v, want %v", test.flag, fi.Mode().Perm(), test.want)
+		}
+	}
+}
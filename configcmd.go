@@ -0,0 +1,249 @@
+// Copyright (c) 2015, J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD-style license that can be found in the LICENSE file.
+
+package cmdapp
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// configCmd is the config command,
+// added when the configuration schema is defined.
+type configCmd struct {
+	reveal bool
+	local  bool
+	global bool
+}
+
+const configLong = `
+Command config reads and modifies the configuration file of the application.
+
+The subcommands are:
+
+    get <key>
+      Prints the value of a key.
+
+    set <key> <value>
+      Sets the value of a key.
+
+    unset <key>
+      Removes a key from the configuration file, so the default value is
+      used.
+
+    list
+      Prints all the keys, with its values and descriptions.
+
+    edit
+      Opens the configuration file in the editor set by the VISUAL or
+      EDITOR environment variables.
+
+Values of secret keys are masked.
+
+The values of the project configuration file, '.<app>.conf' at the project
+root, replace the values of the user configuration file. The values shown by
+get and list are the values in use.
+
+The flags are:
+
+    -global
+      Modifies the user configuration file. This is the default.
+
+    -local
+      Modifies the project configuration file.
+
+    -reveal
+      Shows the values of secret keys.
+`
+
+func (cc *configCmd) Name() string { return "config" }
+func (cc *configCmd) Args() string {
+	return "[-reveal] [-local|-global] get|set|unset|list|edit [<key> [<value>]]"
+}
+func (cc *configCmd) Short() string  { return "reads and modifies the configuration" }
+func (cc *configCmd) Long() string   { return configLong }
+func (cc *configCmd) Runnable() bool { return true }
+
+func (cc *configCmd) Register(fs *flag.FlagSet) {
+	fs.BoolVar(&cc.reveal, "reveal", false, "show values of secret keys")
+	fs.BoolVar(&cc.local, "local", false, "modify the project configuration file")
+	fs.BoolVar(&cc.global, "global", false, "modify the user configuration file")
+}
+
+// file returns the configuration file
+// modified by the command,
+// and its mode:
+// the user file is private,
+// and the project file is shared,
+// as it can be committed with the project.
+func (cc *configCmd) file() (string, fs.FileMode, error) {
+	if cc.local && cc.global {
+		return "", 0, errors.New("flags -local and -global are exclusive")
+	}
+	if !cc.local {
+		name, err := configPath()
+		return name, SecretMode, err
+	}
+	name, ok := projectConfigPath()
+	if !ok {
+		return "", 0, errors.New("not inside a project")
+	}
+	return name, SharedMode, nil
+}
+
+func (cc *configCmd) Run(args []string) error {
+	if len(args) == 0 {
+		return errors.New("expecting a subcommand")
+	}
+	sub, args := args[0], args[1:]
+	nargs := map[string]int{"get": 1, "set": 2, "unset": 1, "list": 0, "edit": 0}
+	n, ok := nargs[sub]
+	if !ok {
+		return errors.Errorf("unknown subcommand %s", sub)
+	}
+	if len(args) != n {
+		return errors.Errorf("%s: expecting %s", sub, Plural(n, "argument", ""))
+	}
+	var k Key
+	if n > 0 {
+		var err error
+		if k, err = schemaKey(args[0]); err != nil {
+			return err
+		}
+	}
+
+	switch sub {
+	case "get":
+		fmt.Fprintln(Output(), cc.show(k, ConfigValue(k.Name)))
+	case "set":
+		if err := checkValue(k, args[1]); err != nil {
+			return errors.Errorf("key '%s': %v", k.Name, err)
+		}
+		return cc.set(k.Name, args[1], false)
+	case "unset":
+		return cc.set(k.Name, "", true)
+	case "list":
+		for _, k := range ConfigKeys() {
+			fmt.Fprintf(Output(), "%s = %s\n", k.Name, cc.show(k, ConfigValue(k.Name)))
+			if k.Desc != "" {
+				fmt.Fprintf(Output(), "    %s (%s)\n", k.Desc, k.Type)
+			}
+		}
+	case "edit":
+		name, perm, err := cc.file()
+		if err != nil {
+			return err
+		}
+		return editConfig(name, perm)
+	}
+	return nil
+}
+
+// set sets or removes a key
+// in the configuration file modified by the command.
+func (cc *configCmd) set(key, val string, unset bool) error {
+	name, perm, err := cc.file()
+	if err != nil {
+		return err
+	}
+	return setConfig(name, perm, key, val, unset)
+}
+
+// show returns the value of a key to be shown.
+func (cc *configCmd) show(k Key, val string) string {
+	if k.Secret && !cc.reveal && val != "" {
+		return "********"
+	}
+	return val
+}
+
+// schemaKey returns a key of the configuration schema.
+func schemaKey(name string) (Key, error) {
+	name = strings.ToLower(name)
+	cfgMutex.Lock()
+	defer cfgMutex.Unlock()
+	k, ok := cfgSchema[name]
+	if !ok {
+		return Key{}, errors.New(unknownKey(name))
+	}
+	return k, nil
+}
+
+// setUserConfig sets or removes a key
+// in the user configuration file.
+func setUserConfig(key, val string, unset bool) error {
+	name, err := configPath()
+	if err != nil {
+		return err
+	}
+	return setConfig(name, SecretMode, key, val, unset)
+}
+
+// setConfig sets or removes a key in a configuration file,
+// with the given mode,
+// keeping the other lines of the file.
+func setConfig(name string, perm fs.FileMode, key, val string, unset bool) error {
+	var lines []string
+	if b, err := os.ReadFile(name); err == nil {
+		lines = strings.Split(strings.TrimRight(string(b), "\n"), "\n")
+	} else if !os.IsNotExist(err) {
+		return errors.Wrap(err, "config")
+	}
+
+	kv := fmt.Sprintf("%s = %s", key, strconv.Quote(val))
+	found := false
+	var out []string
+	for _, ln := range lines {
+		t := strings.TrimSpace(ln)
+		if i := strings.Index(t, "="); i > 0 && !strings.HasPrefix(t, "#") {
+			if strings.ToLower(strings.TrimSpace(t[:i])) == key {
+				if unset || found {
+					continue
+				}
+				found = true
+				out = append(out, kv)
+				continue
+			}
+		}
+		out = append(out, ln)
+	}
+	if !found && !unset {
+		out = append(out, kv)
+	}
+
+	if err := WriteFileAtomic(name, []byte(strings.Join(out, "\n")+"\n"), perm); err != nil {
+		return errors.Wrap(err, "config")
+	}
+	return LoadConfig()
+}
+
+// editConfig opens a configuration file in an editor
+// and validates it after it is edited.
+// If the edited file is not valid,
+// it is not saved,
+// otherwise it is written with the given mode.
+func editConfig(name string, perm fs.FileMode) error {
+	data, err := os.ReadFile(name)
+	if err != nil && !os.IsNotExist(err) {
+		return errors.Wrap(err, "config")
+	}
+	data, err = OpenInEditor(data)
+	if err != nil {
+		return err
+	}
+	if _, err := parseConfig(bytes.NewReader(data), name); err != nil {
+		return errors.Wrap(err, "changes not saved")
+	}
+	if err := WriteFileAtomic(name, data, perm); err != nil {
+		return errors.Wrap(err, "config")
+	}
+	return LoadConfig()
+}
@@ -0,0 +1,63 @@
+// Copyright (c) 2015, J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD-style license that can be found in the LICENSE file.
+
+package cmdapp
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+
+	"github.com/pkg/errors"
+)
+
+// DataDir returns the directory
+// in which the application stores user data,
+// the application directory in XDG_DATA_HOME
+// (by default ~/.local/share),
+// in Windows in %LocalAppData%,
+// and in macOS in ~/Library/Application Support.
+// The directory is not created.
+func DataDir() (string, error) {
+	if dir := os.Getenv("XDG_DATA_HOME"); dir != "" {
+		return filepath.Join(dir, appName()), nil
+	}
+	switch runtime.GOOS {
+	case "windows":
+		if dir := os.Getenv("LocalAppData"); dir != "" {
+			return filepath.Join(dir, appName()), nil
+		}
+	case "darwin", "ios":
+		dir, err := os.UserConfigDir()
+		if err != nil {
+			return "", errors.Wrap(err, "data directory")
+		}
+		return filepath.Join(dir, appName()), nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", errors.Wrap(err, "data directory")
+	}
+	return filepath.Join(home, ".local", "share", appName()), nil
+}
+
+// CacheDir returns the directory
+// in which the application stores cached data,
+// that can be removed without losing user data,
+// the application directory in XDG_CACHE_HOME
+// (by default ~/.cache),
+// in Windows in %LocalAppData%,
+// and in macOS in ~/Library/Caches.
+// The directory is not created.
+func CacheDir() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", errors.Wrap(err, "cache directory")
+	}
+	if runtime.GOOS == "windows" {
+		// the data directory is in the same location
+		return filepath.Join(dir, appName(), "cache"), nil
+	}
+	return filepath.Join(dir, appName()), nil
+}
@@ -0,0 +1,36 @@
+// Copyright (c) 2015, J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD-style license that can be found in the LICENSE file.
+
+package cmdapp
+
+// SetDefault sets the command
+// that is run when the application is called
+// without a command,
+// for example an interactive or status command.
+// The command is run without arguments.
+// If name is empty,
+// or no default command is set,
+// the usage of the application is printed
+// and the application ends with an error.
+func SetDefault(name string) {
+	mutex.Lock()
+	defer mutex.Unlock()
+	reg.def = name
+}
+
+// SetDefault sets the command
+// that is run when the application is called
+// without a command.
+func (a *App) SetDefault(name string) {
+	mutex.Lock()
+	defer mutex.Unlock()
+	a.reg.def = name
+}
+
+// defaultCommand returns the name of the default command.
+func defaultCommand() string {
+	mutex.RLock()
+	defer mutex.RUnlock()
+	return reg.def
+}
@@ -0,0 +1,112 @@
+// Copyright (c) 2015, J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD-style license that can be found in the LICENSE file.
+
+package cmdapp
+
+import (
+	"flag"
+	"fmt"
+	"sync"
+)
+
+// A Deprecation is a deprecated command or flag,
+// and its replacement.
+type Deprecation struct {
+	// Command is the name of the deprecated command,
+	// or the command of the deprecated flag.
+	Command string
+
+	// Flag is the name of the deprecated flag.
+	// If empty,
+	// the command is deprecated.
+	Flag string
+
+	// Replacement is the command
+	// (with its flags, if required)
+	// or the flag name (without dashes)
+	// that replaces the deprecated one.
+	// It is empty if there is no replacement.
+	Replacement string
+
+	// Message is an additional note
+	// for the users.
+	Message string
+}
+
+// deprecations are the registered deprecations.
+var (
+	depMutex     sync.Mutex
+	deprecations []Deprecation
+)
+
+// Deprecate registers deprecated commands and flags.
+// A deprecated command can be an old name
+// that is no longer registered.
+//
+// Running a deprecated command,
+// or setting a deprecated flag,
+// prints a warning before the command is run,
+// and deprecated commands are flagged
+// in the help output.
+func Deprecate(ds ...Deprecation) {
+	depMutex.Lock()
+	defer depMutex.Unlock()
+	deprecations = append(deprecations, ds...)
+}
+
+// deprecation returns the deprecation
+// of a command,
+// or a flag of a command.
+func deprecation(cmd, flag string) (Deprecation, bool) {
+	cmd = normName(cmd)
+	depMutex.Lock()
+	defer depMutex.Unlock()
+	for _, d := range deprecations {
+		if normName(d.Command) == cmd && d.Flag == flag {
+			return d, true
+		}
+	}
+	return Deprecation{}, false
+}
+
+// depNote returns the note of a deprecation.
+func depNote(what string, d Deprecation, repl string) string {
+	msg := what + " is deprecated"
+	if d.Replacement != "" {
+		msg += fmt.Sprintf(", use %q", repl)
+	}
+	if d.Message != "" {
+		msg += ": " + d.Message
+	}
+	return msg
+}
+
+// warnDeprecated prints a warning
+// if a command,
+// or a flag set in the command line,
+// is deprecated.
+// The warnings are not counted by Warnings,
+// so a deprecated command does not fail in strict mode.
+func warnDeprecated(c Command, fs *flag.FlagSet) {
+	if d, ok := deprecation(c.Name(), ""); ok {
+		printDeprecated(depNote(fmt.Sprintf("command %q", c.Name()), d, d.Replacement))
+	}
+	fs.Visit(func(f *flag.Flag) {
+		if d, ok := deprecation(c.Name(), f.Name); ok {
+			printDeprecated(depNote(fmt.Sprintf("flag -%s", f.Name), d, "-"+d.Replacement))
+		}
+	})
+}
+
+// printDeprecated prints a deprecation warning.
+func printDeprecated(msg string) {
+	logger.Info("deprecated", "message", msg)
+	fmt.Fprintf(Stderr, "%s\n", Mark(Warning, fmt.Sprintf("%s: warning: %s", Name, msg)))
+}
+
+// isDeprecated reports whether a command is deprecated.
+func isDeprecated(c Command) bool {
+	_, ok := deprecation(c.Name(), "")
+	return ok
+}
@@ -0,0 +1,161 @@
+// Copyright (c) 2015, J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD-style license that can be found in the LICENSE file.
+
+package cmdapp
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// testDeprecations sets the deprecations
+// used by the tests,
+// and returns a function to restore the previous ones.
+func testDeprecations() func() {
+	depMutex.Lock()
+	prev := deprecations
+	deprecations = []Deprecation{
+		{Command: "old", Replacement: "new"},
+		{Command: "new", Flag: "v", Replacement: "verbose"},
+		{Command: "gone", Message: "no longer supported"},
+	}
+	depMutex.Unlock()
+	return func() {
+		depMutex.Lock()
+		deprecations = prev
+		depMutex.Unlock()
+	}
+}
+
+func TestDeprecatedWarning(t *testing.T) {
+	defer testDeprecations()()
+
+	tests := []struct {
+		args []string
+		want []string
+	}{
+		{args: []string{"old", "x"}, want: []string{`command "old" is deprecated, use "new"`}},
+		{args: []string{"new", "-v", "x"}, want: []string{`flag -v is deprecated, use "-verbose"`}},
+		{args: []string{"new", "x"}},
+		{args: []string{"gone"}, want: []string{`command "gone" is deprecated: no longer supported`}},
+	}
+	for _, test := range tests {
+		var errOut bytes.Buffer
+		a := NewApp("depapp", "a test application")
+		a.Stdout, a.Stderr = &bytes.Buffer{}, &errOut
+		a.Add(&mountCmd{name: "old", run: func(c *mountCmd, args []string) error { return nil }})
+		a.Add(&deprecatedFlagCmd{mountCmd{name: "new", run: func(c *mountCmd, args []string) error { return nil }}})
+		a.Add(&mountCmd{name: "gone", run: func(c *mountCmd, args []string) error { return nil }})
+
+		if code := a.Dispatch(test.args); code != 0 {
+			t.Errorf("%q: exit code %d", test.args, code)
+			continue
+		}
+		if len(test.want) == 0 && errOut.Len() > 0 {
+			t.Errorf("%q: unexpected warning: %s", test.args, errOut.String())
+		}
+		for _, w := range test.want {
+			if !strings.Contains(errOut.String(), w) {
+				t.Errorf("%q: warning %q, want %q", test.args, errOut.String(), w)
+			}
+		}
+	}
+}
+
+// deprecatedFlagCmd is a command
+// with the flags -v and -verbose.
+type deprecatedFlagCmd struct {
+	mountCmd
+}
+
+func (c *deprecatedFlagCmd) Register(fs *flag.FlagSet) {
+	var v bool
+	fs.BoolVar(&v, "v", false, "verbose output (deprecated)")
+	fs.BoolVar(&v, "verbose", false, "verbose output")
+}
+
+func TestMigrateLine(t *testing.T) {
+	defer testDeprecations()()
+	defer func(name string) { Name = name }(Name)
+	Name = "/usr/bin/tool"
+
+	tests := []struct {
+		line  string
+		want  string
+		notes int
+	}{
+		{line: "tool old x", want: "tool new x", notes: 1},
+		{line: "tool new -v x", want: "tool new -verbose x", notes: 1},
+		{line: "tool new --v=3 x", want: "tool new --verbose=3 x", notes: 1},
+		{line: "tool old -v x", want: "tool new -v x", notes: 1},
+		{line: "ls | tool old; echo tool", want: "ls | tool new; echo tool", notes: 1},
+		{line: "/opt/bin/tool old -- -v", want: "/opt/bin/tool new -- -v", notes: 1},
+		{line: "tool gone", want: "tool gone", notes: 1},
+		{line: "tool new x && tool old -q", want: "tool new x && tool new -q", notes: 1},
+		{line: "other old -v", want: "other old -v"},
+		{line: "tool", want: "tool"},
+		{line: "", want: ""},
+	}
+	for _, test := range tests {
+		got, notes := migrateLine(test.line)
+		if got != test.want {
+			t.Errorf("%q: got %q, want %q", test.line, got, test.want)
+		}
+		if len(notes) != test.notes {
+			t.Errorf("%q: notes %q, want %d notes", test.line, notes, test.notes)
+		}
+	}
+}
+
+func TestMigrateUsage(t *testing.T) {
+	defer testDeprecations()()
+
+	var out bytes.Buffer
+	a := NewApp("tool", "a test application")
+	a.Stdout, a.Stderr = &out, &bytes.Buffer{}
+
+	dir := t.TempDir()
+	script := filepath.Join(dir, "script.sh")
+	if err := os.WriteFile(script, []byte("#!/bin/sh\ntool old x\ntool new -v y\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	clean := filepath.Join(dir, "clean.sh")
+	if err := os.WriteFile(clean, []byte("tool new x\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		args []string
+		code int
+		want []string
+	}{
+		{
+			args: []string{"migrate-usage", script},
+			want: []string{
+				script + `:2: command "old" is deprecated, use "new"`,
+				"    tool new x\n",
+				script + `:3: flag -v of "new" is deprecated, use "-verbose"`,
+				"    tool new -verbose y\n",
+				"found 2 deprecated usages",
+			},
+		},
+		{args: []string{"migrate-usage", clean}, want: []string{"no deprecated usage found"}},
+		{args: []string{"migrate-usage", filepath.Join(dir, "none.sh")}, code: 1},
+	}
+	for _, test := range tests {
+		out.Reset()
+		if code := a.Dispatch(test.args); code != test.code {
+			t.Errorf("%q: exit code %d, want %d", test.args, code, test.code)
+		}
+		for _, w := range test.want {
+			if !strings.Contains(out.String(), w) {
+				t.Errorf("%q: output without %q:\n%s", test.args, w, out.String())
+			}
+		}
+	}
+}
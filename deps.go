@@ -0,0 +1,234 @@
+// Copyright (c) 2015, J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD-style license that can be found in the LICENSE file.
+
+package cmdapp
+
+import (
+	"flag"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// A DependentCommand is a command
+// that requires other commands
+// to be run before it.
+type DependentCommand interface {
+	Command
+
+	// Requires returns the names of the commands
+	// that must be run before this command.
+	Requires() []string
+}
+
+// An UpToDateCommand is a command
+// that can check if its results are current.
+type UpToDateCommand interface {
+	Command
+
+	// UpToDate reports whether the results of the command
+	// are current,
+	// so there is no need to run it as a prerequisite.
+	UpToDate() bool
+}
+
+// withDeps is set by the -with-deps flag.
+var withDeps bool
+
+// registerDepsFlags sets the dependency flags
+// of the application.
+func registerDepsFlags(fs *flag.FlagSet) {
+	fs.BoolVar(&withDeps, "with-deps", false, "run the prerequisites of the command before it")
+}
+
+// A graph is a dependency graph of commands.
+type graph struct {
+	nodes map[string]Command
+	deps  map[string][]string
+
+	// order is a topological order of the commands,
+	// prerequisites first
+	order []string
+}
+
+// newGraph returns the dependency graph
+// of a set of commands and their prerequisites.
+func newGraph(targets []Command) (*graph, error) {
+	g := &graph{
+		nodes: make(map[string]Command),
+		deps:  make(map[string][]string),
+	}
+	state := make(map[string]int) // 1: visiting, 2: done
+	var visit func(c Command, path []string) error
+	visit = func(c Command, path []string) error {
+		nm := normName(c.Name())
+		switch state[nm] {
+		case 1:
+			return errors.Errorf("dependency cycle: %s", strings.Join(append(path, nm), " -> "))
+		case 2:
+			return nil
+		}
+		state[nm] = 1
+		g.nodes[nm] = c
+		if d, ok := c.(DependentCommand); ok {
+			for _, r := range d.Requires() {
+				r = normName(r)
+				rc, ok := lookup(r)
+				if !ok || !rc.Runnable() {
+					return errors.Errorf("%s: unknown prerequisite %s", c.Name(), r)
+				}
+				if err := visit(rc, append(path, nm)); err != nil {
+					return err
+				}
+				g.deps[nm] = append(g.deps[nm], r)
+			}
+		}
+		state[nm] = 2
+		g.order = append(g.order, nm)
+		return nil
+	}
+	for _, c := range targets {
+		if err := visit(c, nil); err != nil {
+			return nil, err
+		}
+	}
+	return g, nil
+}
+
+// depOrder returns the prerequisites of a command,
+// in the order in which they must be run.
+func depOrder(c Command) ([]Command, error) {
+	g, err := newGraph([]Command{c})
+	if err != nil {
+		return nil, err
+	}
+	// the last one is the command itself
+	var order []Command
+	for _, nm := range g.order[:len(g.order)-1] {
+		order = append(order, g.nodes[nm])
+	}
+	return order, nil
+}
+
+// run runs the commands of the graph,
+// each one after its prerequisites,
+// using up to jobs concurrent commands,
+// with the given mode for the output of concurrent commands.
+// Target commands are always run,
+// other commands are run as prerequisites.
+//
+// After the first error,
+// no more commands are started.
+func (g *graph) run(targets map[string]bool, jobs int, mode TaskOutput) error {
+	if jobs < 1 {
+		jobs = 1
+	}
+	pos := make(map[string]int, len(g.order))
+	pending := make(map[string]int, len(g.order))
+	dependents := make(map[string][]string)
+	var ready []string
+	for i, nm := range g.order {
+		pos[nm] = i
+		pending[nm] = len(g.deps[nm])
+		for _, d := range g.deps[nm] {
+			dependents[d] = append(dependents[d], nm)
+		}
+		if pending[nm] == 0 {
+			ready = append(ready, nm)
+		}
+	}
+
+	type result struct {
+		name string
+		err  error
+	}
+	done := make(chan result)
+	running := 0
+	var err error
+	for {
+		for err == nil && running < jobs && len(ready) > 0 {
+			nm := ready[0]
+			ready = ready[1:]
+			running++
+			go func(nm string) {
+				c := g.nodes[nm]
+				closeOut := func() {}
+				if jobs > 1 {
+					closeOut = newTaskOutput(c, nm, mode)
+				}
+				var err error
+				if targets[nm] {
+					err = runDefault(c)
+				} else {
+					err = runPrereq(c)
+				}
+				closeOut()
+				done <- result{nm, err}
+			}(nm)
+		}
+		if running == 0 {
+			break
+		}
+		r := <-done
+		running--
+		if r.err != nil {
+			if err == nil {
+				err = r.err
+			}
+			continue
+		}
+		for _, d := range dependents[r.name] {
+			pending[d]--
+			if pending[d] == 0 {
+				ready = append(ready, d)
+			}
+		}
+		// keep the topological order among ready commands
+		sort.Slice(ready, func(i, j int) bool { return pos[ready[i]] < pos[ready[j]] })
+	}
+	return err
+}
+
+// runDefault runs a command
+// with its default flags and no arguments.
+func runDefault(c Command) error {
+	if _, err := parseArgs(c.Name(), c, nil, nil); err != nil {
+		return errors.Wrap(err, c.Name())
+	}
+	logger.Debug("run", "command", c.Name())
+	return runCommand(c, nil)
+}
+
+// runPrereq runs a command as a prerequisite,
+// with its default flags and no arguments.
+// A command that is up to date is skipped.
+func runPrereq(c Command) error {
+	if _, err := parseArgs(c.Name(), c, nil, nil); err != nil {
+		return errors.Wrapf(err, "prerequisite %s", c.Name())
+	}
+	if u, ok := c.(UpToDateCommand); ok && u.UpToDate() {
+		logger.Debug("up to date", "command", c.Name())
+		return nil
+	}
+	logger.Debug("run", "command", c.Name(), "prerequisite", true)
+	if err := runCommand(c, nil); err != nil {
+		return errors.Wrapf(err, "prerequisite %s", c.Name())
+	}
+	return nil
+}
+
+// runDeps runs the prerequisites of a command.
+func runDeps(c Command) error {
+	deps, err := depOrder(c)
+	if err != nil {
+		return err
+	}
+	for _, d := range deps {
+		if err := runPrereq(d); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,133 @@
+// Copyright (c) 2015, J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD-style license that can be found in the LICENSE file.
+
+package cmdapp
+
+import (
+	"flag"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/pkg/errors"
+)
+
+// depCmd is a command with prerequisites.
+type depCmd struct {
+	name     string
+	requires []string
+	upToDate bool
+	needArg  bool // Validate rejects an empty argument list
+
+	mu  *sync.Mutex
+	log *[]string
+}
+
+func (c *depCmd) Name() string              { return c.name }
+func (c *depCmd) Args() string              { return "" }
+func (c *depCmd) Short() string             { return "a command with prerequisites" }
+func (c *depCmd) Long() string              { return "" }
+func (c *depCmd) Register(fs *flag.FlagSet) {}
+func (c *depCmd) Runnable() bool            { return true }
+func (c *depCmd) Requires() []string        { return c.requires }
+func (c *depCmd) UpToDate() bool            { return c.upToDate }
+
+func (c *depCmd) Validate(args []string) error {
+	if c.needArg && len(args) == 0 {
+		return errors.New("expecting an argument")
+	}
+	return nil
+}
+
+func (c *depCmd) Run(args []string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	*c.log = append(*c.log, c.name)
+	return nil
+}
+
+// depApp returns an application
+// with the given commands,
+// and the log of the commands run.
+func depApp(cmds []*depCmd) (*App, *[]string) {
+	a := NewApp("depapp", "a test application")
+	a.Stdout, a.Stderr = io.Discard, io.Discard
+	log := &[]string{}
+	mu := &sync.Mutex{}
+	for _, c := range cmds {
+		c.mu, c.log = mu, log
+		a.Add(c)
+	}
+	return a, log
+}
+
+func TestWithDeps(t *testing.T) {
+	defer func(w bool) { withDeps = w }(withDeps)
+	withDeps = true
+
+	tests := []struct {
+		name string
+		cmds []*depCmd
+		args []string
+		code int
+		want string
+	}{
+		{
+			name: "order",
+			cmds: []*depCmd{
+				{name: "build", requires: []string{"fetch", "gen"}},
+				{name: "fetch"},
+				{name: "gen", requires: []string{"fetch"}},
+			},
+			args: []string{"build", "x"},
+			want: "fetch gen build",
+		},
+		{
+			name: "up to date",
+			cmds: []*depCmd{
+				{name: "build", requires: []string{"fetch", "gen"}},
+				{name: "fetch", upToDate: true},
+				{name: "gen"},
+			},
+			args: []string{"build"},
+			want: "gen build",
+		},
+		{
+			name: "invalid prerequisite",
+			cmds: []*depCmd{
+				{name: "build", requires: []string{"fetch"}},
+				{name: "fetch", needArg: true},
+			},
+			args: []string{"build"},
+			code: ExitUsage,
+		},
+		{
+			name: "cycle",
+			cmds: []*depCmd{
+				{name: "build", requires: []string{"fetch"}},
+				{name: "fetch", requires: []string{"build"}},
+			},
+			args: []string{"build"},
+			code: 1,
+		},
+		{
+			name: "unknown prerequisite",
+			cmds: []*depCmd{
+				{name: "build", requires: []string{"fetch"}},
+			},
+			args: []string{"build"},
+			code: 1,
+		},
+	}
+	for _, test := range tests {
+		a, log := depApp(test.cmds)
+		if code := a.Dispatch(test.args); code != test.code {
+			t.Errorf("%s: exit code %d, want %d", test.name, code, test.code)
+		}
+		if got := strings.Join(*log, " "); got != test.want {
+			t.Errorf("%s: run %q, want %q", test.name, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,131 @@
+// Copyright (c) 2015, J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD-style license that can be found in the LICENSE file.
+
+package cmdapp
+
+import (
+	"encoding/json"
+	"flag"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// appName returns the application name
+// without the path of the program.
+func appName() string {
+	return filepath.Base(Name)
+}
+
+// commandFlags returns the flags of a command,
+// sorted by name.
+func commandFlags(c Command) []*flag.Flag {
+	fs := flag.NewFlagSet(c.Name(), flag.ContinueOnError)
+	c.Register(fs)
+	var flags []*flag.Flag
+	fs.VisitAll(func(f *flag.Flag) {
+		flags = append(flags, f)
+	})
+	return flags
+}
+
+// cliDesc is the JSON description of an application.
+type cliDesc struct {
+	Name     string    `json:"name"`
+	Short    string    `json:"short"`
+	Commands []cmdDesc `json:"commands"`
+}
+
+// cmdDesc is the JSON description of a command.
+type cmdDesc struct {
+	Name     string       `json:"name"`
+	Args     string       `json:"args,omitempty"`
+	Short    string       `json:"short"`
+	Long     string       `json:"long,omitempty"`
+	Topic    bool         `json:"topic,omitempty"`
+	Flags    []flagDesc   `json:"flags,omitempty"`
+	Env      []envVarDesc `json:"env,omitempty"`
+	Exit     []ExitStatus `json:"exit,omitempty"`
+	Examples []Example    `json:"examples,omitempty"`
+	Commands []cmdDesc    `json:"commands,omitempty"`
+}
+
+// envVarDesc is the JSON description
+// of an environment variable.
+type envVarDesc struct {
+	Name    string `json:"name"`
+	Desc    string `json:"desc"`
+	Default string `json:"default,omitempty"`
+	Flag    string `json:"flag,omitempty"`
+}
+
+// flagDesc is the JSON description of a flag.
+type flagDesc struct {
+	Name    string `json:"name"`
+	Usage   string `json:"usage"`
+	Default string `json:"default,omitempty"`
+}
+
+// WriteJSON writes a JSON description
+// of the application and its commands.
+func WriteJSON(w io.Writer) error {
+	d := cliDesc{
+		Name:  appName(),
+		Short: Short,
+	}
+	for _, c := range sortedCommands() {
+		d.Commands = append(d.Commands, describe(c, nil))
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(d); err != nil {
+		return errors.Wrap(err, "cmdapp: json")
+	}
+	return nil
+}
+
+// describe returns the JSON description of a command,
+// parents are the groups that host the command.
+func describe(c Command, parents []*Group) cmdDesc {
+	cd := cmdDesc{
+		Name:  c.Name(),
+		Args:  c.Args(),
+		Short: shortText(c),
+		Long:  strings.TrimSpace(longText(c)),
+		Topic: !c.Runnable(),
+	}
+	if c.Runnable() {
+		for _, f := range pathFlags(c, parents) {
+			cd.Flags = append(cd.Flags, flagDesc{
+				Name:    f.Name,
+				Usage:   f.Usage,
+				Default: f.DefValue,
+			})
+		}
+	}
+	for _, v := range commandEnv(c) {
+		cd.Env = append(cd.Env, envVarDesc{
+			Name:    v.Name,
+			Desc:    v.Desc,
+			Default: v.Default,
+			Flag:    v.Flag,
+		})
+	}
+	if _, ok := c.(ExitStatusCommand); ok {
+		cd.Exit = exitStatus(c)
+	}
+	if e, ok := c.(Exampler); ok {
+		cd.Examples = e.Examples()
+	}
+	if g, ok := c.(*Group); ok {
+		parents = append(parents[:len(parents):len(parents)], g)
+		for _, sub := range g.Commands() {
+			cd.Commands = append(cd.Commands, describe(sub, parents))
+		}
+	}
+	return cd
+}
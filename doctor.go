@@ -0,0 +1,235 @@
+// Copyright (c) 2015, J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD-style license that can be found in the LICENSE file.
+
+package cmdapp
+
+import (
+	"crypto/x509"
+	"flag"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// A Check is a check of the environment of the application
+// run by the doctor command.
+type Check struct {
+	// Name is the name of the check.
+	Name string
+
+	// Desc is a short description of the check.
+	Desc string
+
+	// Run runs the check.
+	// It returns an error if the check fails,
+	// or a short report of the checked configuration.
+	Run func() (string, error)
+}
+
+// checks are the checks added by the application.
+var (
+	checkMutex sync.Mutex
+	checks     []Check
+)
+
+// AddCheck adds checks to the doctor command.
+func AddCheck(c ...Check) {
+	checkMutex.Lock()
+	defer checkMutex.Unlock()
+	checks = append(checks, c...)
+}
+
+// DoctorURL is an URL requested by the network check
+// of the doctor command,
+// to detect proxy and certificate problems.
+// If empty,
+// no request is made.
+var DoctorURL string
+
+// frameworkChecks returns the checks of the framework.
+func frameworkChecks() []Check {
+	return []Check{
+		{Name: "network", Desc: "proxy and TLS configuration", Run: checkNetwork},
+	}
+}
+
+// allChecks returns the checks of the framework
+// and the application.
+func allChecks() []Check {
+	checkMutex.Lock()
+	defer checkMutex.Unlock()
+	return append(frameworkChecks(), checks...)
+}
+
+func init() {
+	addBuiltin(&doctorCmd{})
+}
+
+// doctorCmd is the doctor command.
+type doctorCmd struct{}
+
+const doctorLong = `
+Command doctor checks the environment of the application, and reports the
+problems found. By default all the checks are run, if names are given, only
+the checks with that names are run.
+
+The checks are:
+
+%s
+The network check reports the proxy and the certificates used by HTTP
+requests, and if the application has a test URL, it requests the URL to detect
+certificate problems, for example, in a network that inspects HTTPS traffic
+with its own certificates, that can be trusted with the -ca-cert flag (or the
+ca-certs configuration key, if the application defines it).
+`
+
+func (d *doctorCmd) Name() string   { return "doctor" }
+func (d *doctorCmd) Args() string   { return "[<check>...]" }
+func (d *doctorCmd) Short() string  { return "checks the environment of " + Name }
+func (d *doctorCmd) Runnable() bool { return true }
+
+func (d *doctorCmd) Long() string {
+	var b strings.Builder
+	for _, c := range allChecks() {
+		fmt.Fprintf(&b, "    %-16s %s\n", c.Name, c.Desc)
+	}
+	return fmt.Sprintf(doctorLong, b.String())
+}
+
+func (d *doctorCmd) Register(fs *flag.FlagSet) {}
+
+func (d *doctorCmd) Run(args []string) error {
+	all := allChecks()
+	run := all
+	if len(args) > 0 {
+		run = nil
+		for _, a := range args {
+			found := false
+			for _, c := range all {
+				if strings.EqualFold(c.Name, a) {
+					run = append(run, c)
+					found = true
+					break
+				}
+			}
+			if !found {
+				return errors.Errorf("unknown check %s", a)
+			}
+		}
+	}
+
+	failed := 0
+	for _, c := range run {
+		rep, err := c.Run()
+		if err != nil {
+			failed++
+			fmt.Fprintf(Output(), "%s\n", Mark(Failure, fmt.Sprintf("%s: %v", c.Name, err)))
+			continue
+		}
+		if rep == "" {
+			rep = "ok"
+		}
+		fmt.Fprintf(Output(), "%s\n", Mark(Success, fmt.Sprintf("%s: %s", c.Name, rep)))
+	}
+	if failed > 0 {
+		return errors.Errorf("%s failed", Plural(failed, "check", ""))
+	}
+	return nil
+}
+
+// proxyVars are the environment variables
+// of the proxy.
+var proxyVars = []string{"HTTPS_PROXY", "https_proxy", "HTTP_PROXY", "http_proxy"}
+
+// checkNetwork checks the proxy and TLS configuration
+// of the HTTP client.
+func checkNetwork() (string, error) {
+	var rep []string
+	_, proxy, err := proxyFunc()
+	if err != nil {
+		return "", err
+	}
+	if proxy == "" {
+		for _, v := range proxyVars {
+			val := os.Getenv(v)
+			if val == "" {
+				continue
+			}
+			u, err := url.Parse(val)
+			if err != nil || (u.Scheme != "" && u.Host == "") {
+				return "", errors.Errorf("invalid proxy URL in %s: %q", v, val)
+			}
+			if u.Host != "" {
+				val = u.Redacted()
+			}
+			proxy = val + " (" + v + ")"
+			break
+		}
+	}
+	if proxy == "" {
+		proxy = "none"
+	}
+	rep = append(rep, "proxy: "+proxy)
+	if np := os.Getenv("NO_PROXY"); np != "" {
+		rep = append(rep, "no proxy: "+np)
+	}
+
+	_, files, err := certPool()
+	if err != nil {
+		return "", err
+	}
+	if len(files) > 0 {
+		rep = append(rep, "certificates: system and "+strings.Join(files, ", "))
+	}
+	if httpInsecure {
+		rep = append(rep, "certificates are not verified (-insecure)")
+	}
+
+	if DoctorURL == "" || Offline() {
+		return strings.Join(rep, "; "), nil
+	}
+	c, err := HTTPClient()
+	if err != nil {
+		return "", err
+	}
+	req, err := http.NewRequestWithContext(Context(), http.MethodHead, DoctorURL, nil)
+	if err != nil {
+		return "", err
+	}
+	resp, err := c.Transport.RoundTrip(req)
+	if err != nil {
+		return "", errors.Errorf("%s: %s", DoctorURL, networkProblem(err))
+	}
+	resp.Body.Close()
+	rep = append(rep, fmt.Sprintf("%s: %s", DoctorURL, resp.Status))
+	return strings.Join(rep, "; "), nil
+}
+
+// networkProblem returns a description of a network error,
+// with a hint of the solution
+// for certificate problems.
+func networkProblem(err error) string {
+	for e := err; e != nil; {
+		switch v := e.(type) {
+		case x509.UnknownAuthorityError:
+			return fmt.Sprintf("%v (if the network uses its own certificates, add them with -ca-cert)", err)
+		case x509.HostnameError:
+			return fmt.Sprintf("%v (the certificate is for another host, check the proxy)", err)
+		case x509.CertificateInvalidError:
+			return fmt.Sprintf("%v (check the date of the system)", err)
+		case interface{ Cause() error }:
+			e = v.Cause()
+		case interface{ Unwrap() error }:
+			e = v.Unwrap()
+		default:
+			e = nil
+		}
+	}
+	return err.Error()
+}
@@ -0,0 +1,334 @@
+// Copyright (c) 2015, J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD-style license that can be found in the LICENSE file.
+
+// Package download fetches files from URLs
+// for cmdapp applications,
+// reporting the progress with cmdapp.NewProgress,
+// and logging with cmdapp.Logger.
+//
+// Interrupted downloads are resumed,
+// failed requests are retried,
+// and the content can be verified with a checksum.
+// The requests are made with cmdapp.HTTPClient,
+// so they follow the proxy and certificate flags
+// of the application.
+//
+// A file is downloaded with:
+//
+//	err := download.File(cmdapp.Context(), url, "data.zip", download.Options{
+//		Checksum: "sha256:9f86d08...",
+//	})
+package download
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/js-arias/cmdapp"
+	"github.com/js-arias/cmdapp/verify"
+	"github.com/pkg/errors"
+)
+
+// Options are the options of a download.
+type Options struct {
+	// Checksum is the expected checksum of the file,
+	// in the form '<algorithm>:<hex digest>',
+	// as used by verify.Checksum.
+	// If empty,
+	// the file is not verified.
+	Checksum string
+
+	// Retries is the number of times
+	// a failed request is retried.
+	// If zero,
+	// the request is retried 3 times;
+	// if negative,
+	// it is not retried.
+	Retries int
+
+	// Client is the HTTP client used for the requests.
+	// If nil,
+	// the client of cmdapp.HTTPClient is used,
+	// without its timeout.
+	Client *http.Client
+
+	// Title is the title of the progress.
+	// If empty,
+	// the base name of the URL is used.
+	Title string
+}
+
+// File downloads an URL in a file.
+//
+// The data is written in the file '<name>.part',
+// that is renamed to the given name
+// when the download is complete
+// and the checksum is verified.
+// If the partial file exists,
+// for example from an interrupted download,
+// the download continues from the end of the partial file,
+// if the server supports it,
+// and the file was not changed in the server.
+// The ETag or Last-Modified header
+// of the file in the server
+// is kept in the file '<name>.part.validator',
+// and if the partial file has no validator,
+// or the server file is different,
+// the download restarts from the beginning.
+//
+// In offline mode
+// (see cmdapp.Offline)
+// it fails immediately with cmdapp.ErrOffline.
+func File(ctx context.Context, url, name string, opts Options) error {
+	if opts.Checksum != "" {
+		if err := verify.ValidChecksum(opts.Checksum); err != nil {
+			return errors.Wrapf(err, "download %s", url)
+		}
+	}
+	if cmdapp.Offline() {
+		return errors.Wrapf(cmdapp.ErrOffline, "download %s", url)
+	}
+	if opts.Client == nil {
+		c, err := cmdapp.HTTPClient()
+		if err != nil {
+			return errors.Wrapf(err, "download %s", url)
+		}
+		// a download can take more time
+		// than a regular request
+		dc := *c
+		dc.Timeout = 0
+		opts.Client = &dc
+	}
+	retries := opts.Retries
+	if retries == 0 {
+		retries = 3
+	}
+	if opts.Title == "" {
+		opts.Title = path.Base(url)
+	}
+
+	part := name + ".part"
+	p := cmdapp.NewProgress()
+	p.Start(opts.Title)
+	defer p.Done()
+
+	var err error
+	for try := 0; ; try++ {
+		var retry bool
+		retry, err = fetch(ctx, url, part, opts.Client, p)
+		if err == nil || !retry || try >= retries {
+			break
+		}
+		wait := time.Duration(1<<try) * time.Second
+		cmdapp.Logger().Info("download: retry", "url", url, "error", err.Error(), "wait", wait.String())
+		select {
+		case <-ctx.Done():
+			return errors.Wrapf(ctx.Err(), "download %s", url)
+		case <-time.After(wait):
+		}
+	}
+	if err != nil {
+		return errors.Wrapf(err, "download %s", url)
+	}
+
+	if opts.Checksum != "" {
+		if err := verify.Checksum(part, opts.Checksum); err != nil {
+			os.Remove(part)
+			os.Remove(part + validatorExt)
+			return errors.Wrapf(err, "download %s", url)
+		}
+	}
+	if err := os.Rename(part, name); err != nil {
+		return errors.Wrapf(err, "download %s", url)
+	}
+	os.Remove(part + validatorExt)
+	cmdapp.Logger().Info("download: done", "url", url, "file", name)
+	return nil
+}
+
+// fetch downloads an URL in a partial file,
+// continuing from the end of the file.
+// It returns true if the error is temporary
+// and the request can be retried.
+func fetch(ctx context.Context, url, part string, client *http.Client, p cmdapp.Progress) (bool, error) {
+	var offset int64
+	if fi, err := os.Stat(part); err == nil {
+		offset = fi.Size()
+	}
+	validator := readValidator(part)
+	if validator == "" {
+		// without a validator
+		// the partial file can not be matched
+		// with the file in the server
+		offset = 0
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return false, err
+	}
+	if offset > 0 {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+		req.Header.Set("If-Range", validator)
+	}
+	cmdapp.Logger().Info("download", "url", url, "offset", offset)
+	resp, err := client.Do(req)
+	if err != nil {
+		return ctx.Err() == nil, err
+	}
+	defer resp.Body.Close()
+
+	flags := os.O_WRONLY | os.O_CREATE
+	switch {
+	case resp.StatusCode == http.StatusPartialContent && offset > 0:
+		start, size, ok := contentRange(resp.Header.Get("Content-Range"))
+		if !ok || start != offset {
+			return restart(ctx, url, part, client, p)
+		}
+		if size >= 0 {
+			resp.ContentLength = size - offset
+		}
+		flags |= os.O_APPEND
+	case resp.StatusCode == http.StatusRequestedRangeNotSatisfiable && offset > 0:
+		if _, size, ok := contentRange(resp.Header.Get("Content-Range")); !ok || size != offset {
+			return restart(ctx, url, part, client, p)
+		}
+		// the partial file is already complete
+		return false, nil
+	case resp.StatusCode == http.StatusOK:
+		offset = 0
+		flags |= os.O_TRUNC
+		if err := writeValidator(part, resp.Header); err != nil {
+			return false, err
+		}
+	case resp.StatusCode >= 500 || resp.StatusCode == http.StatusTooManyRequests:
+		return true, errors.New(resp.Status)
+	default:
+		return false, errors.New(resp.Status)
+	}
+
+	total := int64(0)
+	if resp.ContentLength >= 0 {
+		total = offset + resp.ContentLength
+	}
+	f, err := os.OpenFile(part, flags, 0644)
+	if err != nil {
+		return false, err
+	}
+	w := &progressWriter{p: p, n: offset, total: total}
+	p.Update(offset, total)
+	_, err = io.Copy(io.MultiWriter(f, w), resp.Body)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return ctx.Err() == nil, err
+	}
+	return false, nil
+}
+
+// restart removes the partial file
+// and downloads the URL from the beginning.
+func restart(ctx context.Context, url, part string, client *http.Client, p cmdapp.Progress) (bool, error) {
+	cmdapp.Logger().Info("download: restart", "url", url)
+	os.Remove(part + validatorExt)
+	if err := os.Remove(part); err != nil && !os.IsNotExist(err) {
+		return false, err
+	}
+	return fetch(ctx, url, part, client, p)
+}
+
+// contentRange returns the first byte
+// and the size of the file
+// of a Content-Range header,
+// as 'bytes <first>-<last>/<size>'
+// or 'bytes */<size>'.
+// The size is -1 if it is unknown,
+// and the first byte is -1 if it is not given.
+func contentRange(h string) (start, size int64, ok bool) {
+	h, ok = strings.CutPrefix(h, "bytes ")
+	if !ok {
+		return 0, 0, false
+	}
+	rng, sz, ok := strings.Cut(h, "/")
+	if !ok {
+		return 0, 0, false
+	}
+	size = -1
+	if sz != "*" {
+		n, err := strconv.ParseInt(sz, 10, 64)
+		if err != nil || n < 0 {
+			return 0, 0, false
+		}
+		size = n
+	}
+	start = -1
+	if rng != "*" {
+		first, _, ok := strings.Cut(rng, "-")
+		if !ok {
+			return 0, 0, false
+		}
+		n, err := strconv.ParseInt(first, 10, 64)
+		if err != nil || n < 0 {
+			return 0, 0, false
+		}
+		start = n
+	}
+	return start, size, true
+}
+
+// validatorExt is the extension of the file
+// with the validator of a partial file.
+const validatorExt = ".validator"
+
+// readValidator returns the validator
+// of a partial file,
+// or an empty string if it has no validator.
+func readValidator(part string) string {
+	b, err := os.ReadFile(part + validatorExt)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(b))
+}
+
+// writeValidator keeps the validator
+// of the file in the server
+// (its ETag, or its Last-Modified time
+// if the ETag is weak or missing)
+// used to resume a partial file.
+func writeValidator(part string, h http.Header) error {
+	v := h.Get("ETag")
+	if v == "" || strings.HasPrefix(v, "W/") {
+		v = h.Get("Last-Modified")
+	}
+	if v == "" {
+		if err := os.Remove(part + validatorExt); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		return nil
+	}
+	return cmdapp.WriteFileAtomic(part+validatorExt, []byte(v+"\n"), cmdapp.SharedMode)
+}
+
+// progressWriter counts the bytes written
+// and updates a progress.
+type progressWriter struct {
+	p     cmdapp.Progress
+	n     int64
+	total int64
+}
+
+func (w *progressWriter) Write(b []byte) (int, error) {
+	w.n += int64(len(b))
+	w.p.Update(w.n, w.total)
+	return len(b), nil
+}
@@ -0,0 +1,206 @@
+// Copyright (c) 2015, J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD-style license that can be found in the LICENSE file.
+
+package download
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+const content = "0123456789abcdefghijklmnopqrstuvwxyz"
+
+// serveFile serves the content with an ETag.
+func serveFile(etag string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("ETag", etag)
+		http.ServeContent(w, r, "data", time.Time{}, bytes.NewReader([]byte(content)))
+	}
+}
+
+func TestFileResume(t *testing.T) {
+	tests := []struct {
+		name      string
+		handler   http.HandlerFunc
+		part      string
+		validator string
+		wantRange string
+	}{
+		{
+			name:    "new download",
+			handler: serveFile(`"v1"`),
+		},
+		{
+			name:      "resume",
+			handler:   serveFile(`"v1"`),
+			part:      content[:10],
+			validator: `"v1"`,
+			wantRange: "bytes=10-",
+		},
+		{
+			name:      "changed file",
+			handler:   serveFile(`"v2"`),
+			part:      "old data",
+			validator: `"v1"`,
+			wantRange: "bytes=8-",
+		},
+		{
+			name:    "without validator",
+			handler: serveFile(`"v1"`),
+			part:    "old data",
+		},
+		{
+			name: "wrong content range",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("ETag", `"v1"`)
+				if r.Header.Get("Range") == "" {
+					fmt.Fprint(w, content)
+					return
+				}
+				w.Header().Set("Content-Range", fmt.Sprintf("bytes 0-%d/%d", len(content)-1, len(content)))
+				w.WriteHeader(http.StatusPartialContent)
+				fmt.Fprint(w, content)
+			},
+			part:      content[:10],
+			validator: `"v1"`,
+			wantRange: "bytes=10-",
+		},
+		{
+			name: "not satisfiable with a different size",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("ETag", `"v1"`)
+				if r.Header.Get("Range") == "" {
+					fmt.Fprint(w, content)
+					return
+				}
+				w.Header().Set("Content-Range", fmt.Sprintf("bytes */%d", len(content)))
+				w.WriteHeader(http.StatusRequestedRangeNotSatisfiable)
+			},
+			part:      content + "garbage",
+			validator: `"v1"`,
+			wantRange: fmt.Sprintf("bytes=%d-", len(content)+7),
+		},
+		{
+			name:      "complete partial file",
+			handler:   serveFile(`"v1"`),
+			part:      content,
+			validator: `"v1"`,
+			wantRange: fmt.Sprintf("bytes=%d-", len(content)),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var gotRange string
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if rng := r.Header.Get("Range"); rng != "" {
+					gotRange = rng
+				}
+				test.handler(w, r)
+			}))
+			defer srv.Close()
+
+			name := filepath.Join(t.TempDir(), "data")
+			if test.part != "" {
+				if err := os.WriteFile(name+".part", []byte(test.part), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if test.validator != "" {
+				if err := os.WriteFile(name+".part"+validatorExt, []byte(test.validator), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			if err := File(context.Background(), srv.URL, name, Options{Client: srv.Client(), Retries: -1}); err != nil {
+				t.Fatalf("download: %v", err)
+			}
+			if gotRange != test.wantRange {
+				t.Errorf("range %q, want %q", gotRange, test.wantRange)
+			}
+			b, err := os.ReadFile(name)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(b) != content {
+				t.Errorf("content %q, want %q", b, content)
+			}
+			for _, ext := range []string{".part", ".part" + validatorExt} {
+				if _, err := os.Stat(name + ext); !os.IsNotExist(err) {
+					t.Errorf("file %s not removed", ext)
+				}
+			}
+		})
+	}
+}
+
+func TestContentRange(t *testing.T) {
+	tests := []struct {
+		h           string
+		start, size int64
+		ok          bool
+	}{
+		{"bytes 10-35/36", 10, 36, true},
+		{"bytes 0-9/*", 0, -1, true},
+		{"bytes */36", -1, 36, true},
+		{"bytes 10-35", 0, 0, false},
+		{"items 10-35/36", 0, 0, false},
+		{"bytes x-35/36", 0, 0, false},
+		{"", 0, 0, false},
+	}
+	for _, test := range tests {
+		start, size, ok := contentRange(test.h)
+		if start != test.start || size != test.size || ok != test.ok {
+			t.Errorf("contentRange(%q) = %d, %d, %v; want %d, %d, %v", test.h, start, size, ok, test.start, test.size, test.ok)
+		}
+	}
+}
+
+func TestFileChecksum(t *testing.T) {
+	sum := sha256.Sum256([]byte(content))
+	good := "sha256:" + hex.EncodeToString(sum[:])
+	tests := []struct {
+		name     string
+		checksum string
+		ok       bool
+		requests int
+	}{
+		{name: "valid", checksum: good, ok: true, requests: 1},
+		{name: "upper case", checksum: strings.ToUpper(good[len("sha256:"):]), ok: true, requests: 1},
+		{name: "mismatch", checksum: "sha256:" + strings.Repeat("0", 64), requests: 1},
+		{name: "unknown algorithm", checksum: "md5:" + strings.Repeat("0", 32)},
+		{name: "not hex", checksum: "sha256:" + strings.Repeat("z", 64)},
+		{name: "short digest", checksum: "sha256:abcd"},
+		{name: "sha512 size", checksum: "sha512:" + hex.EncodeToString(sum[:])},
+	}
+	for _, test := range tests {
+		requests := 0
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requests++
+			serveFile(`"v1"`)(w, r)
+		}))
+		name := filepath.Join(t.TempDir(), "data")
+		err := File(context.Background(), srv.URL, name, Options{Client: srv.Client(), Retries: -1, Checksum: test.checksum})
+		srv.Close()
+		if (err == nil) != test.ok {
+			t.Errorf("%s: error %v", test.name, err)
+		}
+		if requests != test.requests {
+			t.Errorf("%s: %d requests, want %d", test.name, requests, test.requests)
+		}
+		if _, err := os.Stat(name); (err == nil) != test.ok {
+			t.Errorf("%s: file written %v, want %v", test.name, err == nil, test.ok)
+		}
+	}
+}
@@ -0,0 +1,168 @@
+// Copyright (c) 2015, J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD-style license that can be found in the LICENSE file.
+
+package cmdapp
+
+import (
+	"bufio"
+	"flag"
+	"io"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// A ClonerCommand is a command
+// that can make copies of itself,
+// so it can be run concurrently.
+type ClonerCommand interface {
+	Command
+
+	// Clone returns a new instance of the command.
+	Clone() Command
+}
+
+// each is the each command.
+type each struct {
+	parallel int
+	file     string
+	output   TaskOutput
+}
+
+func init() {
+	addBuiltin(&each{})
+}
+
+const eachLong = `
+Command each runs a command once for each item read from the standard input,
+one item per line. Each occurrence of {} in the arguments of the command is
+replaced by the item. If the arguments do not contain {}, the item is added as
+the last argument.
+
+Blank lines are ignored. The errors of all items are reported after all items
+are processed, as a numbered list.
+
+The flags are:
+
+    -f <file>
+      Reads the items from the given file, instead of the standard input.
+
+    -parallel <number>
+      Sets the maximum number of items processed concurrently. Only commands
+      that can be cloned are run concurrently.
+
+    -output-mode <mode>
+      Sets how the output of concurrent items is shown. Valid values are
+      'prefix' (the default), that prefixes each line with the item, 'group',
+      that writes the output of an item when it finishes, and 'raw', that
+      writes the output as is. Only commands that accept an output writer
+      are prefixed or grouped.
+`
+
+func (e *each) Name() string { return "each" }
+func (e *each) Args() string {
+	return "[-f <file>] [-parallel <number>] [-output-mode <mode>] <command> [<args>...]"
+}
+func (e *each) Short() string  { return "runs a command for each input item" }
+func (e *each) Long() string   { return eachLong }
+func (e *each) Runnable() bool { return true }
+
+func (e *each) Register(fs *flag.FlagSet) {
+	e.output = PrefixOutput
+	fs.StringVar(&e.file, "f", "", "file with the items")
+	fs.IntVar(&e.parallel, "parallel", 1, "maximum number of concurrent items")
+	fs.Var(&e.output, "output-mode", "output of concurrent items: prefix, group, or raw")
+}
+
+func (e *each) Run(args []string) error {
+	if len(args) == 0 {
+		return errors.New("expecting a command")
+	}
+	c, ok := lookup(args[0])
+	if !ok || !c.Runnable() {
+		return errors.Errorf("unknown command %s", args[0])
+	}
+	if c == Command(e) {
+		return errors.New("each can not run itself")
+	}
+
+	var in io.Reader = os.Stdin
+	if e.file != "" {
+		f, err := os.Open(e.file)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		in = f
+	}
+	var items []string
+	s := bufio.NewScanner(in)
+	for s.Scan() {
+		if it := strings.TrimSpace(s.Text()); it != "" {
+			items = append(items, it)
+		}
+	}
+	if err := s.Err(); err != nil {
+		return err
+	}
+
+	jobs := e.parallel
+	cl, canClone := c.(ClonerCommand)
+	if !canClone {
+		jobs = 1
+	}
+	if jobs < 1 {
+		jobs = 1
+	}
+
+	errs := make([]error, len(items))
+	sem := make(chan struct{}, jobs)
+	var wg sync.WaitGroup
+	for i, it := range items {
+		sem <- struct{}{}
+		wg.Add(1)
+		go func(i int, it string) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			ic := c
+			if canClone && jobs > 1 {
+				ic = cl.Clone()
+				done := newTaskOutput(ic, it, e.output)
+				defer done()
+			}
+			errs[i] = invoke(ic, itemArgs(args[1:], it))
+		}(i, it)
+	}
+	wg.Wait()
+
+	var failed Errors
+	for i, err := range errs {
+		if err != nil {
+			failed.Add(errors.Wrap(err, items[i]))
+		}
+	}
+	return failed.Err()
+}
+
+// itemArgs returns the arguments of a command
+// for an item.
+func itemArgs(args []string, item string) []string {
+	a := make([]string, 0, len(args)+1)
+	found := false
+	for _, s := range args {
+		if strings.Contains(s, "{}") {
+			found = true
+			s = strings.Replace(s, "{}", item, -1)
+		}
+		a = append(a, s)
+	}
+	if !found {
+		a = append(a, item)
+	}
+	return a
+}
@@ -0,0 +1,189 @@
+// Copyright (c) 2015, J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD-style license that can be found in the LICENSE file.
+
+package cmdapp
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/pkg/errors"
+)
+
+// echoCmd is a command
+// that writes its arguments,
+// and fails with the argument 'bad'.
+type echoCmd struct {
+	clone bool
+	w     io.Writer
+}
+
+func (c *echoCmd) Name() string              { return "echo-test" }
+func (c *echoCmd) Args() string              { return "<arg>..." }
+func (c *echoCmd) Short() string             { return "writes its arguments" }
+func (c *echoCmd) Long() string              { return "" }
+func (c *echoCmd) Register(fs *flag.FlagSet) {}
+func (c *echoCmd) Runnable() bool            { return true }
+func (c *echoCmd) SetOutput(w io.Writer)     { c.w = w }
+
+func (c *echoCmd) Run(args []string) error {
+	for _, a := range args {
+		if a == "bad" {
+			return errors.New("bad item")
+		}
+	}
+	w := c.w
+	if w == nil {
+		w = Output()
+	}
+	fmt.Fprintln(w, strings.Join(args, " "))
+	return nil
+}
+
+// clonerCmd is an echoCmd
+// that can be cloned.
+type clonerCmd struct {
+	echoCmd
+}
+
+func (c *clonerCmd) Clone() Command { return &clonerCmd{} }
+
+func TestEach(t *testing.T) {
+	items := filepath.Join(t.TempDir(), "items.txt")
+	if err := os.WriteFile(items, []byte("a\n\n  b  \nc\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	bad := filepath.Join(t.TempDir(), "bad.txt")
+	if err := os.WriteFile(bad, []byte("a\nbad\nc\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name  string
+		clone bool
+		args  []string
+		code  int
+		want  []string
+	}{
+		{
+			name: "item as the last argument",
+			args: []string{"each", "-f", items, "echo-test", "x"},
+			want: []string{"x a", "x b", "x c"},
+		},
+		{
+			name: "placeholder",
+			args: []string{"each", "-f", items, "echo-test", "pre-{}-post", "{}"},
+			want: []string{"pre-a-post a", "pre-b-post b", "pre-c-post c"},
+		},
+		{
+			name: "failed item",
+			args: []string{"each", "-f", bad, "echo-test"},
+			code: 1,
+			want: []string{"a", "c"},
+		},
+		{
+			name:  "parallel prefix",
+			clone: true,
+			args:  []string{"each", "-f", items, "-parallel", "3", "echo-test"},
+			want:  []string{"[a] a", "[b] b", "[c] c"},
+		},
+		{
+			name:  "parallel group",
+			clone: true,
+			args:  []string{"each", "-f", items, "-parallel", "3", "-output-mode", "group", "echo-test", "{}"},
+			want:  []string{"a", "b", "c"},
+		},
+		{
+			name:  "parallel raw",
+			clone: true,
+			args:  []string{"each", "-f", items, "-parallel", "2", "-output-mode", "raw", "echo-test"},
+			want:  []string{"a", "b", "c"},
+		},
+		{
+			// the output mode of the previous test
+			// must not be kept
+			name:  "default output mode",
+			clone: true,
+			args:  []string{"each", "-f", items, "-parallel", "2", "echo-test"},
+			want:  []string{"[a] a", "[b] b", "[c] c"},
+		},
+		{
+			name: "parallel without clone",
+			args: []string{"each", "-f", items, "-parallel", "3", "echo-test"},
+			want: []string{"a", "b", "c"},
+		},
+		{
+			name: "no command",
+			args: []string{"each", "-f", items},
+			code: 1,
+		},
+		{
+			name: "unknown command",
+			args: []string{"each", "-f", items, "no-such-command"},
+			code: 1,
+		},
+		{
+			name: "each itself",
+			args: []string{"each", "-f", items, "each", "echo-test"},
+			code: 1,
+		},
+		{
+			name: "missing file",
+			args: []string{"each", "-f", filepath.Join(t.TempDir(), "none"), "echo-test"},
+			code: 1,
+		},
+	}
+
+	var out bytes.Buffer
+	a := NewApp("testapp", "a test application")
+	a.Stdout, a.Stderr = &out, io.Discard
+	a.Add(&echoCmd{})
+	c := NewApp("testapp", "a test application")
+	c.Stdout, c.Stderr = &out, io.Discard
+	c.Add(&clonerCmd{})
+
+	for _, test := range tests {
+		out.Reset()
+		app := a
+		if test.clone {
+			app = c
+		}
+		if code := app.Dispatch(test.args); code != test.code {
+			t.Errorf("%s: exit code %d, want %d", test.name, code, test.code)
+		}
+		var got []string
+		if s := strings.TrimSpace(out.String()); s != "" {
+			got = strings.Split(s, "\n")
+		}
+		sort.Strings(got)
+		if strings.Join(got, "\n") != strings.Join(test.want, "\n") {
+			t.Errorf("%s: output %q, want %q", test.name, got, test.want)
+		}
+	}
+}
+
+func TestItemArgs(t *testing.T) {
+	tests := []struct {
+		args []string
+		want []string
+	}{
+		{nil, []string{"it"}},
+		{[]string{"-n", "1"}, []string{"-n", "1", "it"}},
+		{[]string{"{}.txt", "x"}, []string{"it.txt", "x"}},
+		{[]string{"{}-{}", "{}"}, []string{"it-it", "it"}},
+	}
+	for _, test := range tests {
+		got := itemArgs(test.args, "it")
+		if strings.Join(got, "\x00") != strings.Join(test.want, "\x00") {
+			t.Errorf("%q: got %q, want %q", test.args, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,133 @@
+// Copyright (c) 2015, J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD-style license that can be found in the LICENSE file.
+
+package cmdapp
+
+import (
+	"bytes"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// OpenInEditor opens a text in the editor of the user,
+// and returns the edited text.
+//
+// The editor is set by the VISUAL or EDITOR environment variables,
+// by default it is vi
+// (notepad in Windows).
+// The text is edited in a temporary file,
+// that is removed after the editor is closed.
+// If the text does not use CRLF line endings,
+// the CRLF line endings
+// and the byte order mark
+// added by Windows editors
+// are removed.
+func OpenInEditor(initial []byte) ([]byte, error) {
+	f, err := os.CreateTemp("", appName()+"-*.txt")
+	if err != nil {
+		return nil, errors.Wrap(err, "editor")
+	}
+	name := f.Name()
+	defer os.Remove(name)
+
+	_, err = f.Write(initial)
+	// the file must be closed before the editor opens it,
+	// as Windows does not share open files
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "editor")
+	}
+
+	if err := runEditor(name); err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(name)
+	if err != nil {
+		return nil, errors.Wrap(err, "editor")
+	}
+	if !bytes.Contains(initial, []byte("\r\n")) {
+		data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))
+		data = bytes.Replace(data, []byte("\r\n"), []byte("\n"), -1)
+	}
+	return data, nil
+}
+
+// runEditor opens a file in the editor of the user.
+func runEditor(name string) error {
+	ed, err := editorArgs()
+	if err != nil {
+		return err
+	}
+	cmd := exec.Command(ed[0], append(ed[1:], name)...)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	if err := cmd.Run(); err != nil {
+		return errors.Wrap(err, "editor")
+	}
+	return nil
+}
+
+// editorArgs returns the program and arguments
+// of the editor of the user.
+func editorArgs() ([]string, error) {
+	editor := strings.TrimSpace(os.Getenv("VISUAL"))
+	if editor == "" {
+		editor = strings.TrimSpace(os.Getenv("EDITOR"))
+	}
+	if editor == "" {
+		if runtime.GOOS == "windows" {
+			return []string{"notepad"}, nil
+		}
+		return []string{"vi"}, nil
+	}
+
+	// a path with spaces,
+	// as "C:\Program Files\Editor\editor.exe"
+	if _, err := os.Stat(editor); err == nil {
+		return []string{editor}, nil
+	}
+	if runtime.GOOS == "windows" {
+		return splitQuoted(editor), nil
+	}
+	w, err := splitWords(editor)
+	if err != nil || len(w) == 0 {
+		return nil, errors.Errorf("editor: invalid editor %q", editor)
+	}
+	return w, nil
+}
+
+// splitQuoted splits a command line in words
+// separated by spaces,
+// in which double quotes group words,
+// as in Windows command lines.
+func splitQuoted(s string) []string {
+	var words []string
+	var w strings.Builder
+	inWord, quoted := false, false
+	for _, r := range s {
+		switch {
+		case r == '"':
+			quoted = !quoted
+			inWord = true
+		case (r == ' ' || r == '\t') && !quoted:
+			if inWord {
+				words = append(words, w.String())
+				w.Reset()
+				inWord = false
+			}
+		default:
+			w.WriteRune(r)
+			inWord = true
+		}
+	}
+	if inWord {
+		words = append(words, w.String())
+	}
+	return words
+}
@@ -0,0 +1,332 @@
+// Copyright (c) 2015, J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD-style license that can be found in the LICENSE file.
+
+package cmdapp
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"text/tabwriter"
+
+	"github.com/pkg/errors"
+)
+
+// An EnvVar is an environment variable
+// read by the application.
+type EnvVar struct {
+	// Name is the name of the variable.
+	Name string
+
+	// Desc is a short description of the variable.
+	Desc string
+
+	// Default is the value used
+	// if the variable is not set.
+	Default string
+
+	// Flag is the name of a flag
+	// whose value is set from the variable
+	// if the flag is not given in the command line.
+	Flag string
+
+	// Command is the name of the command
+	// that reads the variable,
+	// or the command of the flag.
+	// If empty,
+	// the variable is read by the application,
+	// and the flag is an application flag.
+	Command string
+
+	// Secret is true if the value should be masked
+	// when it is shown.
+	Secret bool
+}
+
+// envVars are the environment variables
+// registered by the application.
+var (
+	envMutex sync.Mutex
+	envVars  []EnvVar
+)
+
+// An EnvCommand is a command
+// that reads environment variables.
+// The variables are shown in 'help <this-command>' output,
+// the manual page,
+// and the env command.
+type EnvCommand interface {
+	Command
+
+	// Env returns the environment variables
+	// read by the command.
+	// If the Command field of a variable is empty,
+	// it is set to the name of the command.
+	Env() []EnvVar
+}
+
+// commandEnv returns the environment variables
+// read by a command.
+func commandEnv(c Command) []EnvVar {
+	e, ok := c.(EnvCommand)
+	if !ok {
+		return nil
+	}
+	vars := e.Env()
+	for i := range vars {
+		if vars[i].Command == "" {
+			vars[i].Command = c.Name()
+		}
+	}
+	return vars
+}
+
+// envDesc returns the description of a variable,
+// with its default value.
+func envDesc(v EnvVar) string {
+	if v.Default == "" {
+		return v.Desc
+	}
+	return fmt.Sprintf("%s (default: %s)", v.Desc, v.Default)
+}
+
+// AddEnv registers environment variables
+// read by the application.
+func AddEnv(vars ...EnvVar) {
+	envMutex.Lock()
+	defer envMutex.Unlock()
+	envVars = append(envVars, vars...)
+}
+
+// frameworkEnv returns the environment variables
+// read by the framework.
+func frameworkEnv() []EnvVar {
+	return []EnvVar{
+		{Name: envName("ACCESSIBLE"), Desc: "use output suitable for screen readers"},
+		{Name: envName("LOG_FILE"), Desc: "file for the log", Flag: "log-file"},
+		{Name: envName("CA_CERT"), Desc: "file with trusted certificates for HTTPS", Flag: "ca-cert"},
+		{Name: envName("NO_HINTS"), Desc: "do not show hints", Flag: "no-hints"},
+		{Name: envName("OFFLINE"), Desc: "run in offline mode", Flag: "offline"},
+		{Name: envName("REPORT"), Desc: "file for the JSON summary of the run", Flag: "report"},
+		{Name: "HTTPS_PROXY", Desc: "proxy for HTTPS requests"},
+		{Name: "HTTP_PROXY", Desc: "proxy for HTTP requests"},
+		{Name: "NO_PROXY", Desc: "hosts accessed without a proxy"},
+		{Name: "NO_COLOR", Desc: "disable colors"},
+		{Name: "FORCE_HYPERLINK", Desc: "enable or disable terminal hyperlinks"},
+		{Name: "VISUAL", Desc: "editor used by the application"},
+		{Name: "EDITOR", Desc: "editor used if VISUAL is not set"},
+	}
+}
+
+// EnvVars returns the environment variables
+// read by the application, its commands, and the framework,
+// sorted by name.
+func EnvVars() []EnvVar {
+	envMutex.Lock()
+	vars := append(frameworkEnv(), envVars...)
+	envMutex.Unlock()
+	for _, c := range sortedCommands() {
+		vars = append(vars, commandEnv(c)...)
+	}
+	sort.SliceStable(vars, func(i, j int) bool { return vars[i].Name < vars[j].Name })
+	return vars
+}
+
+// bindEnv sets the flags of a flag set
+// from its bound environment variables.
+// The command is empty for the application flags.
+func bindEnv(fs *flag.FlagSet, cmd string) error {
+	for _, v := range EnvVars() {
+		if v.Flag == "" || !strings.EqualFold(v.Command, cmd) {
+			continue
+		}
+		val, ok := os.LookupEnv(v.Name)
+		if !ok || fs.Lookup(v.Flag) == nil {
+			continue
+		}
+		if err := fs.Set(v.Flag, val); err != nil {
+			return errors.Errorf("environment variable %s: %v", v.Name, err)
+		}
+	}
+	return nil
+}
+
+// Exports is the function that returns
+// the environment variables printed by 'env -export',
+// for example the credentials of the active profile.
+// The names must be valid shell names
+// (letters, digits and underscores,
+// not starting with a digit).
+var Exports func() (map[string]string, error)
+
+// envCmd is the env command.
+type envCmd struct {
+	reveal bool
+	export bool
+	shell  string
+}
+
+func init() {
+	addBuiltin(&envCmd{})
+}
+
+const envLong = `
+Command env prints the environment variables read by the application, with
+its current values, and the flags that are set by them. A flag given in the
+command line overrides the value of the environment variable.
+
+Values of secret variables are masked.
+
+With the -export flag, it prints the variables exported by the application as
+shell commands, to be used with eval, for example:
+
+    eval "$(<app> env -export)"
+
+In cmd, the output can be run with 'for /f':
+
+    for /f "delims=" %i in ('<app> env -export') do %i
+
+The flags are:
+
+    -export
+      Prints the exported variables as shell commands.
+
+    -reveal
+      Shows the values of secret variables.
+
+    -shell <shell>
+      Sets the shell used by -export. Valid values are sh, bash, zsh,
+      fish, powershell, and cmd. By default the shell of the user is
+      used.
+`
+
+func (e *envCmd) Name() string   { return "env" }
+func (e *envCmd) Args() string   { return "[-reveal] [-export [-shell <shell>]]" }
+func (e *envCmd) Short() string  { return "prints the environment variables of the application" }
+func (e *envCmd) Long() string   { return envLong }
+func (e *envCmd) Runnable() bool { return true }
+
+func (e *envCmd) Register(fs *flag.FlagSet) {
+	fs.BoolVar(&e.reveal, "reveal", false, "show values of secret variables")
+	fs.BoolVar(&e.export, "export", false, "print exported variables as shell commands")
+	fs.StringVar(&e.shell, "shell", "", "shell used by -export")
+}
+
+func (e *envCmd) Run(args []string) error {
+	if e.export {
+		return e.exportVars()
+	}
+	tw := tabwriter.NewWriter(Output(), 0, 4, 2, ' ', 0)
+	fmt.Fprintf(tw, "VARIABLE\tVALUE\tFLAG\tDESCRIPTION\n")
+	for _, v := range EnvVars() {
+		val, ok := os.LookupEnv(v.Name)
+		switch {
+		case !ok:
+			val = "-"
+		case v.Secret && !e.reveal && val != "":
+			val = "********"
+		}
+		fl := "-"
+		if v.Flag != "" {
+			fl = "-" + v.Flag
+			if v.Command != "" {
+				fl = v.Command + " -" + v.Flag
+			}
+		}
+		desc := envDesc(v)
+		if v.Flag == "" && v.Command != "" {
+			desc = v.Command + ": " + desc
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", v.Name, val, fl, desc)
+	}
+	return tw.Flush()
+}
+
+// exportVars prints the exported variables
+// as shell commands.
+func (e *envCmd) exportVars() error {
+	if Exports == nil {
+		return errors.New("the application does not export variables")
+	}
+	vars, err := Exports()
+	if err != nil {
+		return err
+	}
+	names := make([]string, 0, len(vars))
+	for nm := range vars {
+		names = append(names, nm)
+	}
+	sort.Strings(names)
+
+	shell := e.shell
+	switch shell {
+	case "":
+		shell = UserShell()
+	case "sh", "bash", "zsh", "fish", "powershell", "cmd":
+	default:
+		return &usageError{errors.Errorf("unknown shell %q", shell)}
+	}
+	for _, nm := range names {
+		if !validEnvName(nm) {
+			return errors.Errorf("invalid variable name %q", nm)
+		}
+	}
+	w := &strings.Builder{}
+	for _, nm := range names {
+		switch shell {
+		case "fish":
+			fmt.Fprintf(w, "set -gx %s %s;\n", nm, fishQuote(vars[nm]))
+		case "powershell":
+			fmt.Fprintf(w, "$Env:%s = %s\n", nm, psQuote(vars[nm]))
+		case "cmd":
+			if strings.ContainsAny(vars[nm], "\r\n") {
+				return errors.Errorf("variable %s: value with a new line can not be set in cmd", nm)
+			}
+			fmt.Fprintf(w, "set %s=%s\n", nm, cmdEscape(vars[nm]))
+		default:
+			fmt.Fprintf(w, "export %s=%s\n", nm, shQuote(vars[nm]))
+		}
+	}
+	_, err = io.WriteString(Output(), w.String())
+	return err
+}
+
+// validEnvName reports whether a name
+// is a valid name for an environment variable
+// that can be exported by any shell,
+// i.e. it matches [A-Za-z_][A-Za-z0-9_]*.
+func validEnvName(nm string) bool {
+	if nm == "" {
+		return false
+	}
+	for i, r := range nm {
+		switch {
+		case r == '_':
+		case 'a' <= r && r <= 'z', 'A' <= r && r <= 'Z':
+		case '0' <= r && r <= '9' && i > 0:
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// cmdEscape escapes the special characters of cmd
+// with a caret,
+// so a value can be used unquoted
+// in a set command.
+func cmdEscape(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		if strings.ContainsRune("^&|<>()%!\"", r) {
+			b.WriteByte('^')
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
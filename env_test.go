@@ -0,0 +1,167 @@
+// Copyright (c) 2015, J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD-style license that can be found in the LICENSE file.
+
+package cmdapp
+
+import (
+	"flag"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func init() {
+	AddEnv(
+		EnvVar{Name: "CMDAPP_TEST_COUNT", Desc: "a count", Command: "env-test", Flag: "count"},
+		EnvVar{Name: "CMDAPP_TEST_WAIT", Desc: "a time", Command: "env-test", Flag: "wait"},
+		EnvVar{Name: "CMDAPP_TEST_DEBUG", Desc: "debug mode", Command: "env-test", Flag: "debug"},
+	)
+}
+
+// envFlagSet returns a flag set
+// with the flags bound to environment variables.
+func envFlagSet() (*flag.FlagSet, *int, *time.Duration, *bool) {
+	fs := flag.NewFlagSet("env-test", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	n := fs.Int("count", 1, "")
+	d := fs.Duration("wait", 0, "")
+	b := fs.Bool("debug", false, "")
+	return fs, n, d, b
+}
+
+func TestBindEnv(t *testing.T) {
+	tests := []struct {
+		count, wait, debug string
+		n                  int
+		d                  time.Duration
+		b                  bool
+		err                string
+	}{
+		{n: 1},
+		{count: "3", wait: "2s", debug: "true", n: 3, d: 2 * time.Second, b: true},
+		{count: "x", err: "CMDAPP_TEST_COUNT"},
+		{wait: "2", err: "CMDAPP_TEST_WAIT"},
+		{debug: "maybe", err: "CMDAPP_TEST_DEBUG"},
+	}
+	for _, test := range tests {
+		for name, val := range map[string]string{
+			"CMDAPP_TEST_COUNT": test.count,
+			"CMDAPP_TEST_WAIT":  test.wait,
+			"CMDAPP_TEST_DEBUG": test.debug,
+		} {
+			if val == "" {
+				t.Setenv(name, "")
+				os.Unsetenv(name)
+				continue
+			}
+			t.Setenv(name, val)
+		}
+		fs, n, d, b := envFlagSet()
+		err := bindEnv(fs, "env-test")
+		if test.err != "" {
+			if err == nil || !strings.Contains(err.Error(), test.err) {
+				t.Errorf("bindEnv %v: error %v, want %q", test, err, test.err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("bindEnv %v: unexpected error: %v", test, err)
+			continue
+		}
+		if *n != test.n || *d != test.d || *b != test.b {
+			t.Errorf("bindEnv %v: got %d %v %v", test, *n, *d, *b)
+		}
+	}
+}
+
+// FuzzBindEnv checks that an environment variable
+// sets its flag as the command line.
+func FuzzBindEnv(f *testing.F) {
+	f.Add("3")
+	f.Add("-1")
+	f.Add("0x10")
+	f.Add("1e3")
+	f.Fuzz(func(t *testing.T, val string) {
+		if strings.ContainsRune(val, 0) {
+			return
+		}
+		t.Setenv("CMDAPP_TEST_COUNT", val)
+		fs, n, _, _ := envFlagSet()
+		err := bindEnv(fs, "env-test")
+
+		cl, want, _, _ := envFlagSet()
+		clErr := cl.Parse([]string{"-count=" + val})
+		if (err == nil) != (clErr == nil) {
+			t.Fatalf("value %q: environment error %v, command line error %v", val, err, clErr)
+		}
+		if err == nil && *n != *want {
+			t.Fatalf("value %q: environment %d, command line %d", val, *n, *want)
+		}
+	})
+}
+
+func TestExportVars(t *testing.T) {
+	defer func(f string, tee bool) { outputFile, teeOutput = f, tee }(outputFile, teeOutput)
+	defer func(ex func() (map[string]string, error)) { Exports = ex }(Exports)
+	teeOutput = false
+
+	tests := []struct {
+		shell string
+		vars  map[string]string
+		want  string
+		err   string
+		usage bool
+	}{
+		{shell: "sh", vars: map[string]string{"B": "it's", "A_1": "x y"}, want: "export A_1='x y'\nexport B='it'\\''s'\n"},
+		{shell: "fish", vars: map[string]string{"A": `a\b'c`}, want: `set -gx A 'a\\b\'c';` + "\n"},
+		{shell: "powershell", vars: map[string]string{"A": "it's"}, want: "$Env:A = 'it''s'\n"},
+		{shell: "powershell", vars: map[string]string{"A": "it\u2019s; rm x"}, want: "$Env:A = 'it\u2019\u2019s; rm x'\n"},
+		{shell: "powershell", vars: map[string]string{"A": "\u2018a\u201ab\u201b"}, want: "$Env:A = '\u2018\u2018a\u201a\u201ab\u201b\u201b'\n"},
+		{shell: "powershell", vars: map[string]string{"A": "\u201ca\u201d"}, want: "$Env:A = '\u201ca\u201d'\n"},
+		{shell: "cmd", vars: map[string]string{"A": "x y"}, want: "set A=x y\n"},
+		{shell: "cmd", vars: map[string]string{"A": `a"&b|c%PATH%!^(d)<e>`}, want: `set A=a^"^&b^|c^%PATH^%^!^^^(d^)^<e^>` + "\n"},
+		{shell: "cmd", vars: map[string]string{"A": "a\nb"}, err: "new line"},
+		{shell: "sh", vars: map[string]string{"A": "a", "B;rm": "b"}, err: "invalid variable name"},
+		{shell: "cmd", vars: map[string]string{"1A": "a"}, err: "invalid variable name"},
+		{shell: "sh", vars: map[string]string{"": "a"}, err: "invalid variable name"},
+		{shell: "csh", vars: map[string]string{"A": "a"}, err: "unknown shell", usage: true},
+	}
+	for _, test := range tests {
+		outputFile = filepath.Join(t.TempDir(), "out.txt")
+		done, err := openOutputFile()
+		if err != nil {
+			t.Fatal(err)
+		}
+		Exports = func() (map[string]string, error) { return test.vars, nil }
+		err = (&envCmd{export: true, shell: test.shell}).exportVars()
+		code := 0
+		if err != nil {
+			code = 1
+		}
+		done(code)
+		if test.err != "" {
+			if err == nil || !strings.Contains(err.Error(), test.err) {
+				t.Errorf("%s %v: error %v, want %q", test.shell, test.vars, err, test.err)
+			}
+			if _, ok := err.(*usageError); ok != test.usage {
+				t.Errorf("%s %v: usage error %v, want %v", test.shell, test.vars, ok, test.usage)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s %v: unexpected error: %v", test.shell, test.vars, err)
+			continue
+		}
+		b, err := os.ReadFile(outputFile)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(b) != test.want {
+			t.Errorf("%s %v: got %q, want %q", test.shell, test.vars, b, test.want)
+		}
+	}
+}
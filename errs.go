@@ -0,0 +1,92 @@
+// Copyright (c) 2015, J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD-style license that can be found in the LICENSE file.
+
+package cmdapp
+
+import (
+	"fmt"
+	"strings"
+)
+
+// An ExitCoder is an error
+// that sets the exit code of the application.
+type ExitCoder interface {
+	error
+	ExitCode() int
+}
+
+// Errors is a list of errors,
+// used by commands that process many items
+// to return all the failures.
+// The errors are reported as a numbered list,
+// and the exit code is the largest exit code of the errors.
+type Errors []error
+
+// Add adds an error to the list,
+// if it is not nil.
+func (e *Errors) Add(err error) {
+	if err != nil {
+		*e = append(*e, err)
+	}
+}
+
+// Err returns the list as an error,
+// or nil if the list is empty.
+func (e Errors) Err() error {
+	if len(e) == 0 {
+		return nil
+	}
+	return e
+}
+
+func (e Errors) Error() string {
+	if len(e) == 1 {
+		return e[0].Error()
+	}
+	msg := make([]string, 0, len(e))
+	for _, err := range e {
+		msg = append(msg, err.Error())
+	}
+	return fmt.Sprintf("%d errors: %s", len(e), strings.Join(msg, "; "))
+}
+
+// Unwrap returns the errors of the list.
+func (e Errors) Unwrap() []error {
+	return e
+}
+
+// ExitCode returns the largest exit code
+// of the errors in the list.
+func (e Errors) ExitCode() int {
+	code := 0
+	for _, err := range e {
+		if c := exitCode(err); c > code {
+			code = c
+		}
+	}
+	if code == 0 {
+		code = 1
+	}
+	return code
+}
+
+// exitCode returns the exit code of an error.
+// By default it is 1.
+func exitCode(err error) int {
+	for err != nil {
+		switch e := err.(type) {
+		case ExitCoder:
+			return e.ExitCode()
+		case interface{ Unwrap() []error }:
+			return Errors(e.Unwrap()).ExitCode()
+		case interface{ Cause() error }:
+			err = e.Cause()
+		case interface{ Unwrap() error }:
+			err = e.Unwrap()
+		default:
+			return 1
+		}
+	}
+	return 1
+}
@@ -0,0 +1,165 @@
+// Copyright (c) 2015, J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD-style license that can be found in the LICENSE file.
+
+package cmdapp
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+	"runtime"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Exec runs an external program,
+// and waits for it to finish.
+// The program is killed if the context is done.
+//
+// The standard output of the program is written to Output,
+// and its error output to Stderr.
+// Interrupt and termination signals
+// received while the program is running
+// are forwarded to the program,
+// so it can finish cleanly.
+//
+// The invocation is logged at the info level.
+// If DryRun is set,
+// the command line is written to Output
+// instead of running the program.
+// If AuditFile is set,
+// the invocation is recorded in the audit file.
+func Exec(ctx context.Context, name string, args ...string) (err error) {
+	line := cmdLine(name, args)
+	logger.Info("exec", "command", line, "dry-run", DryRun)
+	if DryRun {
+		fmt.Fprintln(Output(), line)
+		return audit(ExecRecord{Time: Now(), Args: append([]string{name}, args...), DryRun: true, ExitCode: -1})
+	}
+
+	rec := ExecRecord{Time: Now(), Args: append([]string{name}, args...), ExitCode: -1}
+	defer func() {
+		rec.Duration = Since(rec.Time)
+		if err != nil {
+			rec.Error = err.Error()
+		}
+		if aerr := audit(rec); aerr != nil && err == nil {
+			err = aerr
+		}
+	}()
+
+	cmd := exec.CommandContext(ctx, name, args...)
+	if runtime.GOOS != "windows" {
+		// ask the program to stop,
+		// and kill it after the grace period
+		cmd.Cancel = func() error { return cmd.Process.Signal(syscall.SIGTERM) }
+		cmd.WaitDelay = GracePeriod
+	}
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = Output()
+	cmd.Stderr = stderr{}
+	if err := cmd.Start(); err != nil {
+		return errors.Wrapf(err, "exec %s", name)
+	}
+
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, os.Interrupt, syscall.SIGTERM)
+	done := make(chan struct{})
+	go func() {
+		for {
+			select {
+			case s := <-sig:
+				logger.Debug("exec: forward signal", "command", name, "signal", s.String())
+				cmd.Process.Signal(s)
+			case <-done:
+				return
+			}
+		}
+	}()
+	err = cmd.Wait()
+	signal.Stop(sig)
+	close(done)
+	Flush()
+	if cmd.ProcessState != nil {
+		rec.ExitCode = cmd.ProcessState.ExitCode()
+	}
+
+	if err != nil {
+		logger.Info("exec: done", "command", line, "error", err.Error())
+		return errors.Wrapf(err, "exec %s", name)
+	}
+	return nil
+}
+
+// cmdLine returns the command line of a program,
+// quoted for the shell of the user.
+func cmdLine(name string, args []string) string {
+	return QuoteArgs(UserShell(), append([]string{name}, args...))
+}
+
+// DryRun is set if external programs run with Exec
+// should be shown instead of run.
+// It can be set with the -dry-run flag.
+var DryRun bool
+
+// AuditFile is the file in which the programs run with Exec
+// are recorded,
+// as JSON lines.
+// It can be set with the -audit-file flag.
+var AuditFile string
+
+// registerExecFlags sets the flags of external programs.
+func registerExecFlags(fs *flag.FlagSet) {
+	fs.BoolVar(&DryRun, "dry-run", false, "show external programs instead of running them")
+	fs.StringVar(&AuditFile, "audit-file", "", "record external programs, in JSON lines, in the given file")
+}
+
+// An ExecRecord is the record of an external program
+// run with Exec.
+type ExecRecord struct {
+	Time time.Time `json:"time"`
+
+	// Args is the program name and its arguments.
+	Args []string `json:"args"`
+
+	// ExitCode is -1 if the program was not run,
+	// or it was killed by a signal.
+	ExitCode int `json:"exit_code"`
+
+	DryRun   bool          `json:"dry_run,omitempty"`
+	Duration time.Duration `json:"duration,omitempty"`
+	Error    string        `json:"error,omitempty"`
+}
+
+// auditMutex serializes the writes to the audit file.
+var auditMutex sync.Mutex
+
+// audit adds a record to the audit file.
+func audit(rec ExecRecord) error {
+	if AuditFile == "" {
+		return nil
+	}
+	b, err := json.Marshal(rec)
+	if err != nil {
+		return errors.Wrap(err, "audit")
+	}
+	auditMutex.Lock()
+	defer auditMutex.Unlock()
+	f, err := os.OpenFile(AuditFile, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return errors.Wrap(err, "audit")
+	}
+	if _, err := f.Write(append(b, '\n')); err != nil {
+		f.Close()
+		return errors.Wrap(err, "audit")
+	}
+	return errors.Wrap(f.Close(), "audit")
+}
@@ -0,0 +1,121 @@
+// Copyright (c) 2015, J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD-style license that can be found in the LICENSE file.
+
+package cmdapp
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// An ExitStatus is an exit code of a command
+// and its meaning.
+type ExitStatus struct {
+	// Code is the exit code.
+	Code int `json:"code"`
+
+	// Desc is the meaning of the exit code.
+	// If empty,
+	// the meaning registered with AddExitCode is used.
+	Desc string `json:"desc"`
+}
+
+// An ExitStatusCommand is a command
+// that declares its exit codes,
+// shown in 'help <this-command>' output,
+// and the manual page.
+type ExitStatusCommand interface {
+	Command
+
+	// ExitStatus returns the exit codes of the command.
+	// The codes of the framework
+	// (0, 1, ExitUsage, and ExitTimeout)
+	// are added automatically.
+	ExitStatus() []ExitStatus
+}
+
+// exitCodes are the exit codes
+// registered by the application.
+var (
+	codeMutex sync.Mutex
+	exitCodes = make(map[int]string)
+)
+
+// frameworkCodes are the exit codes
+// used by the framework.
+var frameworkCodes = map[int]string{
+	0:           "success",
+	1:           "error",
+	ExitUsage:   "invalid arguments",
+	ExitTimeout: "time limit reached",
+}
+
+// AddExitCode registers the meaning of an exit code
+// used by the commands of the application.
+// Codes should be between 3 and 125,
+// and not used by the framework,
+// otherwise it will trigger a panic.
+func AddExitCode(code int, desc string) {
+	codeMutex.Lock()
+	defer codeMutex.Unlock()
+	if err := checkExitCode(code); err != "" {
+		panic(fmt.Sprintf("cmdapp: exit code %d: %s", code, err))
+	}
+	if d, dup := exitCodes[code]; dup && d != desc {
+		panic(fmt.Sprintf("cmdapp: Repeated exit code: %d", code))
+	}
+	exitCodes[code] = desc
+}
+
+// checkExitCode returns the reason
+// for which an exit code can not be declared,
+// or an empty string if it is valid.
+func checkExitCode(code int) string {
+	switch {
+	case code < 0 || code > 255:
+		return "out of range"
+	case frameworkCodes[code] != "":
+		return "used by the framework as " + frameworkCodes[code]
+	case code > 125:
+		return "reserved by the shell"
+	}
+	return ""
+}
+
+// exitStatus returns the exit codes of a command,
+// sorted by code.
+// It panics if the command declares an exit code
+// that is invalid,
+// or without a meaning.
+func exitStatus(c Command) []ExitStatus {
+	st := []ExitStatus{
+		{Code: 0, Desc: frameworkCodes[0]},
+		{Code: 1, Desc: frameworkCodes[1]},
+		{Code: ExitUsage, Desc: frameworkCodes[ExitUsage]},
+	}
+	if _, ok := c.(TimeoutCommand); ok {
+		st = append(st, ExitStatus{Code: ExitTimeout, Desc: frameworkCodes[ExitTimeout]})
+	}
+	if e, ok := c.(ExitStatusCommand); ok {
+		codeMutex.Lock()
+		for _, s := range e.ExitStatus() {
+			if err := checkExitCode(s.Code); err != "" {
+				codeMutex.Unlock()
+				panic(fmt.Sprintf("cmdapp: command %s: exit code %d: %s", c.Name(), s.Code, err))
+			}
+			if s.Desc == "" {
+				s.Desc = exitCodes[s.Code]
+			}
+			if s.Desc == "" {
+				codeMutex.Unlock()
+				panic(fmt.Sprintf("cmdapp: command %s: exit code %d: undefined meaning", c.Name(), s.Code))
+			}
+			st = append(st, s)
+		}
+		codeMutex.Unlock()
+	}
+	sort.SliceStable(st, func(i, j int) bool { return st[i].Code < st[j].Code })
+	return st
+}
@@ -0,0 +1,96 @@
+// Copyright (c) 2015, J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD-style license that can be found in the LICENSE file.
+
+package cmdapp
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// firstRunMarker is the file,
+// in the data directory,
+// that marks that the application was already run.
+const firstRunMarker = "first-run"
+
+// firstRunHook is the function set with OnFirstRun.
+var (
+	firstMutex   sync.Mutex
+	firstRunHook func() error
+	isFirstRun   bool
+)
+
+// OnFirstRun sets a function
+// that is called the first time the application is run
+// by the user,
+// before the command is run,
+// for example to ask for consent,
+// create the initial configuration,
+// or offer the installation of the shell completion.
+//
+// The first run is detected with a marker file
+// in the data directory,
+// that is created after the function returns without error,
+// with the same permissions as the other files
+// of the data directory.
+// If the function returns an error,
+// the application ends with the error,
+// and the function is called again
+// in the next run.
+func OnFirstRun(f func() error) {
+	firstMutex.Lock()
+	defer firstMutex.Unlock()
+	firstRunHook = f
+}
+
+// FirstRun returns true
+// if this is the first time the application is run
+// by the user.
+// The first run is only detected
+// if a function is set with OnFirstRun.
+func FirstRun() bool {
+	firstMutex.Lock()
+	defer firstMutex.Unlock()
+	return isFirstRun
+}
+
+// firstRun calls the first run function,
+// if this is the first run.
+func firstRun() error {
+	firstMutex.Lock()
+	f := firstRunHook
+	firstMutex.Unlock()
+	if f == nil {
+		return nil
+	}
+
+	dir, err := DataDir()
+	if err != nil {
+		// without a data directory
+		// every run would be the first
+		logger.Info("first run: no data directory", "error", err.Error())
+		return nil
+	}
+	marker := filepath.Join(dir, firstRunMarker)
+	if _, err := os.Stat(marker); err == nil {
+		return nil
+	}
+
+	firstMutex.Lock()
+	isFirstRun = true
+	firstMutex.Unlock()
+
+	logger.Info("first run")
+	if err := f(); err != nil {
+		return errors.Wrap(err, "first run")
+	}
+	if err := WriteSecret(marker, []byte(Now().Format(time.RFC3339)+"\n")); err != nil {
+		return errors.Wrap(err, "first run")
+	}
+	return nil
+}
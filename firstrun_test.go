@@ -0,0 +1,71 @@
+// Copyright (c) 2015, J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD-style license that can be found in the LICENSE file.
+
+package cmdapp
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/pkg/errors"
+)
+
+func TestFirstRun(t *testing.T) {
+	defer func() {
+		firstMutex.Lock()
+		firstRunHook, isFirstRun = nil, false
+		firstMutex.Unlock()
+	}()
+
+	tests := []struct {
+		marker bool
+		err    error
+		called bool
+		first  bool
+		done   bool
+	}{
+		{called: true, first: true, done: true},
+		{marker: true, done: true},
+		{err: errors.New("no consent"), called: true, first: true},
+	}
+	for _, test := range tests {
+		t.Setenv("XDG_DATA_HOME", t.TempDir())
+		dir, err := DataDir()
+		if err != nil {
+			t.Fatal(err)
+		}
+		marker := filepath.Join(dir, firstRunMarker)
+		if test.marker {
+			if err := WriteSecret(marker, []byte("x\n")); err != nil {
+				t.Fatal(err)
+			}
+		}
+		called := false
+		OnFirstRun(func() error {
+			called = true
+			return test.err
+		})
+		firstMutex.Lock()
+		isFirstRun = false
+		firstMutex.Unlock()
+
+		err = firstRun()
+		if (err != nil) != (test.err != nil) {
+			t.Errorf("marker %v, hook error %v: got error %v", test.marker, test.err, err)
+		}
+		if called != test.called || FirstRun() != test.first {
+			t.Errorf("marker %v, hook error %v: called %v, first run %v", test.marker, test.err, called, FirstRun())
+		}
+		fi, err := os.Stat(marker)
+		if (err == nil) != test.done {
+			t.Errorf("marker %v, hook error %v: marker file %v", test.marker, test.err, err)
+			continue
+		}
+		if err == nil && runtime.GOOS != "windows" && fi.Mode().Perm() != SecretMode {
+			t.Errorf("marker %v, hook error %v: marker mode %v, want %v", test.marker, test.err, fi.Mode().Perm(), SecretMode)
+		}
+	}
+}
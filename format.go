@@ -0,0 +1,102 @@
+// Copyright (c) 2015, J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD-style license that can be found in the LICENSE file.
+
+package cmdapp
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Plural returns a count
+// followed by the singular or plural form of a noun,
+// for example "1 file" or "3 files".
+// If plural is empty,
+// the plural is formed adding an "s" to the singular.
+func Plural(n int, singular, plural string) string {
+	if n == 1 || n == -1 {
+		return fmt.Sprintf("%d %s", n, singular)
+	}
+	if plural == "" {
+		plural = singular + "s"
+	}
+	return fmt.Sprintf("%d %s", n, plural)
+}
+
+// byteUnits are the binary prefixes of the byte units.
+var byteUnits = []string{"KiB", "MiB", "GiB", "TiB", "PiB", "EiB"}
+
+// Bytes returns a size in bytes
+// in human readable form,
+// for example "512 B" or "1.5 MiB".
+// In porcelain mode,
+// it returns the number of bytes.
+func Bytes(n int64) string {
+	if Porcelain() {
+		return strconv.FormatInt(n, 10)
+	}
+	if n < 1024 && n > -1024 {
+		return fmt.Sprintf("%d B", n)
+	}
+	v := float64(n)
+	u := -1
+	for (v >= 1024 || v <= -1024) && u < len(byteUnits)-1 {
+		v /= 1024
+		u++
+	}
+	return decimal(v) + " " + byteUnits[u]
+}
+
+// Duration returns a duration in human readable form,
+// for example "250ms", "3.2s", "2m5s", or "1d3h".
+// Only the two most significant units are shown.
+// In porcelain mode,
+// it returns the exact duration,
+// as formatted by time.Duration.
+func Duration(d time.Duration) string {
+	if Porcelain() {
+		return d.String()
+	}
+	if d < 0 {
+		return "-" + Duration(-d)
+	}
+	switch {
+	case d < time.Second:
+		return d.Round(time.Millisecond).String()
+	case d < time.Minute:
+		return decimal(d.Seconds()) + "s"
+	case d < time.Hour:
+		d = d.Round(time.Second)
+		return fmt.Sprintf("%dm%ds", d/time.Minute, (d%time.Minute)/time.Second)
+	case d < 24*time.Hour:
+		d = d.Round(time.Minute)
+		return fmt.Sprintf("%dh%dm", d/time.Hour, (d%time.Hour)/time.Minute)
+	}
+	d = d.Round(time.Hour)
+	day := 24 * time.Hour
+	return fmt.Sprintf("%dd%dh", d/day, (d%day)/time.Hour)
+}
+
+// commaLangs are the languages
+// that use a comma as decimal separator.
+var commaLangs = map[string]bool{
+	"de": true, "es": true, "fr": true, "it": true,
+	"nl": true, "pt": true, "ru": true, "pl": true,
+	"sv": true, "da": true, "nb": true, "fi": true,
+	"cs": true, "tr": true, "id": true, "uk": true,
+}
+
+// decimal formats a number with a decimal,
+// using the decimal separator of the user locale.
+func decimal(v float64) string {
+	s := fmt.Sprintf("%.1f", v)
+	s = strings.TrimSuffix(s, ".0")
+	locs := userLocales()
+	if len(locs) > 0 && commaLangs[locs[len(locs)-1]] {
+		s = strings.Replace(s, ".", ",", 1)
+	}
+	return s
+}
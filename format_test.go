@@ -0,0 +1,97 @@
+// Copyright (c) 2015, J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD-style license that can be found in the LICENSE file.
+
+package cmdapp
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPlural(t *testing.T) {
+	tests := []struct {
+		n        int
+		singular string
+		plural   string
+		want     string
+	}{
+		{0, "file", "", "0 files"},
+		{1, "file", "", "1 file"},
+		{-1, "file", "", "-1 file"},
+		{2, "file", "", "2 files"},
+		{3, "directory", "directories", "3 directories"},
+		{1, "directory", "directories", "1 directory"},
+	}
+	for _, test := range tests {
+		if got := Plural(test.n, test.singular, test.plural); got != test.want {
+			t.Errorf("Plural(%d, %q, %q) = %q, want %q", test.n, test.singular, test.plural, got, test.want)
+		}
+	}
+}
+
+func TestBytes(t *testing.T) {
+	defer func(l string, p bool) { Locale, porcelain = l, p }(Locale, porcelain)
+
+	tests := []struct {
+		n         int64
+		locale    string
+		porcelain bool
+		want      string
+	}{
+		{n: 0, want: "0 B"},
+		{n: 512, want: "512 B"},
+		{n: 1023, want: "1023 B"},
+		{n: 1024, want: "1 KiB"},
+		{n: 1536, want: "1.5 KiB"},
+		{n: 1536, locale: "es_AR", want: "1,5 KiB"},
+		{n: 1536, locale: "en_US", want: "1.5 KiB"},
+		{n: -1536, want: "-1.5 KiB"},
+		{n: 5 << 20, want: "5 MiB"},
+		{n: 3 << 30, want: "3 GiB"},
+		{n: 1 << 62, want: "4 EiB"},
+		{n: 1536, porcelain: true, want: "1536"},
+	}
+	for _, test := range tests {
+		Locale, porcelain = test.locale, test.porcelain
+		if Locale == "" {
+			Locale = "C"
+		}
+		if got := Bytes(test.n); got != test.want {
+			t.Errorf("Bytes(%d) [%s]: got %q, want %q", test.n, test.locale, got, test.want)
+		}
+	}
+}
+
+func TestDuration(t *testing.T) {
+	defer func(l string, p bool) { Locale, porcelain = l, p }(Locale, porcelain)
+
+	tests := []struct {
+		d         time.Duration
+		locale    string
+		porcelain bool
+		want      string
+	}{
+		{d: 0, want: "0s"},
+		{d: 250*time.Millisecond + 400*time.Microsecond, want: "250ms"},
+		{d: 3200 * time.Millisecond, want: "3.2s"},
+		{d: 3200 * time.Millisecond, locale: "de", want: "3,2s"},
+		{d: 3 * time.Second, want: "3s"},
+		{d: 2*time.Minute + 5*time.Second, want: "2m5s"},
+		{d: 2*time.Minute + 5400*time.Millisecond, want: "2m5s"},
+		{d: 3*time.Hour + 20*time.Minute + 40*time.Second, want: "3h21m"},
+		{d: 27*time.Hour + 10*time.Minute, want: "1d3h"},
+		{d: -3 * time.Second, want: "-3s"},
+		{d: 2*time.Minute + 5*time.Second, porcelain: true, want: "2m5s"},
+		{d: 3*time.Hour + 20*time.Minute + 40*time.Second, porcelain: true, want: "3h20m40s"},
+	}
+	for _, test := range tests {
+		Locale, porcelain = test.locale, test.porcelain
+		if Locale == "" {
+			Locale = "C"
+		}
+		if got := Duration(test.d); got != test.want {
+			t.Errorf("Duration(%v) [%s]: got %q, want %q", test.d, test.locale, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,219 @@
+// Copyright (c) 2015, J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD-style license that can be found in the LICENSE file.
+
+package cmdapp
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing/fstest"
+)
+
+// A FileSystem is a file system
+// with write operations,
+// used by commands for file operations,
+// so they can be used in dry-run mode
+// or tested with an in-memory file system.
+//
+// Names are operating system paths,
+// as in the os package.
+type FileSystem interface {
+	fs.FS
+
+	// WriteFile writes data to a file,
+	// creating it if necessary.
+	WriteFile(name string, data []byte, perm fs.FileMode) error
+
+	// MkdirAll creates a directory
+	// and any necessary parents.
+	MkdirAll(name string, perm fs.FileMode) error
+
+	// Remove removes a file or empty directory.
+	Remove(name string) error
+
+	// Rename renames a file.
+	Rename(oldName, newName string) error
+}
+
+// fsys is the file system used by commands.
+var (
+	fsMutex sync.Mutex
+	fsys    FileSystem
+)
+
+// FS returns the file system used by commands.
+// By default it is the operating system file system,
+// or, if DryRun is set,
+// a read-only view of it
+// in which the write operations are shown
+// in the Output instead of performed.
+// If the trash is enabled with EnableTrash,
+// removed or replaced files are kept in the trash.
+func FS() FileSystem {
+	fsMutex.Lock()
+	defer fsMutex.Unlock()
+	if fsys != nil {
+		return fsys
+	}
+	if DryRun {
+		return dryFS{}
+	}
+	if useTrash() {
+		return trashFS{}
+	}
+	return osFS{}
+}
+
+// SetFS sets the file system used by commands,
+// for example an in-memory file system for tests.
+// If nil,
+// the default file system is used.
+func SetFS(f FileSystem) {
+	fsMutex.Lock()
+	fsys = f
+	fsMutex.Unlock()
+}
+
+// osFS is the operating system file system.
+type osFS struct{}
+
+func (osFS) Open(name string) (fs.File, error) { return os.Open(name) }
+func (osFS) Remove(name string) error          { return os.Remove(name) }
+func (osFS) Rename(oldName, newName string) error {
+	return os.Rename(oldName, newName)
+}
+
+func (osFS) WriteFile(name string, data []byte, perm fs.FileMode) error {
+	return os.WriteFile(name, data, perm)
+}
+
+func (osFS) MkdirAll(name string, perm fs.FileMode) error {
+	return os.MkdirAll(name, perm)
+}
+
+// dryFS is a read-only operating system file system
+// that shows the write operations.
+type dryFS struct{}
+
+func (dryFS) Open(name string) (fs.File, error) { return os.Open(name) }
+
+func (dryFS) WriteFile(name string, data []byte, perm fs.FileMode) error {
+	fmt.Fprintf(Output(), "write %s (%s)\n", name, Bytes(int64(len(data))))
+	return nil
+}
+
+func (dryFS) MkdirAll(name string, perm fs.FileMode) error {
+	fmt.Fprintf(Output(), "mkdir %s\n", name)
+	return nil
+}
+
+func (dryFS) Remove(name string) error {
+	fmt.Fprintf(Output(), "remove %s\n", name)
+	return nil
+}
+
+func (dryFS) Rename(oldName, newName string) error {
+	fmt.Fprintf(Output(), "rename %s %s\n", oldName, newName)
+	return nil
+}
+
+// memFS is an in-memory file system.
+type memFS struct {
+	mu    sync.Mutex
+	files fstest.MapFS
+}
+
+// NewMemFS returns an empty in-memory file system.
+func NewMemFS() FileSystem {
+	return &memFS{files: make(fstest.MapFS)}
+}
+
+// memName returns the name of a file
+// in the in-memory file system.
+func memName(name string) string {
+	name = path.Clean(filepath.ToSlash(name))
+	name = strings.TrimPrefix(name, "/")
+	if name == "" {
+		return "."
+	}
+	return name
+}
+
+func (m *memFS) Open(name string) (fs.File, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.files.Open(memName(name))
+}
+
+func (m *memFS) WriteFile(name string, data []byte, perm fs.FileMode) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	nm := memName(name)
+	if f, ok := m.files[nm]; ok && f.Mode.IsDir() {
+		return &fs.PathError{Op: "write", Path: name, Err: fs.ErrInvalid}
+	}
+	m.files[nm] = &fstest.MapFile{
+		Data:    append([]byte(nil), data...),
+		Mode:    perm,
+		ModTime: Now(),
+	}
+	return nil
+}
+
+func (m *memFS) MkdirAll(name string, perm fs.FileMode) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for nm := memName(name); nm != "."; nm = path.Dir(nm) {
+		if f, ok := m.files[nm]; ok {
+			if !f.Mode.IsDir() {
+				return &fs.PathError{Op: "mkdir", Path: name, Err: fs.ErrExist}
+			}
+			continue
+		}
+		m.files[nm] = &fstest.MapFile{Mode: fs.ModeDir | perm, ModTime: Now()}
+	}
+	return nil
+}
+
+func (m *memFS) Remove(name string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	nm := memName(name)
+	if _, ok := m.files[nm]; !ok {
+		return &fs.PathError{Op: "remove", Path: name, Err: fs.ErrNotExist}
+	}
+	for f := range m.files {
+		if strings.HasPrefix(f, nm+"/") {
+			return &fs.PathError{Op: "remove", Path: name, Err: fs.ErrInvalid}
+		}
+	}
+	delete(m.files, nm)
+	return nil
+}
+
+func (m *memFS) Rename(oldName, newName string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	on, nn := memName(oldName), memName(newName)
+	f, ok := m.files[on]
+	if !ok {
+		return &fs.PathError{Op: "rename", Path: oldName, Err: fs.ErrNotExist}
+	}
+	moved := map[string]*fstest.MapFile{nn: f}
+	for nm, f := range m.files {
+		if nm == on || strings.HasPrefix(nm, on+"/") {
+			moved[nn+nm[len(on):]] = f
+			delete(m.files, nm)
+		}
+	}
+	for nm, f := range moved {
+		m.files[nm] = f
+	}
+	return nil
+}
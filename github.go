@@ -0,0 +1,104 @@
+// Copyright (c) 2015, J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD-style license that can be found in the LICENSE file.
+
+package cmdapp
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+)
+
+// GitHubAnnotations is set
+// if the application writes workflow commands
+// when it is run in GitHub Actions,
+// so warnings and errors annotate the workflow run
+// (and the pull request),
+// and the progress of each task
+// is shown as a collapsible group of the log.
+// It is set by default,
+// the application can clear it
+// to use the plain output.
+var GitHubAnnotations = true
+
+// gitHubActions reports whether
+// the workflow commands of GitHub Actions
+// should be written.
+func gitHubActions() bool {
+	return GitHubAnnotations && os.Getenv("GITHUB_ACTIONS") == "true"
+}
+
+// workflowCommand returns a workflow command
+// of GitHub Actions,
+// with an optional title.
+func workflowCommand(cmd, title, msg string) string {
+	if title == "" {
+		return fmt.Sprintf("::%s::%s", cmd, escapeWorkflowData(msg))
+	}
+	return fmt.Sprintf("::%s title=%s::%s", cmd, escapeWorkflowProperty(title), escapeWorkflowData(msg))
+}
+
+// workflowData escapes the message of a workflow command.
+var workflowData = strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A")
+
+// workflowProperty escapes a property of a workflow command.
+var workflowProperty = strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A", ":", "%3A", ",", "%2C")
+
+func escapeWorkflowData(s string) string     { return workflowData.Replace(s) }
+func escapeWorkflowProperty(s string) string { return workflowProperty.Replace(s) }
+
+// githubGroup is set
+// if there is an open group in the log.
+// GitHub Actions does not support nested groups.
+var (
+	githubMutex sync.Mutex
+	githubGroup bool
+)
+
+// githubProgress writes the progress of a task
+// as log lines
+// inside a group of the log.
+type githubProgress struct {
+	logProgress
+	group bool
+}
+
+func (p *githubProgress) Start(title string) {
+	githubMutex.Lock()
+	if !githubGroup {
+		githubGroup = true
+		p.group = true
+		fmt.Fprintf(Stderr, "%s\n", workflowCommand("group", "", title))
+	}
+	githubMutex.Unlock()
+	p.logProgress.Start(title)
+}
+
+func (p *githubProgress) Done() {
+	p.logProgress.Done()
+	if !p.group {
+		return
+	}
+	githubMutex.Lock()
+	githubGroup = false
+	p.group = false
+	githubMutex.Unlock()
+	fmt.Fprintf(Stderr, "::endgroup::\n")
+}
+
+// githubErrHandler reports the error of a command
+// as error annotations,
+// one for each error of a list of errors.
+func githubErrHandler(c Command, err error) int {
+	title := Name + " " + c.Name()
+	list := []error{err}
+	if l, ok := err.(interface{ Unwrap() []error }); ok && len(l.Unwrap()) > 1 {
+		list = l.Unwrap()
+	}
+	for _, e := range list {
+		fmt.Fprintf(Stderr, "%s\n", workflowCommand("error", title, e.Error()))
+	}
+	return exitCode(err)
+}
@@ -0,0 +1,91 @@
+// Copyright (c) 2015, J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD-style license that can be found in the LICENSE file.
+
+package cmdapp
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"sync"
+)
+
+// globalFlags are the functions
+// that register the global flags
+// of the application.
+// globalSet is the flag set
+// with only the global flags,
+// used for the documentation.
+var (
+	globalMutex sync.Mutex
+	globalFlags []func(*flag.FlagSet)
+	globalSet   *flag.FlagSet
+)
+
+// AddGlobalFlags adds flags of the application,
+// for example -verbose or -config,
+// that are given before the command name,
+// and are available to all the commands.
+// The function registers the flags in a flag set,
+// as the Register method of a command,
+// and the flags are set
+// before the command is run.
+//
+// The global flags are shown
+// in the usage of the application.
+// Flag names should not be used
+// by the framework flags,
+// otherwise it will trigger a panic.
+func AddGlobalFlags(register func(fs *flag.FlagSet)) {
+	globalMutex.Lock()
+	defer globalMutex.Unlock()
+	globalFlags = append(globalFlags, register)
+}
+
+// registerGlobalFlags sets the global flags
+// added with AddGlobalFlags.
+func registerGlobalFlags(fs *flag.FlagSet) {
+	globalMutex.Lock()
+	defer globalMutex.Unlock()
+	if len(globalFlags) == 0 {
+		return
+	}
+	set := flag.NewFlagSet(fs.Name(), flag.ContinueOnError)
+	for _, r := range globalFlags {
+		r(set)
+	}
+	set.VisitAll(func(f *flag.Flag) {
+		fs.Var(f.Value, f.Name, f.Usage)
+	})
+	globalSet = set
+}
+
+// hasGlobalFlags reports whether the application
+// has global flags.
+func hasGlobalFlags() bool {
+	globalMutex.Lock()
+	defer globalMutex.Unlock()
+	return globalSet != nil
+}
+
+// printGlobalFlags prints the global flags
+// of the application.
+func printGlobalFlags(w io.Writer) {
+	globalMutex.Lock()
+	set := globalSet
+	globalMutex.Unlock()
+	if set == nil {
+		return
+	}
+	fmt.Fprintf(w, "The global flags are:\n")
+	set.VisitAll(func(f *flag.Flag) {
+		name, usage := flag.UnquoteUsage(f)
+		fl := "-" + f.Name
+		if name != "" {
+			fl += " <" + name + ">"
+		}
+		fmt.Fprintf(w, "    %-16s %s\n", fl, usage)
+	})
+	fmt.Fprintf(w, "\n")
+}
@@ -0,0 +1,123 @@
+// Copyright (c) 2015, J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD-style license that can be found in the LICENSE file.
+
+package cmdapp
+
+import (
+	"os"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// A GlyphStyle is the style of the markers
+// used in status messages.
+type GlyphStyle int
+
+// Valid glyph styles.
+const (
+	// GlyphAuto uses emoji in terminals with UTF-8 support,
+	// and no markers otherwise.
+	GlyphAuto GlyphStyle = iota
+
+	// GlyphEmoji uses emoji markers.
+	GlyphEmoji
+
+	// GlyphASCII uses ASCII markers.
+	GlyphASCII
+
+	// GlyphNone does not use markers.
+	GlyphNone
+)
+
+var glyphNames = []string{"auto", "emoji", "ascii", "none"}
+
+func (g GlyphStyle) String() string {
+	if g < 0 || int(g) >= len(glyphNames) {
+		return "auto"
+	}
+	return glyphNames[g]
+}
+
+// Set sets the glyph style from its name,
+// so it can be used as a flag value.
+func (g *GlyphStyle) Set(s string) error {
+	for i, nm := range glyphNames {
+		if strings.ToLower(s) == nm {
+			*g = GlyphStyle(i)
+			return nil
+		}
+	}
+	return errors.Errorf("unknown glyph style %q", s)
+}
+
+// Glyphs is the style of the markers
+// used in status messages.
+// It can be set with the -glyphs flag.
+var Glyphs GlyphStyle
+
+// A Status is the kind of a status message.
+type Status int
+
+// Valid status.
+const (
+	Success Status = iota
+	Failure
+	Warning
+	Notice
+)
+
+var (
+	emojiMarks = []string{"✅", "❌", "⚠️", "ℹ️"}
+	asciiMarks = []string{"[ok]", "[fail]", "[warn]", "[info]"}
+)
+
+// Symbol returns the marker of a status,
+// using the current glyph style.
+// It returns an empty string
+// if no markers are used.
+func Symbol(s Status) string {
+	if s < 0 || int(s) >= len(emojiMarks) {
+		return ""
+	}
+	switch glyphStyle() {
+	case GlyphEmoji:
+		return emojiMarks[s]
+	case GlyphASCII:
+		return asciiMarks[s]
+	}
+	return ""
+}
+
+// Mark returns a message
+// prefixed with the marker of a status.
+func Mark(s Status, msg string) string {
+	if m := Symbol(s); m != "" {
+		return m + " " + msg
+	}
+	return msg
+}
+
+// glyphStyle returns the glyph style in use.
+func glyphStyle() GlyphStyle {
+	if Glyphs != GlyphAuto {
+		return Glyphs
+	}
+	if Porcelain() || Accessible() || !Interactive() || !utf8Locale() {
+		return GlyphNone
+	}
+	return GlyphEmoji
+}
+
+// utf8Locale reports whether the user locale
+// uses UTF-8 encoding.
+func utf8Locale() bool {
+	for _, v := range []string{"LC_ALL", "LC_CTYPE", "LANG"} {
+		if l := os.Getenv(v); l != "" {
+			l = strings.ToLower(l)
+			return strings.Contains(l, "utf-8") || strings.Contains(l, "utf8")
+		}
+	}
+	return false
+}
@@ -0,0 +1,188 @@
+// Copyright (c) 2015, J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD-style license that can be found in the LICENSE file.
+
+package cmdapp
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// GracePeriod is the time given to a command
+// to return after its context is cancelled,
+// by a signal or by its time limit,
+// before the application is terminated.
+// It can be set with the -grace-period flag.
+var GracePeriod = 5 * time.Second
+
+// registerGraceFlags sets the grace period flag
+// of the application.
+func registerGraceFlags(fs *flag.FlagSet) {
+	fs.DurationVar(&GracePeriod, "grace-period", GracePeriod, "time given to a cancelled command to return")
+}
+
+// signalContext returns a context,
+// derived from parent,
+// that is cancelled when the application
+// receives an interrupt or terminate signal.
+// The cause of the cancellation
+// is a *signalError.
+// The returned function stops the handling of the signals.
+func signalContext(parent context.Context) (context.Context, func()) {
+	ctx, cancel := context.WithCancelCause(parent)
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, os.Interrupt, syscall.SIGTERM)
+
+	done := make(chan struct{})
+	go func() {
+		select {
+		case s := <-sig:
+			logger.Info("signal", "signal", s.String())
+			cancel(&signalError{sig: s})
+		case <-done:
+		}
+	}()
+	return ctx, func() {
+		signal.Stop(sig)
+		close(done)
+	}
+}
+
+// forced are the functions that release
+// the resources of the running commands,
+// called if the application is terminated
+// before the commands return.
+var (
+	forcedMutex sync.Mutex
+	forced      []*func()
+)
+
+// onForcedExit adds a function called
+// if the application is terminated
+// before the command returns,
+// and returns the function that removes it.
+// The function can be called twice,
+// by the command and by the forced exit,
+// so it should be idempotent.
+func onForcedExit(f func()) (remove func()) {
+	p := &f
+	forcedMutex.Lock()
+	forced = append(forced, p)
+	forcedMutex.Unlock()
+	return func() {
+		forcedMutex.Lock()
+		defer forcedMutex.Unlock()
+		for i, q := range forced {
+			if q == p {
+				forced = append(forced[:i], forced[i+1:]...)
+				return
+			}
+		}
+	}
+}
+
+// runForced calls the functions added with onForcedExit,
+// in reverse order.
+func runForced() {
+	forcedMutex.Lock()
+	fs := forced
+	forced = nil
+	forcedMutex.Unlock()
+	for i := len(fs) - 1; i >= 0; i-- {
+		(*fs[i])()
+	}
+}
+
+// watchGrace waits until a context is cancelled,
+// and then terminates the application
+// if the command does not return
+// within the grace period.
+// A second signal terminates the application
+// immediately.
+// Before the application is terminated,
+// the running commands are closed
+// (see CloserCommand)
+// and the application lock is released.
+// The returned function must be called
+// when the command returns.
+func watchGrace(ctx context.Context) func() {
+	// the values are read when the command starts,
+	// as the application restores them
+	// when the command returns
+	w, name, grace := Stderr, Name, GracePeriod
+	done := make(chan struct{})
+	go func() {
+		select {
+		case <-ctx.Done():
+		case <-done:
+			return
+		}
+		select {
+		case <-done:
+			// the command returned
+			// while the context was cancelled
+			return
+		default:
+		}
+		fmt.Fprintf(w, "%s: terminating...\n", name)
+
+		sig := make(chan os.Signal, 1)
+		signal.Notify(sig, os.Interrupt, syscall.SIGTERM)
+		defer signal.Stop(sig)
+		t := time.NewTimer(grace)
+		defer t.Stop()
+
+		code := ExitTimeout
+		select {
+		case <-done:
+			return
+		case s := <-sig:
+			code = signalCode(s)
+			fmt.Fprintf(w, "%s: killed\n", name)
+		case <-t.C:
+			if se, ok := context.Cause(ctx).(*signalError); ok {
+				code = se.ExitCode()
+			}
+			fmt.Fprintf(w, "%s: killed after %v\n", name, grace)
+		}
+		logger.Info("killed", "grace-period", grace.String())
+		runForced()
+		Exit(code)
+	}()
+	return func() { close(done) }
+}
+
+// A signalError is the error of a command
+// interrupted by a signal.
+type signalError struct {
+	sig os.Signal
+	err error
+}
+
+func (e *signalError) Error() string {
+	if e.err == nil || errors.Cause(e.err) == context.Canceled {
+		return fmt.Sprintf("received signal: %v", e.sig)
+	}
+	return fmt.Sprintf("received signal: %v: %v", e.sig, e.err)
+}
+
+func (e *signalError) Unwrap() error { return e.err }
+func (e *signalError) ExitCode() int { return signalCode(e.sig) }
+
+// signalCode returns the exit code
+// of a program terminated by a signal.
+func signalCode(s os.Signal) int {
+	if n, ok := s.(syscall.Signal); ok {
+		return 128 + int(n)
+	}
+	return 1
+}
@@ -0,0 +1,119 @@
+// Copyright (c) 2015, J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD-style license that can be found in the LICENSE file.
+
+package cmdapp
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"io"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestForcedExit(t *testing.T) {
+	var got []string
+	onForcedExit(func() { got = append(got, "unlock") })
+	remove := onForcedExit(func() { got = append(got, "removed") })
+	onForcedExit(func() { got = append(got, "close") })
+	remove()
+
+	runForced()
+	want := []string{"close", "unlock"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("forced exit: got %v, want %v", got, want)
+	}
+
+	runForced()
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("forced exit called twice: got %v, want %v", got, want)
+	}
+}
+
+// lockedBuffer is a buffer
+// safe for concurrent use.
+type lockedBuffer struct {
+	mu sync.Mutex
+	b  bytes.Buffer
+}
+
+func (b *lockedBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.b.Write(p)
+}
+
+func (b *lockedBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.b.String()
+}
+
+// timeoutCmd is a command with a time limit.
+type timeoutCmd struct {
+	d time.Duration
+}
+
+func (c *timeoutCmd) Name() string              { return "timeout-test" }
+func (c *timeoutCmd) Args() string              { return "" }
+func (c *timeoutCmd) Short() string             { return "a command with a time limit" }
+func (c *timeoutCmd) Long() string              { return "" }
+func (c *timeoutCmd) Register(fs *flag.FlagSet) {}
+func (c *timeoutCmd) Runnable() bool            { return true }
+func (c *timeoutCmd) Run(args []string) error   { return nil }
+func (c *timeoutCmd) Timeout() time.Duration    { return c.d }
+
+func TestGraceReturned(t *testing.T) {
+	var out lockedBuffer
+	a := NewApp("testapp", "a test application")
+	a.Stdout, a.Stderr = io.Discard, &out
+	a.Add(&timeoutCmd{d: time.Minute})
+
+	for i := 0; i < 200; i++ {
+		if code := a.Dispatch([]string{"timeout-test"}); code != 0 {
+			t.Fatalf("exit code %d", code)
+		}
+	}
+	// give time to a late watcher
+	time.Sleep(10 * time.Millisecond)
+	if n := strings.Count(out.String(), "terminating"); n > 0 {
+		t.Errorf("command that returned: terminating message printed %d times", n)
+	}
+}
+
+func TestWatchGrace(t *testing.T) {
+	defer func(w io.Writer, d time.Duration) { Stderr, GracePeriod = w, d }(Stderr, GracePeriod)
+	GracePeriod = time.Minute
+
+	tests := []struct {
+		cancelFirst bool
+		want        string
+	}{
+		{cancelFirst: false, want: ""},
+		{cancelFirst: true, want: "terminating"},
+	}
+	for _, test := range tests {
+		var out lockedBuffer
+		Stderr = &out
+		ctx, cancel := context.WithCancel(context.Background())
+		stop := watchGrace(ctx)
+		if test.cancelFirst {
+			cancel()
+			time.Sleep(10 * time.Millisecond)
+			stop()
+		} else {
+			stop()
+			cancel()
+		}
+		time.Sleep(10 * time.Millisecond)
+		got := out.String()
+		if (test.want == "") != (got == "") || !strings.Contains(got, test.want) {
+			t.Errorf("cancel first %v: got %q, want %q", test.cancelFirst, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,306 @@
+// Copyright (c) 2015, J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD-style license that can be found in the LICENSE file.
+
+package cmdapp
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// A Group is a command that hosts its own commands,
+// for example 'remote' in 'app remote add <name>'.
+// A group can host other groups.
+//
+// A group is added to the application with Add,
+// and its commands with the Add method of the group.
+type Group struct {
+	// Cmd is the name of the group.
+	Cmd string
+
+	// Desc is a short description of the group.
+	Desc string
+
+	// Text is the long description of the group.
+	Text string
+
+	// Flags,
+	// if set,
+	// registers the flags of the group.
+	// The flags are inherited by all the commands
+	// of the group,
+	// and of its nested groups,
+	// so they can be given before
+	// or after the command name,
+	// for example 'app remote -profile x add'
+	// or 'app remote add -profile x'.
+	// A command flag with the same name
+	// hides the inherited flag.
+	// Flags given before the group name,
+	// as 'app -profile x remote add',
+	// are global flags,
+	// that must be added with AddGlobalFlags.
+	//
+	// The flags are registered again
+	// each time the group is run,
+	// so the flag values are reset to their defaults
+	// between runs,
+	// for example in a script.
+	Flags func(fs *flag.FlagSet)
+
+	mu    sync.RWMutex
+	cmds  map[string]Command
+	flags *flag.FlagSet
+}
+
+func (g *Group) Name() string   { return g.Cmd }
+func (g *Group) Args() string   { return "<command> [<args>...]" }
+func (g *Group) Short() string  { return g.Desc }
+func (g *Group) Long() string   { return g.Text }
+func (g *Group) Runnable() bool { return true }
+
+// Register adds the flags of the group
+// to the flag set of the group command,
+// the flags are registered again,
+// so their values are reset to the defaults.
+func (g *Group) Register(fs *flag.FlagSet) {
+	g.mu.Lock()
+	g.flags = nil
+	g.mu.Unlock()
+	inheritFlags(fs, g.flagSet())
+}
+
+// flagSet returns the flag set
+// with the flags of the group.
+// The flags are registered
+// when the group is registered
+// in the flag set of the group command,
+// so the flag values are shared
+// by all the flag sets of the group
+// and its commands
+// in a run of the group.
+func (g *Group) flagSet() *flag.FlagSet {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.flags == nil && g.Flags != nil {
+		g.flags = flag.NewFlagSet(g.Cmd, flag.ContinueOnError)
+		g.Flags(g.flags)
+	}
+	return g.flags
+}
+
+// inheritFlags adds to a flag set
+// the flags of a parent flag set
+// that are not defined in the flag set.
+func inheritFlags(fs, parent *flag.FlagSet) {
+	if parent == nil {
+		return
+	}
+	parent.VisitAll(func(f *flag.Flag) {
+		if fs.Lookup(f.Name) != nil {
+			return
+		}
+		fs.Var(f.Value, f.Name, f.Usage)
+	})
+}
+
+// Add adds a command to the group.
+// Command names should be unique in the group,
+// otherwise it will trigger a panic.
+func (g *Group) Add(c Command) {
+	name := normName(c.Name())
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if prev, dup := g.cmds[name]; dup {
+		panic(fmt.Sprintf("cmdapp: Repeated command name in %s: %s %s", g.Cmd, nameConflict(c.Name(), prev.Name()), c.Short()))
+	}
+	if g.cmds == nil {
+		g.cmds = make(map[string]Command)
+	}
+	g.cmds[name] = c
+}
+
+// Commands returns the commands of the group
+// sorted by name.
+func (g *Group) Commands() []Command {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	cmds := make([]Command, 0, len(g.cmds))
+	for _, c := range g.cmds {
+		cmds = append(cmds, c)
+	}
+	sort.Slice(cmds, func(i, j int) bool {
+		return normName(cmds[i].Name()) < normName(cmds[j].Name())
+	})
+	return cmds
+}
+
+// names returns the names of the runnable commands
+// of the group.
+func (g *Group) names() []string {
+	var names []string
+	for _, c := range g.Commands() {
+		if c.Runnable() {
+			names = append(names, c.Name())
+		}
+	}
+	return names
+}
+
+// lookup returns a command of the group by its name.
+func (g *Group) lookup(name string) (Command, bool) {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	c, ok := g.cmds[normName(name)]
+	return c, ok
+}
+
+// Run runs the command of the group
+// given as the first argument,
+// when the group is run by other command.
+// The application runs the command of a group
+// directly,
+// as any other command.
+func (g *Group) Run(args []string) error {
+	c, _, fs, err := g.leaf(g.Cmd, args, nil)
+	if err != nil || c == nil {
+		return err
+	}
+	done := startContext(Context(), c)
+	return done(runCommand(c, fs.Args()))
+}
+
+// leaf returns the command of the group
+// (or of a nested group)
+// given by the arguments,
+// its path from the application,
+// and its flag set with the parsed arguments.
+// The path is the path of the group,
+// and inherited are the flag sets
+// of the parent groups.
+// If the help of the command is requested,
+// it is printed,
+// and the returned command is nil.
+func (g *Group) leaf(path string, args []string, inherited []*flag.FlagSet) (Command, string, *flag.FlagSet, error) {
+	if len(args) == 0 {
+		printPathUsage(Stderr, path, g, args)
+		return nil, path, nil, &usageError{errors.New("expecting a command")}
+	}
+	c, ok := g.lookup(args[0])
+	if !ok || !c.Runnable() {
+		printPathUsage(Stderr, path, g, args)
+		return nil, path, nil, &usageError{errors.Errorf("unknown command %s%s", args[0], didYouMean(normName(args[0]), g.names()))}
+	}
+	path += " " + c.Name()
+
+	inherited = append(inherited, g.flagSet())
+	fs, err := parseArgs(path, c, args[1:], inherited)
+	if err == flag.ErrHelp {
+		printHelp(func(w io.Writer) { pathDocumentation(w, path, c, UserShell()) })
+		return nil, path, nil, nil
+	}
+	if err != nil {
+		if _, ok := err.(*usageError); ok {
+			printPathUsage(Stderr, path, c, args[1:])
+		}
+		return nil, path, nil, errors.Wrap(err, c.Name())
+	}
+
+	warnDeprecated(c, fs)
+	if sub, ok := c.(*Group); ok {
+		return sub.leaf(path, fs.Args(), inherited)
+	}
+	return c, path, fs, nil
+}
+
+// resolve returns the command of the group
+// (or of a nested group)
+// with the given names,
+// and its path from the application.
+func (g *Group) resolve(names []string) (Command, string, error) {
+	var c Command = g
+	path := g.Name()
+	for _, nm := range names {
+		sub, ok := c.(*Group)
+		if !ok {
+			return nil, "", errors.Errorf("%s has no commands", path)
+		}
+		if c, ok = sub.lookup(nm); !ok {
+			return nil, "", errors.Errorf("unknown command %s %s", path, nm)
+		}
+		path += " " + c.Name()
+	}
+	return c, path, nil
+}
+
+// printGroupCommands prints the commands of a group.
+func printGroupCommands(w io.Writer, path string, g *Group) {
+	cmds := g.Commands()
+	if len(cmds) == 0 {
+		return
+	}
+	fmt.Fprintf(w, "The commands are:\n")
+	for _, c := range cmds {
+		fmt.Fprintf(w, "    %-16s %s\n", c.Name(), listText(c))
+	}
+	fmt.Fprintf(w, "\nUse '%s help %s <command>' for more information about a command.\n\n", Name, path)
+}
+
+// usageError is an error
+// caused by an invalid use of a command.
+type usageError struct {
+	err error
+}
+
+func (e *usageError) Error() string { return e.err.Error() }
+func (e *usageError) Cause() error  { return e.err }
+func (e *usageError) ExitCode() int { return ExitUsage }
+
+// walkCommands calls fn for each command of the application
+// and of its groups,
+// with the path of the command from the application,
+// and the groups that host the command.
+// The commands of a group are visited
+// after the group.
+func walkCommands(fn func(path string, c Command, parents []*Group)) {
+	for _, c := range sortedCommands() {
+		walkPath(c.Name(), c, nil, fn)
+	}
+}
+
+// walkPath calls fn for a command
+// and, if it is a group,
+// for each one of its commands.
+func walkPath(path string, c Command, parents []*Group, fn func(string, Command, []*Group)) {
+	fn(path, c, parents)
+	g, ok := c.(*Group)
+	if !ok {
+		return
+	}
+	parents = append(parents[:len(parents):len(parents)], g)
+	for _, sub := range g.Commands() {
+		walkPath(path+" "+sub.Name(), sub, parents, fn)
+	}
+}
+
+// pathFlags returns the flags of a command,
+// including the flags inherited from its groups,
+// sorted by name.
+func pathFlags(c Command, parents []*Group) []*flag.Flag {
+	fs := flag.NewFlagSet(c.Name(), flag.ContinueOnError)
+	c.Register(fs)
+	for i := len(parents) - 1; i >= 0; i-- {
+		inheritFlags(fs, parents[i].flagSet())
+	}
+	var flags []*flag.Flag
+	fs.VisitAll(func(f *flag.Flag) {
+		flags = append(flags, f)
+	})
+	return flags
+}
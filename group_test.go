@@ -0,0 +1,142 @@
+// Copyright (c) 2015, J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD-style license that can be found in the LICENSE file.
+
+package cmdapp
+
+import (
+	"flag"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+func TestGroupFlagsReset(t *testing.T) {
+	var profile string
+	var got []string
+	remote := &Group{
+		Cmd:  "remote",
+		Desc: "manages remotes",
+		Flags: func(fs *flag.FlagSet) {
+			fs.StringVar(&profile, "profile", "default", "profile of the remote")
+		},
+	}
+	remote.Add(&mountCmd{name: "add", run: func(c *mountCmd, args []string) error {
+		got = append(got, profile)
+		return nil
+	}})
+
+	a := NewApp("groupapp", "a test application")
+	a.Stdout, a.Stderr = io.Discard, io.Discard
+	a.Add(remote)
+
+	script := filepath.Join(t.TempDir(), "script")
+	lines := []string{
+		"remote -profile x add",
+		"remote add",
+		"remote add -profile y",
+		"remote add",
+	}
+	if err := os.WriteFile(script, []byte(strings.Join(lines, "\n")+"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if code := a.Dispatch([]string{"run-script", "-e", script}); code != 0 {
+		t.Fatalf("run-script: exit code %d", code)
+	}
+	if code := a.Dispatch([]string{"remote", "-profile", "z", "add"}); code != 0 {
+		t.Fatalf("remote add: exit code %d", code)
+	}
+	if code := a.Dispatch([]string{"remote", "add"}); code != 0 {
+		t.Fatalf("remote add: exit code %d", code)
+	}
+
+	want := []string{"x", "default", "y", "default", "z", "default"}
+	if strings.Join(got, " ") != strings.Join(want, " ") {
+		t.Errorf("profiles %q, want %q", got, want)
+	}
+}
+
+// leafCmd is a command of a group
+// with a time limit,
+// that requires a single argument.
+type leafCmd struct {
+	ran      bool
+	deadline bool
+}
+
+func (c *leafCmd) Name() string              { return "add" }
+func (c *leafCmd) Args() string              { return "<name>" }
+func (c *leafCmd) Short() string             { return "adds a remote" }
+func (c *leafCmd) Long() string              { return "Adds a remote." }
+func (c *leafCmd) Register(fs *flag.FlagSet) {}
+func (c *leafCmd) Runnable() bool            { return true }
+func (c *leafCmd) Timeout() time.Duration    { return time.Minute }
+func (c *leafCmd) Run(args []string) error {
+	c.ran = true
+	_, c.deadline = Context().Deadline()
+	return nil
+}
+
+func (c *leafCmd) Validate(args []string) error {
+	if len(args) != 1 {
+		return errors.New("expecting a name")
+	}
+	return nil
+}
+
+func TestGroupLeaf(t *testing.T) {
+	tests := []struct {
+		args []string
+		code int
+		ran  bool
+	}{
+		{args: []string{"remote", "add", "x"}, ran: true},
+		{args: []string{"remote", "sub", "add", "x"}, ran: true},
+		{args: []string{"remote", "add"}, code: ExitUsage},
+		{args: []string{"remote", "add", "-bad", "x"}, code: ExitUsage},
+		{args: []string{"remote", "sub", "add", "x", "y"}, code: ExitUsage},
+		{args: []string{"remote"}, code: ExitUsage},
+		{args: []string{"remote", "nope"}, code: ExitUsage},
+		{args: []string{"remote", "-bad", "add", "x"}, code: ExitUsage},
+		{args: []string{"remote", "add", "-h"}},
+	}
+	for _, test := range tests {
+		add, subAdd := &leafCmd{}, &leafCmd{}
+		sub := &Group{Cmd: "sub", Desc: "a nested group"}
+		sub.Add(subAdd)
+		remote := &Group{Cmd: "remote", Desc: "manages remotes"}
+		remote.Add(add)
+		remote.Add(sub)
+
+		a := NewApp("groupapp", "a test application")
+		a.Stdout, a.Stderr = io.Discard, io.Discard
+		a.Add(remote)
+
+		if code := a.Dispatch(test.args); code != test.code {
+			t.Errorf("%v: exit code %d, want %d", test.args, code, test.code)
+		}
+		ran := add.ran || subAdd.ran
+		if ran != test.ran {
+			t.Errorf("%v: run %v, want %v", test.args, ran, test.ran)
+		}
+		if ran && !add.deadline && !subAdd.deadline {
+			t.Errorf("%v: the time limit of the command was not set", test.args)
+		}
+	}
+
+	// a group run by other command
+	add := &leafCmd{}
+	remote := &Group{Cmd: "remote", Desc: "manages remotes"}
+	remote.Add(add)
+	if err := invoke(remote, []string{"add", "x"}); err != nil {
+		t.Fatalf("invoke remote add: %v", err)
+	}
+	if !add.ran || !add.deadline {
+		t.Errorf("invoke remote add: run %v, time limit %v", add.ran, add.deadline)
+	}
+}
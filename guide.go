@@ -0,0 +1,123 @@
+// Copyright (c) 2015, J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD-style license that can be found in the LICENSE file.
+
+package cmdapp
+
+import (
+	"bufio"
+	"flag"
+	"io/fs"
+	"path"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// A Guide is a help topic,
+// a documentation pseudo-command
+// that is shown with 'help <topic>'.
+type Guide struct {
+	// Topic is the name used to access the guide.
+	Topic string
+
+	// Title is the title of the guide.
+	Title string
+
+	// Desc is a short description of the guide.
+	Desc string
+
+	// Text is the content of the guide.
+	Text string
+}
+
+func (g *Guide) Name() string           { return g.Topic }
+func (g *Guide) Args() string           { return "" }
+func (g *Guide) Short() string          { return g.Desc }
+func (g *Guide) Long() string           { return g.Text }
+func (g *Guide) Register(*flag.FlagSet) {}
+func (g *Guide) Runnable() bool         { return false }
+
+func (g *Guide) Run(args []string) error {
+	return errors.Errorf("%s: is a help topic", g.Topic)
+}
+
+// AddGuidesFS adds as help topics
+// each Markdown file (with extension .md)
+// found in the given directory of a file system,
+// for example an embed.FS.
+//
+// The topic name is the file name without its extension,
+// the title is taken from the first level header,
+// and the short description from the first paragraph.
+func AddGuidesFS(fsys fs.FS, dir string) error {
+	files, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return errors.Wrap(err, "cmdapp: guides")
+	}
+	for _, f := range files {
+		if f.IsDir() || path.Ext(f.Name()) != ".md" {
+			continue
+		}
+		data, err := fs.ReadFile(fsys, path.Join(dir, f.Name()))
+		if err != nil {
+			return errors.Wrap(err, "cmdapp: guides")
+		}
+		Add(parseGuide(strings.TrimSuffix(f.Name(), ".md"), string(data)))
+	}
+	return nil
+}
+
+// parseGuide creates a guide from a Markdown text.
+func parseGuide(name, text string) *Guide {
+	g := &Guide{Topic: strings.ToLower(name), Title: name}
+
+	var lines []string
+	s := bufio.NewScanner(strings.NewReader(text))
+	for s.Scan() {
+		lines = append(lines, strings.TrimRight(s.Text(), " \t\r"))
+	}
+
+	// title
+	body := lines
+	for i, ln := range lines {
+		if strings.TrimSpace(ln) == "" {
+			continue
+		}
+		if strings.HasPrefix(ln, "# ") {
+			g.Title = strings.TrimSpace(strings.Trim(ln, "#"))
+			body = lines[i+1:]
+		} else if i+1 < len(lines) && isSetextH1(lines[i+1]) {
+			g.Title = strings.TrimSpace(ln)
+			body = lines[i+2:]
+		}
+		break
+	}
+
+	// first paragraph
+	var par []string
+	for _, ln := range body {
+		ln = strings.TrimSpace(ln)
+		if ln == "" {
+			if len(par) > 0 {
+				break
+			}
+			continue
+		}
+		par = append(par, ln)
+	}
+	g.Desc = strings.Join(par, " ")
+	if g.Desc == "" {
+		g.Desc = g.Title
+	}
+
+	g.Text = strings.Join(body, "\n")
+	return g
+}
+
+// isSetextH1 reports whether a line is a Markdown underline
+// for a first level header.
+func isSetextH1(ln string) bool {
+	ln = strings.TrimSpace(ln)
+	return ln != "" && strings.Trim(ln, "=") == ""
+}
@@ -12,7 +12,6 @@ import (
 	"fmt"
 	"io"
 	"os"
-	"sort"
 	"strings"
 
 	"github.com/pkg/errors"
@@ -21,6 +20,17 @@ import (
 // help is the help command.
 type help struct{}
 
+// helpWeb is set by the -web flag of help.
+var helpWeb bool
+
+// DocURL is the URL of the online documentation
+// of the application,
+// opened by 'help -web'.
+// If it contains '%s',
+// it is replaced by the name of the command
+// to show.
+var DocURL string
+
 func init() {
 	Add(help{})
 }
@@ -30,22 +40,43 @@ Command help displays help information for a command or a help topic.
 
 With no arguments prints to the standard output the list of available commands
 and help topics.
+
+For a command that hosts other commands,
+'help <command> <subcommand>' displays the help of the subcommand.
+
+With 'search <term>' it prints the commands and help topics that contain the
+given term.
+
+The flags are:
+
+    -web
+      Opens the online documentation of the application in the browser.
 `
 
-func (h help) Name() string              { return "help" }
-func (h help) Args() string              { return "[<command>]" }
-func (h help) Short() string             { return "displays help information about " + Name }
-func (h help) Long() string              { return helpCmdLong }
-func (h help) Register(fs *flag.FlagSet) {}
-func (h help) Runnable() bool            { return true }
+func (h help) Name() string   { return "help" }
+func (h help) Args() string   { return "[-web] [<command> [<subcommand>...] | search <term>]" }
+func (h help) Short() string  { return "displays help information about " + Name }
+func (h help) Long() string   { return helpCmdLong }
+func (h help) Runnable() bool { return true }
+
+func (h help) Register(fs *flag.FlagSet) {
+	fs.BoolVar(&helpWeb, "web", false, "open the online documentation")
+}
 
 func (h help) Run(args []string) error {
+	if helpWeb {
+		return openDoc(args)
+	}
 	if len(args) == 0 {
-		printUsage(os.Stdout)
+		printHelp(printUsage)
 		return nil
 	}
-	if len(args) != 1 {
-		return errors.New("help: too many arguments.")
+	if args[0] == "search" && len(args) > 1 {
+		search(Output(), strings.Join(args[1:], " "))
+		return nil
+	}
+	if len(args) > 1 {
+		return groupHelp(args)
 	}
 
 	arg := args[0]
@@ -59,74 +90,147 @@ func (h help) Run(args []string) error {
 		defer f.Close()
 		fmt.Fprintf(f, "%s\n", strings.TrimSpace(goHead))
 		printUsage(f)
-		mutex.Lock()
-		defer mutex.Unlock()
-		var cmds []string
-		for _, c := range commands {
-			cmds = append(cmds, c.Name())
-		}
-		sort.Strings(cmds)
-
-		for _, c := range cmds {
-			documentation(f, commands[c])
+		var cmds, topics []string
+		walkCommands(func(path string, c Command, _ []*Group) {
+			var b strings.Builder
+			pathDocumentation(&b, path, c, docShell)
+			doc := strings.Replace(b.String(), "*/", "* /", -1)
+			if !c.Runnable() {
+				topics = append(topics, doc)
+				return
+			}
+			cmds = append(cmds, doc)
+		})
+
+		// commands are followed by help topics and guides
+		for _, doc := range append(cmds, topics...) {
+			fmt.Fprint(f, doc)
 		}
 		fmt.Fprintf(f, "\n%s", strings.TrimSpace(goFoot))
 		return nil
 	}
 
-	mutex.Lock()
-	c, ok := commands[arg]
-	mutex.Unlock()
+	// 'help readme' updates the commands of README.md
+	if arg == "readme" {
+		return UpdateReadme("README.md")
+	}
+
+	c, ok := lookup(arg)
 	if !ok {
+		if path, ok := findPlugin(arg); ok {
+			fmt.Fprintf(Output(), "%s is a plugin command (%s).\n\nUse '%s %s -help' for more information.\n", arg, path, Name, arg)
+			return nil
+		}
 		return errors.Errorf("help: unknown help topic: %s", arg)
 	}
-	documentation(os.Stdout, c)
+	printHelp(func(w io.Writer) { documentation(w, c) })
 	return nil
 }
 
+// groupHelp prints the help of a command of a group.
+func groupHelp(args []string) error {
+	c, ok := lookup(args[0])
+	if !ok {
+		return errors.Errorf("help: unknown help topic: %s", args[0])
+	}
+	g, ok := c.(*Group)
+	if !ok {
+		return errors.New("help: too many arguments.")
+	}
+	c, path, err := g.resolve(args[1:])
+	if err != nil {
+		return errors.Wrap(err, "help")
+	}
+	printHelp(func(w io.Writer) { pathDocumentation(w, path, c, UserShell()) })
+	return nil
+}
+
+// printHelp prints a help text in the standard output,
+// using hyperlinks for URLs
+// if they are supported by the terminal.
+func printHelp(help func(io.Writer)) {
+	if !Hyperlinks() {
+		help(Output())
+		return
+	}
+	var b strings.Builder
+	help(&b)
+	fmt.Fprint(Output(), linkify(b.String()))
+}
+
 // printUsage outputs the application usage help.
 func printUsage(w io.Writer) {
 	fmt.Fprintf(w, "%s\n\n", Short)
-	fmt.Fprintf(w, "Usage:\n\n    %s [help] <command> [<args>...]\n\n", Name)
+	flags := ""
+	if hasGlobalFlags() {
+		flags = "[<flags>] "
+	}
+	fmt.Fprintf(w, "Usage:\n\n    %s %s[help] <command> [<args>...]\n\n", Name, flags)
+	printGlobalFlags(w)
 	topics := false
-	fmt.Fprintf(w, "The commands are:\n")
-
-	mutex.Lock()
-	defer mutex.Unlock()
-	var cmds []string
-	for _, c := range commands {
-		cmds = append(cmds, c.Name())
+	cmds := sortedCommands()
+	var run []Command
+	for _, c := range cmds {
 		if !c.Runnable() {
 			topics = true
-		}
-	}
-	sort.Strings(cmds)
-
-	for _, nm := range cmds {
-		c := commands[nm]
-		if !c.Runnable() {
 			continue
 		}
-		fmt.Fprintf(w, "    %-16s %s\n", c.Name(), c.Short())
+		run = append(run, c)
 	}
+	printCommandList(w, run)
 	fmt.Fprintf(w, "\nUse '%s help <command>' for more information about a command.\n\n", Name)
+	printPlugins(w)
 	if !topics {
 		return
 	}
 	fmt.Fprintf(w, "Additional help topics:\n\n")
-	for _, nm := range cmds {
-		c := commands[nm]
+	for _, c := range cmds {
 		if c.Runnable() {
 			continue
 		}
-		fmt.Fprintf(w, "    %-16s %s\n", c.Name(), c.Short())
+		fmt.Fprintf(w, "    %-16s %s\n", c.Name(), shortText(c))
 	}
 	fmt.Fprintf(w, "\nUse '%s help <topic>' for more information about that topic.\n\n", Name)
 }
 
+// listText returns the text of a command
+// in the list of commands,
+// its short description,
+// flagged if the command is deprecated.
+func listText(c Command) string {
+	if isDeprecated(c) {
+		return shortText(c) + " (deprecated)"
+	}
+	return shortText(c)
+}
+
 var goHead = `// Authomatically generated doc.go file for use with godoc.
 
 /*`
 
 var goFoot = `*/
 package main`
+
+// openDoc opens the online documentation
+// of the application or a command.
+func openDoc(args []string) error {
+	if DocURL == "" {
+		return errors.New("help: no online documentation")
+	}
+	if len(args) > 1 {
+		return errors.New("help: too many arguments.")
+	}
+	name := ""
+	if len(args) == 1 {
+		c, ok := lookup(args[0])
+		if !ok {
+			return errors.Errorf("help: unknown help topic: %s", args[0])
+		}
+		name = c.Name()
+	}
+	url := DocURL
+	if strings.Contains(url, "%s") {
+		url = strings.Replace(url, "%s", name, 1)
+	}
+	return OpenBrowser(url)
+}
@@ -0,0 +1,142 @@
+// Copyright (c) 2015, J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD-style license that can be found in the LICENSE file.
+
+package cmdapp
+
+import (
+	"flag"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// A Hint is a tip about a feature of the application.
+type Hint struct {
+	// Command is the name of the command
+	// in which the hint is relevant,
+	// for a command of a group,
+	// its path,
+	// for example "remote add".
+	// If empty,
+	// the hint is relevant in all commands.
+	Command string
+
+	// Text is the one line text of the hint,
+	// for example "use -format json for scripting".
+	Text string
+}
+
+// hints are the hints added by the application.
+var (
+	hintMutex sync.Mutex
+	hints     []Hint
+)
+
+// AddHint adds hints of the application.
+//
+// After a command finishes without errors,
+// a hint relevant to the command,
+// not already shown,
+// is printed in Stderr,
+// at most once each HintInterval.
+// Hints are not shown
+// if the application is not interactive,
+// in porcelain mode,
+// or if the -no-hints flag is set.
+func AddHint(h ...Hint) {
+	hintMutex.Lock()
+	defer hintMutex.Unlock()
+	hints = append(hints, h...)
+}
+
+// HintInterval is the minimum time
+// between two hints.
+var HintInterval = 24 * time.Hour
+
+// noHints is set with the -no-hints flag.
+var noHints bool
+
+// registerHintFlags sets the hint flags
+// of the application.
+func registerHintFlags(fs *flag.FlagSet) {
+	fs.BoolVar(&noHints, "no-hints", false, "do not show hints after the commands")
+}
+
+// State store keys of the hints.
+const (
+	hintLastKey = "hints.last"
+	hintSeenKey = "hints.seen"
+)
+
+// showHint prints a hint relevant to a command,
+// if it is time for a new hint.
+// Hints specific to the command
+// are preferred.
+func showHint(path string) {
+	if noHints || Porcelain() || !Interactive() {
+		return
+	}
+	hintMutex.Lock()
+	hs := append([]Hint(nil), hints...)
+	hintMutex.Unlock()
+	if len(hs) == 0 {
+		return
+	}
+
+	var last time.Time
+	if _, err := State().Get(hintLastKey, &last); err != nil {
+		logger.Info("hint", "error", err.Error())
+		return
+	}
+	if Since(last) < HintInterval {
+		return
+	}
+	var seen []string
+	if _, err := State().Get(hintSeenKey, &seen); err != nil {
+		logger.Info("hint", "error", err.Error())
+		return
+	}
+
+	h, ok := nextHint(hs, path, seen)
+	if !ok {
+		return
+	}
+	fmt.Fprintf(Stderr, "%s\n", Mark(Notice, "tip: "+h.Text))
+	if err := State().Set(hintLastKey, Now()); err != nil {
+		logger.Info("hint", "error", err.Error())
+		return
+	}
+	if err := State().Set(hintSeenKey, append(seen, h.Text)); err != nil {
+		logger.Info("hint", "error", err.Error())
+	}
+}
+
+// nextHint returns the first hint not seen
+// specific to a command,
+// or else the first general hint not seen.
+func nextHint(hs []Hint, cmd string, seen []string) (Hint, bool) {
+	isSeen := make(map[string]bool, len(seen))
+	for _, s := range seen {
+		isSeen[s] = true
+	}
+	var general *Hint
+	for i, h := range hs {
+		if isSeen[h.Text] {
+			continue
+		}
+		if h.Command == "" {
+			if general == nil {
+				general = &hs[i]
+			}
+			continue
+		}
+		if normName(h.Command) == normName(cmd) {
+			return h, true
+		}
+	}
+	if general == nil {
+		return Hint{}, false
+	}
+	return *general, true
+}
@@ -0,0 +1,99 @@
+// Copyright (c) 2015, J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD-style license that can be found in the LICENSE file.
+
+package cmdapp
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNextHint(t *testing.T) {
+	hs := []Hint{
+		{Text: "g1"},
+		{Command: "build", Text: "b1"},
+		{Text: "g2"},
+		{Command: "Remote Add", Text: "r1"},
+		{Command: "build", Text: "b2"},
+	}
+	tests := []struct {
+		cmd  string
+		seen []string
+		want string
+	}{
+		{cmd: "build", want: "b1"},
+		{cmd: "build", seen: []string{"b1"}, want: "b2"},
+		{cmd: "build", seen: []string{"b1", "b2"}, want: "g1"},
+		{cmd: "build", seen: []string{"b1", "b2", "g1"}, want: "g2"},
+		{cmd: "build", seen: []string{"b1", "b2", "g1", "g2"}},
+		{cmd: "remote add", want: "r1"},
+		{cmd: "remote", want: "g1"},
+		{cmd: "test", seen: []string{"g1"}, want: "g2"},
+	}
+	for _, test := range tests {
+		h, ok := nextHint(hs, test.cmd, test.seen)
+		if ok != (test.want != "") || h.Text != test.want {
+			t.Errorf("%s, seen %q: got %q (%v), want %q", test.cmd, test.seen, h.Text, ok, test.want)
+		}
+	}
+}
+
+func TestShowHint(t *testing.T) {
+	t.Setenv("XDG_DATA_HOME", t.TempDir())
+	defer func(s *StateStore, c Clock, m Mode, no, p bool, w io.Writer) {
+		state, InteractiveMode, noHints, porcelain, Stderr = s, m, no, p, w
+		SetClock(c)
+	}(state, getClock(), InteractiveMode, noHints, porcelain, Stderr)
+	hintMutex.Lock()
+	prev := hints
+	hints = nil
+	hintMutex.Unlock()
+	defer func() {
+		hintMutex.Lock()
+		hints = prev
+		hintMutex.Unlock()
+	}()
+
+	state = &StateStore{}
+	clock := NewFakeClock(time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC))
+	SetClock(clock)
+	AddHint(Hint{Text: "general"}, Hint{Command: "build", Text: "use -j to build faster"})
+
+	// the steps are run in order,
+	// as they share the state
+	tests := []struct {
+		cmd     string
+		advance time.Duration
+		mode    Mode
+		noHints bool
+		porc    bool
+		want    string
+	}{
+		{cmd: "build", want: "tip: use -j to build faster"},
+		{cmd: "build", advance: time.Hour},
+		{cmd: "build", advance: 24 * time.Hour, mode: Never},
+		{cmd: "build", noHints: true},
+		{cmd: "build", porc: true},
+		{cmd: "build", want: "tip: general"},
+		{cmd: "build", advance: 25 * time.Hour},
+		{cmd: "test", advance: 25 * time.Hour},
+	}
+	for i, test := range tests {
+		var errOut bytes.Buffer
+		Stderr = &errOut
+		clock.Advance(test.advance)
+		InteractiveMode, noHints, porcelain = Always, test.noHints, test.porc
+		if test.mode != Auto {
+			InteractiveMode = test.mode
+		}
+		showHint(test.cmd)
+		got := strings.TrimSpace(errOut.String())
+		if (test.want == "" && got != "") || !strings.Contains(got, test.want) {
+			t.Errorf("step %d: %s: got %q, want %q", i, test.cmd, got, test.want)
+		}
+	}
+}
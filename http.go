@@ -0,0 +1,208 @@
+// Copyright (c) 2015, J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD-style license that can be found in the LICENSE file.
+
+package cmdapp
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"flag"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// HTTP client flags.
+var (
+	httpInsecure bool
+	httpCACert   string
+	httpProxy    string
+	httpTimeout  time.Duration
+)
+
+// registerHTTPFlags sets the HTTP client flags
+// of the application.
+func registerHTTPFlags(fs *flag.FlagSet) {
+	fs.BoolVar(&httpInsecure, "insecure", false, "do not verify the certificates of HTTPS servers")
+	fs.StringVar(&httpCACert, "ca-cert", "", "trust the certificates in the given PEM file")
+	fs.StringVar(&httpProxy, "proxy", "", "URL of the proxy for HTTP requests")
+	fs.DurationVar(&httpTimeout, "http-timeout", 30*time.Second, "maximum time of an HTTP request (0 for no limit)")
+}
+
+// httpClient is the client returned by HTTPClient.
+var (
+	httpMutex  sync.Mutex
+	httpClient *http.Client
+)
+
+// HTTPClient returns the HTTP client of the application,
+// that should be used by all commands
+// for a consistent network behavior.
+//
+// The client is configured with the flags
+// -insecure,
+// -ca-cert,
+// -proxy
+// (by default the proxy is set with the HTTP_PROXY,
+// HTTPS_PROXY,
+// and NO_PROXY environment variables),
+// and -http-timeout,
+// and the keys of NetworkConfig,
+// if they are defined.
+// Requests without a User-Agent
+// use the name and version of the application,
+// and all requests are logged
+// as debug messages.
+//
+// Unless the -no-cache flag is set,
+// responses with an ETag or Last-Modified header
+// are stored in the cache directory
+// (except private responses,
+// and responses of authenticated requests),
+// and revalidated in the next requests.
+// If the server can not be reached
+// and the stale-if-error directive of the response allows it,
+// or the application is in offline mode,
+// the cached response is used.
+func HTTPClient() (*http.Client, error) {
+	httpMutex.Lock()
+	defer httpMutex.Unlock()
+	if httpClient != nil {
+		return httpClient, nil
+	}
+
+	t := http.DefaultTransport.(*http.Transport).Clone()
+	proxy, _, err := proxyFunc()
+	if err != nil {
+		return nil, err
+	}
+	t.Proxy = proxy
+	pool, _, err := certPool()
+	if err != nil {
+		return nil, err
+	}
+	if httpInsecure || pool != nil {
+		t.TLSClientConfig = &tls.Config{
+			InsecureSkipVerify: httpInsecure,
+			RootCAs:            pool,
+		}
+	}
+
+	httpClient = &http.Client{
+		Transport: logTransport{newCacheTransport(offlineTransport{t})},
+		Timeout:   httpTimeout,
+	}
+	return httpClient, nil
+}
+
+// NetworkConfig are the configuration keys
+// read by HTTPClient.
+// They are not part of the configuration schema
+// unless the application defines them,
+// with:
+//
+//	cmdapp.DefineConfig(cmdapp.NetworkConfig...)
+var NetworkConfig = []Key{
+	{Name: "ca-certs", Desc: "PEM files with trusted certificates for HTTPS, separated by '" + string(filepath.ListSeparator) + "'"},
+	{Name: "proxy", Desc: "URL of the proxy for HTTP requests"},
+}
+
+// proxyFunc returns the proxy function
+// of the HTTP client,
+// and a description of the proxy.
+// The -proxy flag takes precedence
+// over the proxy configuration key,
+// and both over the environment variables.
+func proxyFunc() (func(*http.Request) (*url.URL, error), string, error) {
+	from, proxy := "flag -proxy", httpProxy
+	if proxy == "" {
+		from, proxy = "config key proxy", ConfigValue("proxy")
+	}
+	if proxy == "" {
+		return http.ProxyFromEnvironment, "", nil
+	}
+	u, err := url.Parse(proxy)
+	if err != nil {
+		return nil, "", errors.Wrap(err, from)
+	}
+	if u.Scheme == "" || u.Host == "" {
+		return nil, "", errors.Errorf("%s: invalid proxy URL %q", from, proxy)
+	}
+	return http.ProxyURL(u), u.Redacted() + " (" + from + ")", nil
+}
+
+// certPool returns the pool of trusted certificates,
+// with the system certificates
+// and the certificates of the files
+// given with the -ca-cert flag,
+// or the ca-certs configuration key,
+// and the files read.
+// If no file is given,
+// it returns a nil pool,
+// so the system certificates are used.
+func certPool() (*x509.CertPool, []string, error) {
+	var files []string
+	if httpCACert != "" {
+		files = append(files, httpCACert)
+	}
+	for _, f := range filepath.SplitList(ConfigValue("ca-certs")) {
+		if f != "" {
+			files = append(files, f)
+		}
+	}
+	if len(files) == 0 {
+		return nil, nil, nil
+	}
+
+	pool, err := x509.SystemCertPool()
+	if err != nil {
+		pool = x509.NewCertPool()
+	}
+	for _, f := range files {
+		pem, err := os.ReadFile(f)
+		if err != nil {
+			return nil, nil, errors.Wrap(err, "CA certificates")
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, nil, errors.Errorf("CA certificates: no certificates in %s", f)
+		}
+	}
+	return pool, files, nil
+}
+
+// userAgent returns the User-Agent of the requests
+// of the application.
+func userAgent() string {
+	if Version == "" {
+		return appName()
+	}
+	return appName() + "/" + Version
+}
+
+// logTransport is an HTTP transport
+// that sets the User-Agent of the requests
+// and logs them.
+type logTransport struct {
+	base http.RoundTripper
+}
+
+func (t logTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Header.Get("User-Agent") == "" {
+		req = req.Clone(req.Context())
+		req.Header.Set("User-Agent", userAgent())
+	}
+	start := time.Now()
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		logger.Debug("http", "method", req.Method, "url", req.URL.Redacted(), "error", err.Error())
+		return nil, err
+	}
+	logger.Debug("http", "method", req.Method, "url", req.URL.Redacted(), "status", resp.StatusCode, "duration", time.Since(start).String())
+	return resp, nil
+}
@@ -0,0 +1,300 @@
+// Copyright (c) 2015, J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD-style license that can be found in the LICENSE file.
+
+package cmdapp
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"flag"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// MaxCacheSize is the maximum size of a response body
+// stored in the HTTP cache.
+// Larger responses,
+// as downloads,
+// are not cached.
+var MaxCacheSize int64 = 1 << 20
+
+// noCache is set with the -no-cache flag.
+var noCache bool
+
+// registerCacheFlags sets the HTTP cache flags
+// of the application.
+func registerCacheFlags(fs *flag.FlagSet) {
+	fs.BoolVar(&noCache, "no-cache", false, "do not use the cache of HTTP responses")
+}
+
+// cacheTransport is an HTTP transport
+// that stores the responses
+// with an ETag or Last-Modified header
+// in the cache directory,
+// and revalidates them in the next requests.
+// Responses are stored by URL
+// and by the request headers named by Vary.
+// Private responses,
+// responses that should not be stored,
+// and responses of authenticated requests
+// are not stored.
+//
+// If the server can not be reached,
+// the cached response is used
+// if its stale-if-error directive allows it,
+// or if the application is in offline mode.
+type cacheTransport struct {
+	base http.RoundTripper
+	dir  string
+}
+
+// newCacheTransport returns a cache transport
+// or the base transport if the cache is disabled.
+func newCacheTransport(base http.RoundTripper) http.RoundTripper {
+	if noCache {
+		return base
+	}
+	dir, err := CacheDir()
+	if err != nil {
+		logger.Info("http: cache disabled", "error", err.Error())
+		return base
+	}
+	return cacheTransport{base: base, dir: filepath.Join(dir, "http")}
+}
+
+func (t cacheTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Method != http.MethodGet || req.Header.Get("Range") != "" || !storable(req) {
+		return t.base.RoundTrip(req)
+	}
+
+	name := t.file(req, t.vary(req))
+	cached := t.read(name, req)
+	if cached != nil {
+		req = req.Clone(req.Context())
+		if v := cached.Header.Get("ETag"); v != "" {
+			req.Header.Set("If-None-Match", v)
+		}
+		if v := cached.Header.Get("Last-Modified"); v != "" {
+			req.Header.Set("If-Modified-Since", v)
+		}
+	}
+
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		if cached == nil || req.Context().Err() != nil {
+			return nil, err
+		}
+		if errors.Cause(err) != ErrOffline && !staleIfError(cached) {
+			cached.Body.Close()
+			return nil, err
+		}
+		logger.Info("http: using cached response", "url", req.URL.Redacted(), "error", err.Error())
+		return cached, nil
+	}
+	if resp.StatusCode == http.StatusNotModified && cached != nil {
+		resp.Body.Close()
+		return cached, nil
+	}
+	if cached != nil {
+		cached.Body.Close()
+	}
+	if resp.StatusCode != http.StatusOK || !cacheable(resp) {
+		if cached != nil && resp.StatusCode == http.StatusOK {
+			// the cached response is outdated
+			os.Remove(name)
+		}
+		return resp, nil
+	}
+	return t.store(req, resp)
+}
+
+// storable returns true
+// if the response of a request can be stored in the cache.
+func storable(req *http.Request) bool {
+	if req.Header.Get("Authorization") != "" {
+		return false
+	}
+	_, noStore := cacheControl(req.Header)["no-store"]
+	return !noStore
+}
+
+// cacheable returns true
+// if a response can be stored in the cache.
+func cacheable(resp *http.Response) bool {
+	if resp.Header.Get("ETag") == "" && resp.Header.Get("Last-Modified") == "" {
+		return false
+	}
+	cc := cacheControl(resp.Header)
+	if _, ok := cc["no-store"]; ok {
+		return false
+	}
+	if _, ok := cc["private"]; ok {
+		return false
+	}
+	for _, v := range varyHeaders(resp.Header) {
+		if v == "*" {
+			return false
+		}
+	}
+	return resp.ContentLength <= MaxCacheSize
+}
+
+// staleIfError returns true
+// if a cached response can be used
+// when the server can not be reached,
+// i.e. it has a stale-if-error directive,
+// and the response is not older
+// than its max-age
+// plus the stale-if-error time.
+func staleIfError(resp *http.Response) bool {
+	cc := cacheControl(resp.Header)
+	if _, ok := cc["no-cache"]; ok {
+		return false
+	}
+	if _, ok := cc["must-revalidate"]; ok {
+		return false
+	}
+	stale, err := strconv.Atoi(cc["stale-if-error"])
+	if err != nil {
+		return false
+	}
+	maxAge, _ := strconv.Atoi(cc["max-age"])
+	date, err := http.ParseTime(resp.Header.Get("Date"))
+	if err != nil {
+		return false
+	}
+	return Since(date) <= time.Duration(maxAge+stale)*time.Second
+}
+
+// cacheControl returns the directives
+// of the Cache-Control header,
+// with the directive names in lower case.
+func cacheControl(h http.Header) map[string]string {
+	cc := make(map[string]string)
+	for _, v := range h.Values("Cache-Control") {
+		for _, d := range strings.Split(v, ",") {
+			name, val, _ := strings.Cut(strings.TrimSpace(d), "=")
+			if name == "" {
+				continue
+			}
+			cc[strings.ToLower(name)] = strings.Trim(val, `"`)
+		}
+	}
+	return cc
+}
+
+// varyHeaders returns the header names
+// of the Vary header,
+// in canonical form.
+func varyHeaders(h http.Header) []string {
+	var names []string
+	for _, v := range h.Values("Vary") {
+		for _, n := range strings.Split(v, ",") {
+			if n = strings.TrimSpace(n); n != "" {
+				names = append(names, http.CanonicalHeaderKey(n))
+			}
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// file returns the cache file of a request,
+// vary are the request headers
+// named by the Vary header of the response.
+func (t cacheTransport) file(req *http.Request, vary []string) string {
+	key := req.URL.String()
+	for _, h := range vary {
+		key += "\n" + h + ": " + strings.Join(req.Header.Values(h), ", ")
+	}
+	sum := sha256.Sum256([]byte(key))
+	return filepath.Join(t.dir, hex.EncodeToString(sum[:]))
+}
+
+// varyFile returns the file
+// with the Vary header names
+// of the cached responses of an URL.
+func (t cacheTransport) varyFile(req *http.Request) string {
+	return t.file(req, nil) + ".vary"
+}
+
+// vary returns the header names
+// of the Vary header
+// of the cached responses of a request.
+func (t cacheTransport) vary(req *http.Request) []string {
+	b, err := os.ReadFile(t.varyFile(req))
+	if err != nil {
+		return nil
+	}
+	return strings.Fields(string(b))
+}
+
+// read returns the cached response of a request,
+// or nil if there is no cached response.
+func (t cacheTransport) read(name string, req *http.Request) *http.Response {
+	b, err := os.ReadFile(name)
+	if err != nil {
+		return nil
+	}
+	resp, err := http.ReadResponse(bufio.NewReader(bytes.NewReader(b)), req)
+	if err != nil {
+		logger.Info("http: invalid cache file", "file", name, "error", err.Error())
+		return nil
+	}
+	return resp
+}
+
+// store stores the response of a request in the cache
+// and returns a response with the same content.
+// If the body is larger than MaxCacheSize,
+// the response is not stored.
+func (t cacheTransport) store(req *http.Request, resp *http.Response) (*http.Response, error) {
+	body, err := io.ReadAll(io.LimitReader(resp.Body, MaxCacheSize+1))
+	if err != nil {
+		resp.Body.Close()
+		return nil, err
+	}
+	if int64(len(body)) > MaxCacheSize {
+		resp.Body = readCloser{io.MultiReader(bytes.NewReader(body), resp.Body), resp.Body}
+		return resp, nil
+	}
+	resp.Body.Close()
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+
+	save := *resp
+	save.Body = io.NopCloser(bytes.NewReader(body))
+	save.ContentLength = int64(len(body))
+	save.TransferEncoding = nil
+	vary := varyHeaders(resp.Header)
+	err = WriteFileAtomic(t.varyFile(req), []byte(strings.Join(vary, "\n")), SecretMode)
+	if err == nil {
+		err = writeCache(t.file(req, vary), &save)
+	}
+	if err != nil {
+		logger.Info("http: cache not written", "url", req.URL.Redacted(), "error", err.Error())
+	}
+	return resp, nil
+}
+
+// writeCache writes a response in a cache file.
+func writeCache(name string, resp *http.Response) error {
+	return WriteAtomic(name, SecretMode, resp.Write)
+}
+
+// readCloser is a reader
+// with the closer of another reader.
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
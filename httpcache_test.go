@@ -0,0 +1,166 @@
+// Copyright (c) 2015, J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD-style license that can be found in the LICENSE file.
+
+package cmdapp
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// fakeServer is an HTTP transport
+// that answers with the response headers
+// and a body with the Accept header of the request.
+type fakeServer struct {
+	header http.Header
+	err    error
+
+	// revalidated is set if the last request
+	// was a conditional request
+	revalidated bool
+
+	// notModified is the number
+	// of not modified responses
+	notModified int
+}
+
+func (s *fakeServer) RoundTrip(req *http.Request) (*http.Response, error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+	s.revalidated = req.Header.Get("If-None-Match") != ""
+	body := "body " + req.Header.Get("Accept")
+	h := s.header.Clone()
+	h.Set("ETag", `"`+req.Header.Get("Accept")+`"`)
+	h.Set("Date", Now().UTC().Format(http.TimeFormat))
+	status := http.StatusOK
+	if req.Header.Get("If-None-Match") == h.Get("ETag") {
+		status, body = http.StatusNotModified, ""
+		s.notModified++
+	}
+	return &http.Response{
+		Status:        http.StatusText(status),
+		StatusCode:    status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        h,
+		Body:          io.NopCloser(strings.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}, nil
+}
+
+// get makes a request with the given headers
+// and returns the body of the response.
+func get(t *testing.T, rt http.RoundTripper, header ...string) (string, error) {
+	t.Helper()
+	req, err := http.NewRequest(http.MethodGet, "http://example.com/data", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i+1 < len(header); i += 2 {
+		req.Header.Set(header[i], header[i+1])
+	}
+	resp, err := rt.RoundTrip(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	b, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(b), nil
+}
+
+func TestCacheStore(t *testing.T) {
+	tests := []struct {
+		name   string
+		header http.Header
+		req    []string
+		want   bool
+	}{
+		{"stored", http.Header{}, nil, true},
+		{"no-store", http.Header{"Cache-Control": {"no-store"}}, nil, false},
+		{"private", http.Header{"Cache-Control": {"max-age=60, private"}}, nil, false},
+		{"vary all", http.Header{"Vary": {"*"}}, nil, false},
+		{"authorization", http.Header{}, []string{"Authorization", "Bearer x"}, false},
+		{"request no-store", http.Header{}, []string{"Cache-Control", "no-store"}, false},
+	}
+	for _, test := range tests {
+		srv := &fakeServer{header: test.header}
+		ct := cacheTransport{base: srv, dir: t.TempDir()}
+		for i := 0; i < 2; i++ {
+			if _, err := get(t, ct, test.req...); err != nil {
+				t.Fatalf("%s: %v", test.name, err)
+			}
+		}
+		if srv.revalidated != test.want {
+			t.Errorf("%s: stored %v, want %v", test.name, srv.revalidated, test.want)
+		}
+	}
+}
+
+func TestCacheVary(t *testing.T) {
+	srv := &fakeServer{header: http.Header{"Vary": {"Accept"}}}
+	ct := cacheTransport{base: srv, dir: t.TempDir()}
+	for _, accept := range []string{"text/plain", "application/json", "text/plain", "application/json"} {
+		body, err := get(t, ct, "Accept", accept)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := "body " + accept; body != want {
+			t.Errorf("accept %s: body %q, want %q", accept, body, want)
+		}
+	}
+	if srv.notModified != 2 {
+		t.Errorf("got %d cached responses, want 2", srv.notModified)
+	}
+}
+
+func TestCacheStaleIfError(t *testing.T) {
+	defer SetClock(nil)
+	netErr := errors.New("network is unreachable")
+
+	tests := []struct {
+		name    string
+		control string
+		age     time.Duration
+		err     error
+		want    bool
+	}{
+		{"without stale-if-error", "max-age=60", 0, netErr, false},
+		{"stale-if-error", "max-age=60, stale-if-error=60", 90 * time.Second, netErr, true},
+		{"too old", "max-age=60, stale-if-error=60", 3 * time.Minute, netErr, false},
+		{"no-cache", "no-cache, stale-if-error=60", 0, netErr, false},
+		{"must-revalidate", "must-revalidate, stale-if-error=60", 0, netErr, false},
+		{"offline", "", time.Hour, ErrOffline, true},
+	}
+	for _, test := range tests {
+		clock := NewFakeClock(time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC))
+		SetClock(clock)
+
+		srv := &fakeServer{header: http.Header{"Cache-Control": {test.control}}}
+		ct := cacheTransport{base: srv, dir: t.TempDir()}
+		if _, err := get(t, ct); err != nil {
+			t.Fatalf("%s: %v", test.name, err)
+		}
+
+		clock.Advance(test.age)
+		srv.err = test.err
+		body, err := get(t, ct)
+		if got := err == nil; got != test.want {
+			t.Errorf("%s: cached response used %v, want %v", test.name, got, test.want)
+		}
+		if err == nil && body != "body " {
+			t.Errorf("%s: body %q", test.name, body)
+		}
+	}
+}
@@ -0,0 +1,130 @@
+// Copyright (c) 2015, J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD-style license that can be found in the LICENSE file.
+
+package cmdapp
+
+import (
+	"os"
+	"strings"
+	"sync"
+)
+
+// A Message is the translated help of a command
+// or help topic.
+// Empty fields use the original text.
+type Message struct {
+	Short string
+	Long  string
+}
+
+// A Catalog is a set of translated help messages
+// for a locale,
+// keyed by command name.
+type Catalog map[string]Message
+
+// catalogs are the message catalogs
+// keyed by locale.
+var (
+	catMutex sync.Mutex
+	catalogs = make(map[string]Catalog)
+)
+
+// AddCatalog adds a message catalog for a locale.
+// The locale is a language tag,
+// such as "es" or "pt-BR".
+// If a catalog for the locale already exists,
+// the messages are merged.
+func AddCatalog(locale string, c Catalog) {
+	locale = normLocale(locale)
+	catMutex.Lock()
+	defer catMutex.Unlock()
+	cat, ok := catalogs[locale]
+	if !ok {
+		cat = make(Catalog)
+		catalogs[locale] = cat
+	}
+	for nm, m := range c {
+		cat[normName(nm)] = m
+	}
+}
+
+// Locale is the locale used for help messages.
+// If empty,
+// it is taken from the LC_ALL, LC_MESSAGES, or LANG
+// environment variables.
+var Locale string
+
+// normLocale returns a locale in lower case,
+// without encoding,
+// and using '-' as separator.
+func normLocale(l string) string {
+	if i := strings.IndexAny(l, ".@"); i >= 0 {
+		l = l[:i]
+	}
+	return strings.ToLower(strings.Replace(l, "_", "-", -1))
+}
+
+// userLocales returns the locales
+// preferred by the user,
+// from the most specific to the most general.
+func userLocales() []string {
+	l := Locale
+	if l == "" {
+		for _, v := range []string{"LC_ALL", "LC_MESSAGES", "LANG"} {
+			if l = os.Getenv(v); l != "" {
+				break
+			}
+		}
+	}
+	l = normLocale(l)
+	if l == "" || l == "c" || l == "posix" {
+		return nil
+	}
+	locs := []string{l}
+	for {
+		i := strings.LastIndex(l, "-")
+		if i < 0 {
+			break
+		}
+		l = l[:i]
+		locs = append(locs, l)
+	}
+	return locs
+}
+
+// message returns the translated message of a command
+// for the user locale.
+func message(c Command) Message {
+	locs := userLocales()
+	if len(locs) == 0 {
+		return Message{}
+	}
+	nm := normName(c.Name())
+	catMutex.Lock()
+	defer catMutex.Unlock()
+	for _, l := range locs {
+		if m, ok := catalogs[l][nm]; ok {
+			return m
+		}
+	}
+	return Message{}
+}
+
+// shortText returns the short description of a command
+// in the user locale.
+func shortText(c Command) string {
+	if m := message(c); m.Short != "" {
+		return m.Short
+	}
+	return c.Short()
+}
+
+// longText returns the long description of a command
+// in the user locale.
+func longText(c Command) string {
+	if m := message(c); m.Long != "" {
+		return m.Long
+	}
+	return c.Long()
+}
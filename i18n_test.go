@@ -0,0 +1,99 @@
+// Copyright (c) 2015, J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD-style license that can be found in the LICENSE file.
+
+package cmdapp
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestUserLocales(t *testing.T) {
+	defer func(l string) { Locale = l }(Locale)
+
+	tests := []struct {
+		locale string
+		env    map[string]string
+		want   string
+	}{
+		{locale: "es", want: "es"},
+		{locale: "pt_BR.UTF-8", want: "pt-br pt"},
+		{locale: "sr_RS@latin", want: "sr-rs sr"},
+		{locale: "zh-Hant-TW", want: "zh-hant-tw zh-hant zh"},
+		{env: map[string]string{"LANG": "fr_CA.UTF-8"}, want: "fr-ca fr"},
+		{env: map[string]string{"LC_MESSAGES": "de_DE", "LANG": "fr_CA"}, want: "de-de de"},
+		{env: map[string]string{"LC_ALL": "it", "LC_MESSAGES": "de_DE", "LANG": "fr_CA"}, want: "it"},
+		{locale: "es", env: map[string]string{"LC_ALL": "it"}, want: "es"},
+		{env: map[string]string{"LANG": "C.UTF-8"}},
+		{env: map[string]string{"LANG": "POSIX"}},
+		{},
+	}
+	for _, test := range tests {
+		Locale = test.locale
+		for _, v := range []string{"LC_ALL", "LC_MESSAGES", "LANG"} {
+			t.Setenv(v, test.env[v])
+		}
+		if got := strings.Join(userLocales(), " "); got != test.want {
+			t.Errorf("locale %q, env %v: got %q, want %q", test.locale, test.env, got, test.want)
+		}
+	}
+}
+
+func TestMessage(t *testing.T) {
+	defer func(l string) { Locale = l }(Locale)
+	catMutex.Lock()
+	prev := catalogs
+	catalogs = make(map[string]Catalog)
+	catMutex.Unlock()
+	defer func() {
+		catMutex.Lock()
+		catalogs = prev
+		catMutex.Unlock()
+	}()
+
+	AddCatalog("es", Catalog{"Greet": {Short: "saluda", Long: "Saluda al usuario."}})
+	AddCatalog("pt", Catalog{"greet": {Short: "cumprimenta"}})
+	AddCatalog("pt_BR", Catalog{"greet": {Short: "dá oi"}})
+	AddCatalog("es", Catalog{"other": {Short: "otro"}})
+
+	c := &mountCmd{name: "greet"}
+	tests := []struct {
+		locale string
+		short  string
+		long   string
+	}{
+		{locale: "es_AR.UTF-8", short: "saluda", long: "Saluda al usuario."},
+		{locale: "pt-BR", short: "dá oi", long: c.Long()},
+		{locale: "pt-PT", short: "cumprimenta", long: c.Long()},
+		{locale: "fr", short: c.Short(), long: c.Long()},
+		{locale: "C", short: c.Short(), long: c.Long()},
+	}
+	for _, test := range tests {
+		Locale = test.locale
+		if got := shortText(c); got != test.short {
+			t.Errorf("%s: short %q, want %q", test.locale, got, test.short)
+		}
+		if got := longText(c); got != test.long {
+			t.Errorf("%s: long %q, want %q", test.locale, got, test.long)
+		}
+	}
+	if _, ok := catalogs["es"]["other"]; !ok {
+		t.Errorf("catalogs not merged")
+	}
+
+	// the help uses the translations
+	Locale = "es"
+	var out bytes.Buffer
+	a := NewApp("i18napp", "a test application")
+	a.Stdout, a.Stderr = &out, io.Discard
+	a.Add(&mountCmd{name: "greet", run: func(c *mountCmd, args []string) error { return nil }})
+	if code := a.Dispatch([]string{"help", "greet"}); code != 0 {
+		t.Fatalf("help greet: exit code %d", code)
+	}
+	if !strings.Contains(out.String(), "Saluda al usuario.") {
+		t.Errorf("help greet: output without the translation:\n%s", out.String())
+	}
+}
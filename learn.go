@@ -0,0 +1,287 @@
+// Copyright (c) 2015, J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD-style license that can be found in the LICENSE file.
+
+package cmdapp
+
+import (
+	"bufio"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// A Lesson is an interactive tutorial
+// shown with the learn command.
+type Lesson struct {
+	// Name is the name used to start the lesson.
+	Name string
+
+	// Title is the title of the lesson.
+	Title string
+
+	// Steps are the steps of the lesson.
+	Steps []Step
+}
+
+// A Step is a step of a lesson.
+type Step struct {
+	// Text is the explanation of the step.
+	Text string
+
+	// Command is the command line,
+	// without the application name,
+	// that the user should run.
+	// If empty,
+	// the step only shows the text.
+	Command string
+
+	// Check validates the output of the command
+	// given by the user.
+	// If nil,
+	// the command given by the user
+	// must be the same as Command.
+	Check func(output string) error
+}
+
+// lessons are the registered lessons.
+var (
+	lessonMutex sync.Mutex
+	lessons     []Lesson
+	lessonOnce  sync.Once
+)
+
+// AddLesson adds lessons to the application.
+// The learn command is added
+// the first time a lesson is added.
+func AddLesson(ls ...Lesson) {
+	lessonOnce.Do(func() { addBuiltin(&learnCmd{}) })
+	lessonMutex.Lock()
+	defer lessonMutex.Unlock()
+	lessons = append(lessons, ls...)
+}
+
+// findLesson returns a lesson by its name.
+func findLesson(name string) (Lesson, bool) {
+	lessonMutex.Lock()
+	defer lessonMutex.Unlock()
+	for _, l := range lessons {
+		if normName(l.Name) == normName(name) {
+			return l, true
+		}
+	}
+	return Lesson{}, false
+}
+
+// learnCmd is the learn command.
+type learnCmd struct {
+	reset bool
+}
+
+const learnLong = `
+Command learn runs the interactive lessons of the application. Without
+arguments, it lists the lessons and the progress of the user.
+
+In each step of a lesson, the command to run is shown, and it must be
+typed (with or without the application name). Type 'hint' to see the
+command again, 'skip' to skip the step, or 'quit' to stop the lesson.
+The progress is saved, so a lesson can be continued later.
+
+The flags are:
+
+    -reset
+      Starts the lesson from the beginning.
+`
+
+func (l *learnCmd) Name() string   { return "learn" }
+func (l *learnCmd) Args() string   { return "[-reset] [<lesson>]" }
+func (l *learnCmd) Short() string  { return "runs the interactive lessons" }
+func (l *learnCmd) Long() string   { return learnLong }
+func (l *learnCmd) Runnable() bool { return true }
+
+func (l *learnCmd) Register(fs *flag.FlagSet) {
+	fs.BoolVar(&l.reset, "reset", false, "start the lesson from the beginning")
+}
+
+func (l *learnCmd) Run(args []string) error {
+	prog, err := readLearnProgress()
+	if err != nil {
+		return err
+	}
+	if len(args) == 0 {
+		lessonMutex.Lock()
+		defer lessonMutex.Unlock()
+		for _, ls := range lessons {
+			fmt.Fprintf(Output(), "    %-16s %s (%d/%d)\n", ls.Name, ls.Title, prog[normName(ls.Name)], len(ls.Steps))
+		}
+		return nil
+	}
+	if len(args) > 1 {
+		return errors.New("too many arguments")
+	}
+	ls, ok := findLesson(args[0])
+	if !ok {
+		return errors.Errorf("unknown lesson %s", args[0])
+	}
+	key := normName(ls.Name)
+	if l.reset || prog[key] >= len(ls.Steps) {
+		prog[key] = 0
+	}
+
+	in := bufio.NewReader(os.Stdin)
+	fmt.Fprintf(Output(), "%s\n\n", ls.Title)
+	for prog[key] < len(ls.Steps) {
+		i := prog[key]
+		done, err := runStep(in, ls.Steps[i], i, len(ls.Steps))
+		if err != nil {
+			return err
+		}
+		if !done {
+			return writeLearnProgress(prog)
+		}
+		prog[key]++
+		if err := writeLearnProgress(prog); err != nil {
+			return err
+		}
+	}
+	fmt.Fprintln(Output(), Mark(Success, fmt.Sprintf("lesson %s completed", ls.Name)))
+	return nil
+}
+
+// runStep runs a step of a lesson.
+// It returns false if the user quits the lesson.
+func runStep(in *bufio.Reader, st Step, i, n int) (bool, error) {
+	fmt.Fprintf(Output(), "Step %d of %d\n\n%s\n\n", i+1, n, strings.TrimSpace(st.Text))
+	if st.Command == "" {
+		fmt.Fprint(Output(), "Press Enter to continue ")
+		Flush()
+		_, err := in.ReadString('\n')
+		fmt.Fprintln(Output())
+		return err == nil, nil
+	}
+	fmt.Fprintf(Output(), "Run:\n\n    %s %s\n\n", appName(), st.Command)
+	for {
+		fmt.Fprintf(Output(), "%s> ", appName())
+		Flush()
+		line, err := in.ReadString('\n')
+		if err == io.EOF && line == "" {
+			fmt.Fprintln(Output())
+			return false, nil
+		}
+		if err != nil && err != io.EOF {
+			return false, err
+		}
+		line = strings.TrimSpace(line)
+		switch line {
+		case "":
+			continue
+		case "quit":
+			return false, nil
+		case "hint":
+			fmt.Fprintf(Output(), "    %s %s\n", appName(), st.Command)
+			continue
+		case "skip":
+			fmt.Fprintln(Output())
+			return true, nil
+		}
+		if err := tryStep(st, line); err != nil {
+			fmt.Fprintln(Output(), Mark(Failure, err.Error()))
+			continue
+		}
+		fmt.Fprintf(Output(), "%s\n\n", Mark(Success, "well done"))
+		return true, nil
+	}
+}
+
+// tryStep runs the command given by the user in a step,
+// and validates it.
+func tryStep(st Step, line string) error {
+	words, err := splitWords(line)
+	if err != nil {
+		return err
+	}
+	if len(words) > 0 && strings.TrimSuffix(filepath.Base(words[0]), ".exe") == strings.TrimSuffix(appName(), ".exe") {
+		words = words[1:]
+	}
+	if len(words) == 0 {
+		return errors.New("expecting a command")
+	}
+	if st.Check == nil {
+		want, err := splitWords(st.Command)
+		if err != nil {
+			return err
+		}
+		if strings.Join(words, "\x00") != strings.Join(want, "\x00") {
+			return errors.Errorf("expecting '%s', type 'hint' to see it again", st.Command)
+		}
+	}
+	c, ok := lookup(words[0])
+	if !ok || !c.Runnable() {
+		return errors.Errorf("unknown command %s", words[0])
+	}
+	if _, ok := c.(*learnCmd); ok {
+		return errors.New("learn can not run itself")
+	}
+
+	out, err := captureOutput(func() error {
+		return invoke(c, words[1:])
+	})
+	Output().Write(out)
+	if err != nil {
+		return err
+	}
+	if st.Check != nil {
+		return st.Check(string(out))
+	}
+	return nil
+}
+
+// learnFile returns the file of the progress of the lessons.
+func learnFile() (string, error) {
+	dir, err := DataDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "learn.json"), nil
+}
+
+// readLearnProgress reads the number of completed steps
+// of each lesson.
+func readLearnProgress() (map[string]int, error) {
+	prog := make(map[string]int)
+	name, err := learnFile()
+	if err != nil {
+		return nil, err
+	}
+	b, err := os.ReadFile(name)
+	if os.IsNotExist(err) {
+		return prog, nil
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "learn")
+	}
+	if err := json.Unmarshal(b, &prog); err != nil {
+		return nil, errors.Wrapf(err, "learn: %s", name)
+	}
+	return prog, nil
+}
+
+// writeLearnProgress writes the progress of the lessons.
+func writeLearnProgress(prog map[string]int) error {
+	name, err := learnFile()
+	if err != nil {
+		return err
+	}
+	b, err := json.MarshalIndent(prog, "", "\t")
+	if err != nil {
+		return errors.Wrap(err, "learn")
+	}
+	return errors.Wrap(WriteFileAtomic(name, b, SharedMode), "learn")
+}
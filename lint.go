@@ -0,0 +1,91 @@
+// Copyright (c) 2015, J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD-style license that can be found in the LICENSE file.
+
+package cmdapp
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// A Problem is an issue
+// found in the commands of the application.
+type Problem struct {
+	// Command is the name of the command
+	// or alias with the issue.
+	Command string
+
+	// Message describes the issue.
+	Message string
+}
+
+func (p Problem) String() string {
+	return fmt.Sprintf("%s: %s", p.Command, p.Message)
+}
+
+// Lint inspects the commands of the application
+// and returns the issues found,
+// so they can be checked in tests,
+// for example:
+//
+//	func TestCommands(t *testing.T) {
+//		for _, p := range cmdapp.Lint() {
+//			t.Error(p)
+//		}
+//	}
+//
+// Commands of the framework are not inspected.
+// Lint reports runnable commands without examples,
+// flags without usage text,
+// references in SeeAlso to unknown commands or help topics,
+// aliases that duplicate a command name
+// or expand to an unknown command,
+// and commands that break the policy of the application.
+func Lint() []Problem {
+	var probs []Problem
+	for _, c := range sortedCommands() {
+		if isBuiltin(c) {
+			continue
+		}
+		nm := c.Name()
+		if c.Runnable() {
+			if e, ok := c.(Exampler); !ok || len(e.Examples()) == 0 {
+				probs = append(probs, Problem{nm, "without examples"})
+			}
+			for _, f := range commandFlags(c) {
+				if strings.TrimSpace(f.Usage) == "" {
+					probs = append(probs, Problem{nm, fmt.Sprintf("flag -%s without usage text", f.Name)})
+				}
+			}
+		}
+		if s, ok := c.(SeeAlsoCommand); ok {
+			for _, ref := range s.SeeAlso() {
+				if _, ok := lookup(ref); !ok {
+					probs = append(probs, Problem{nm, fmt.Sprintf("unknown command or help topic %q in see also", ref)})
+				}
+			}
+		}
+		if err := checkPolicy(c); err != nil {
+			probs = append(probs, Problem{nm, strings.TrimPrefix(err.Error(), "command "+nm+": ")})
+		}
+	}
+
+	al := aliases()
+	names := make([]string, 0, len(al))
+	for a := range al {
+		names = append(names, a)
+	}
+	sort.Strings(names)
+	for _, a := range names {
+		if _, ok := lookup(a); ok {
+			probs = append(probs, Problem{a, "alias duplicates a command name"})
+			continue
+		}
+		if _, err := checkAlias(a, al[a]); err != nil {
+			probs = append(probs, Problem{a, strings.TrimPrefix(err.Error(), "alias "+a+": ")})
+		}
+	}
+	return probs
+}
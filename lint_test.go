@@ -0,0 +1,133 @@
+// Copyright (c) 2015, J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD-style license that can be found in the LICENSE file.
+
+package cmdapp
+
+import (
+	"flag"
+	"io"
+	"reflect"
+	"testing"
+)
+
+// lintCmd is a command
+// with configurable help.
+type lintCmd struct {
+	name     string
+	usage    string
+	examples []Example
+	seeAlso  []string
+	run      func()
+}
+
+func (c *lintCmd) Name() string  { return c.name }
+func (c *lintCmd) Args() string  { return "[-v]" }
+func (c *lintCmd) Short() string { return "a lint test" }
+func (c *lintCmd) Long() string  { return "A command to test the lint." }
+func (c *lintCmd) Register(fs *flag.FlagSet) {
+	fs.Bool("v", false, c.usage)
+}
+func (c *lintCmd) Runnable() bool      { return true }
+func (c *lintCmd) Examples() []Example { return c.examples }
+func (c *lintCmd) SeeAlso() []string   { return c.seeAlso }
+func (c *lintCmd) Run(args []string) error {
+	if c.run != nil {
+		c.run()
+	}
+	return nil
+}
+
+func TestLint(t *testing.T) {
+	cfgMutex.Lock()
+	oldCfg := cfgValues
+	cfgMutex.Unlock()
+	defer func(p *Policy) {
+		cfgMutex.Lock()
+		cfgValues = oldCfg
+		cfgMutex.Unlock()
+		policyMutex.Lock()
+		policy = p
+		policyMutex.Unlock()
+	}(policy)
+
+	tests := []struct {
+		name    string
+		policy  *Policy
+		aliases map[string]string
+		want    []string
+	}{
+		{
+			name: "commands",
+			want: []string{
+				"bad: without examples",
+				"bad: flag -v without usage text",
+				`bad: unknown command or help topic "missing" in see also`,
+			},
+		},
+		{
+			name: "aliases",
+			aliases: map[string]string{
+				"alias.b":    "bad -v",
+				"alias.good": "bad",
+				"alias.x":    "missing -v",
+				"alias.q":    `bad "x`,
+			},
+			want: []string{
+				"bad: without examples",
+				"bad: flag -v without usage text",
+				`bad: unknown command or help topic "missing" in see also`,
+				"good: alias duplicates a command name",
+				"q: unterminated quote",
+				"x: unknown command missing",
+			},
+		},
+		{
+			name:   "policy",
+			policy: &Policy{RequireGroup: true},
+			want: []string{
+				"bad: without examples",
+				"bad: flag -v without usage text",
+				`bad: unknown command or help topic "missing" in see also`,
+				"bad: without group",
+				"good: without group",
+			},
+		},
+	}
+	for _, test := range tests {
+		cfgMutex.Lock()
+		cfgValues = make(map[string]string)
+		for k, v := range test.aliases {
+			cfgValues[k] = v
+		}
+		cfgMutex.Unlock()
+
+		var got []string
+		a := NewApp("lintapp", "a test application")
+		a.Stdout, a.Stderr = io.Discard, io.Discard
+		a.Add(&lintCmd{
+			name:     "good",
+			usage:    "verbose output",
+			examples: []Example{{Desc: "a good example", Args: "-v"}},
+			seeAlso:  []string{"bad", "help"},
+			run: func() {
+				for _, p := range Lint() {
+					got = append(got, p.String())
+				}
+			},
+		})
+		a.Add(&lintCmd{name: "bad", seeAlso: []string{"missing"}})
+
+		// the policy is set after adding the commands,
+		// as Add panics with a command that breaks it
+		policyMutex.Lock()
+		policy = test.policy
+		policyMutex.Unlock()
+		if code := a.Dispatch([]string{"good"}); code != 0 {
+			t.Fatalf("%s: exit code %d", test.name, code)
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%s: got %q, want %q", test.name, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,152 @@
+// Copyright (c) 2015, J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD-style license that can be found in the LICENSE file.
+
+package cmdapp
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// An ExclusiveCommand is a command
+// that requires the application lock,
+// so it is never run concurrently
+// with other exclusive commands of the application.
+type ExclusiveCommand interface {
+	Command
+
+	// Exclusive reports whether the command
+	// requires the application lock.
+	Exclusive() bool
+}
+
+// LockFile is the path of the application lock file.
+// If empty,
+// the file 'lock' in the cache directory
+// (see CacheDir)
+// is used,
+// so the lock is private to the user.
+var LockFile string
+
+// A LockError is the error returned
+// when the application lock is held by other process.
+type LockError struct {
+	PID  int
+	File string
+}
+
+func (e *LockError) Error() string {
+	return fmt.Sprintf("application locked by process %d (lock file %s)", e.PID, e.File)
+}
+
+// lockPath returns the path of the application lock file.
+func lockPath() (string, error) {
+	if LockFile != "" {
+		return LockFile, nil
+	}
+	dir, err := CacheDir()
+	if err != nil {
+		return "", err
+	}
+	if err := MkdirAllMode(dir, SecretDirMode); err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "lock"), nil
+}
+
+// Lock acquires the application lock,
+// and returns a function that releases it.
+// If the lock is held by other process
+// it returns a *LockError.
+//
+// The lock is a lock of the operating system
+// on the lock file,
+// so it is released when the process finishes,
+// even if the process is killed.
+// The lock file keeps the PID
+// of the process that holds the lock.
+// In systems without file locks,
+// as WASM,
+// the lock is held in memory.
+func Lock() (unlock func(), err error) {
+	if !fileLocks {
+		return memLock()
+	}
+	name, err := lockPath()
+	if err != nil {
+		return nil, errors.Wrap(err, "lock")
+	}
+
+	// the lock is also held in memory,
+	// as in some systems (fcntl locks)
+	// the file lock is held by the process,
+	// and it is released
+	// when the process closes any file
+	// of the lock file
+	memUnlock, err := memLock()
+	if err != nil {
+		return nil, &LockError{PID: os.Getpid(), File: name}
+	}
+	f, err := os.OpenFile(name, os.O_CREATE|os.O_RDWR, SecretMode)
+	if err != nil {
+		memUnlock()
+		return nil, errors.Wrap(err, "lock")
+	}
+	ok, err := lockFD(f, false)
+	if err != nil {
+		f.Close()
+		memUnlock()
+		return nil, errors.Wrap(err, "lock")
+	}
+	if !ok {
+		b, _ := io.ReadAll(f)
+		f.Close()
+		memUnlock()
+		pid, _ := strconv.Atoi(strings.TrimSpace(string(b)))
+		return nil, &LockError{PID: pid, File: name}
+	}
+
+	// the file is never removed,
+	// as other process might have it open
+	// waiting for the lock
+	f.Truncate(0)
+	f.WriteAt([]byte(fmt.Sprintf("%d\n", os.Getpid())), 0)
+	return func() {
+		f.Truncate(0)
+		unlockFD(f)
+		f.Close()
+		memUnlock()
+	}, nil
+}
+
+// memLocked is set when the in-memory lock is held.
+var (
+	memMutex  sync.Mutex
+	memLocked bool
+)
+
+// memLock acquires the application lock in memory,
+// used in platforms without processes or file system,
+// and to exclude the goroutines of the process
+// in the other platforms.
+func memLock() (func(), error) {
+	memMutex.Lock()
+	defer memMutex.Unlock()
+	if memLocked {
+		return nil, &LockError{PID: os.Getpid()}
+	}
+	memLocked = true
+	return func() {
+		memMutex.Lock()
+		memLocked = false
+		memMutex.Unlock()
+	}, nil
+}
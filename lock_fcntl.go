@@ -0,0 +1,52 @@
+// Copyright (c) 2015, J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD-style license that can be found in the LICENSE file.
+
+//go:build aix || solaris
+
+package cmdapp
+
+import (
+	"os"
+	"syscall"
+)
+
+// fileLocks is set if the application lock
+// is a lock file.
+const fileLocks = true
+
+// lockFD acquires an exclusive lock on a file,
+// that is released when the file is closed,
+// or the process finishes.
+// If wait is false
+// and the lock is held by other process,
+// it returns false.
+//
+// In these systems there is no flock,
+// so it uses fcntl locks,
+// that are held by the process,
+// the callers must exclude the goroutines of the process.
+func lockFD(f *os.File, wait bool) (bool, error) {
+	cmd := syscall.F_SETLKW
+	if !wait {
+		cmd = syscall.F_SETLK
+	}
+	lk := &syscall.Flock_t{Type: syscall.F_WRLCK}
+	for {
+		err := syscall.FcntlFlock(f.Fd(), cmd, lk)
+		switch err {
+		case nil:
+			return true, nil
+		case syscall.EINTR:
+			continue
+		case syscall.EAGAIN, syscall.EACCES:
+			return false, nil
+		}
+		return false, err
+	}
+}
+
+// unlockFD releases the lock on a file.
+func unlockFD(f *os.File) error {
+	return syscall.FcntlFlock(f.Fd(), syscall.F_SETLK, &syscall.Flock_t{Type: syscall.F_UNLCK})
+}
@@ -0,0 +1,28 @@
+// Copyright (c) 2015, J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD-style license that can be found in the LICENSE file.
+
+//go:build !unix && !windows
+
+package cmdapp
+
+import "os"
+
+// In systems without file locks
+// (as WASM,
+// where there are no other processes
+// and the file system might be unavailable)
+// the application lock is held in memory.
+const fileLocks = false
+
+// lockFD does nothing,
+// as there are no file locks.
+func lockFD(f *os.File, wait bool) (bool, error) {
+	return true, nil
+}
+
+// unlockFD does nothing,
+// as there are no file locks.
+func unlockFD(f *os.File) error {
+	return nil
+}
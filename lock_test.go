@@ -0,0 +1,60 @@
+// Copyright (c) 2015, J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD-style license that can be found in the LICENSE file.
+
+package cmdapp
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/pkg/errors"
+)
+
+func TestLock(t *testing.T) {
+	if !fileLocks {
+		t.Skip("no file locks")
+	}
+	defer func(f string) { LockFile = f }(LockFile)
+
+	tests := []struct {
+		name string
+		prev string
+	}{
+		{"new", ""},
+		{"left by a finished process", "999999999\n"},
+		{"invalid content", "not a pid"},
+	}
+	for _, test := range tests {
+		LockFile = filepath.Join(t.TempDir(), "lock")
+		if test.prev != "" {
+			if err := os.WriteFile(LockFile, []byte(test.prev), 0600); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		unlock, err := Lock()
+		if err != nil {
+			t.Fatalf("%s: %v", test.name, err)
+		}
+		if _, err := Lock(); err == nil {
+			t.Errorf("%s: lock acquired twice", test.name)
+		} else if le, ok := errors.Cause(err).(*LockError); !ok || le.PID != os.Getpid() {
+			t.Errorf("%s: got error %v, want a lock error with PID %d", test.name, err, os.Getpid())
+		}
+		if fi, err := os.Stat(LockFile); err != nil {
+			t.Errorf("%s: %v", test.name, err)
+		} else if runtime.GOOS != "windows" && fi.Mode().Perm() != SecretMode {
+			t.Errorf("%s: lock file mode %v, want %v", test.name, fi.Mode().Perm(), SecretMode)
+		}
+		unlock()
+
+		unlock, err = Lock()
+		if err != nil {
+			t.Fatalf("%s: after unlock: %v", test.name, err)
+		}
+		unlock()
+	}
+}
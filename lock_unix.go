@@ -0,0 +1,46 @@
+// Copyright (c) 2015, J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD-style license that can be found in the LICENSE file.
+
+//go:build darwin || dragonfly || freebsd || linux || netbsd || openbsd
+
+package cmdapp
+
+import (
+	"os"
+	"syscall"
+)
+
+// fileLocks is set if the application lock
+// is a lock file.
+const fileLocks = true
+
+// lockFD acquires an exclusive lock on a file,
+// that is released when the file is closed,
+// or the process finishes.
+// If wait is false
+// and the lock is held by other process,
+// it returns false.
+func lockFD(f *os.File, wait bool) (bool, error) {
+	how := syscall.LOCK_EX
+	if !wait {
+		how |= syscall.LOCK_NB
+	}
+	for {
+		err := syscall.Flock(int(f.Fd()), how)
+		switch err {
+		case nil:
+			return true, nil
+		case syscall.EINTR:
+			continue
+		case syscall.EWOULDBLOCK:
+			return false, nil
+		}
+		return false, err
+	}
+}
+
+// unlockFD releases the lock on a file.
+func unlockFD(f *os.File) error {
+	return syscall.Flock(int(f.Fd()), syscall.LOCK_UN)
+}
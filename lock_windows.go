@@ -0,0 +1,64 @@
+// Copyright (c) 2015, J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD-style license that can be found in the LICENSE file.
+
+package cmdapp
+
+import (
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+// fileLocks is set if the application lock
+// is a lock file.
+const fileLocks = true
+
+var (
+	procLockFileEx   = syscall.NewLazyDLL("kernel32.dll").NewProc("LockFileEx")
+	procUnlockFileEx = syscall.NewLazyDLL("kernel32.dll").NewProc("UnlockFileEx")
+)
+
+const (
+	lockfileFailImmediately = 0x1
+	lockfileExclusiveLock   = 0x2
+	errorLockViolation      = syscall.Errno(33)
+
+	// lockOffset is the high part of the offset
+	// of the locked byte,
+	// beyond the content of the file,
+	// so other processes can read the file.
+	lockOffset = 0x7fffffff
+)
+
+// lockFD acquires an exclusive lock on a file,
+// that is released when the file is closed,
+// or the process finishes.
+// If wait is false
+// and the lock is held by other process,
+// it returns false.
+func lockFD(f *os.File, wait bool) (bool, error) {
+	flags := uintptr(lockfileExclusiveLock)
+	if !wait {
+		flags |= lockfileFailImmediately
+	}
+	ol := &syscall.Overlapped{OffsetHigh: lockOffset}
+	r, _, err := procLockFileEx.Call(f.Fd(), flags, 0, 1, 0, uintptr(unsafe.Pointer(ol)))
+	if r != 0 {
+		return true, nil
+	}
+	if err == errorLockViolation {
+		return false, nil
+	}
+	return false, err
+}
+
+// unlockFD releases the lock on a file.
+func unlockFD(f *os.File) error {
+	ol := &syscall.Overlapped{OffsetHigh: lockOffset}
+	r, _, err := procUnlockFileEx.Call(f.Fd(), 0, 1, 0, uintptr(unsafe.Pointer(ol)))
+	if r == 0 {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,113 @@
+// Copyright (c) 2015, J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD-style license that can be found in the LICENSE file.
+
+package cmdapp
+
+import (
+	"context"
+	"flag"
+	"io"
+	"log/slog"
+)
+
+// ConsoleLevel is the minimum level
+// of the log messages printed in the console.
+var ConsoleLevel = new(slog.LevelVar)
+
+func init() {
+	ConsoleLevel.Set(slog.LevelWarn)
+}
+
+// logger is the logger of the application.
+var logger = slog.New(slog.NewTextHandler(stderr{}, &slog.HandlerOptions{Level: ConsoleLevel}))
+
+// logFile is the name of the file
+// in which the log is written,
+// set with the -log-file flag.
+var logFile string
+
+// Logger returns the logger of the application,
+// used by the framework and that should be used by commands.
+//
+// Messages are printed in the console,
+// and if the -log-file flag is set,
+// all messages are written as JSON lines in the log file.
+func Logger() *slog.Logger {
+	return logger
+}
+
+// registerLogFlags sets the log flags
+// of the application.
+func registerLogFlags(fs *flag.FlagSet) {
+	fs.StringVar(&logFile, "log-file", "", "write the log, in JSON lines, to the given file")
+	fs.BoolFunc("debug", "print debug messages in the console", func(s string) error {
+		if s == "true" {
+			ConsoleLevel.Set(slog.LevelDebug)
+		}
+		return nil
+	})
+}
+
+// openLog opens the log file
+// and adds it to the logger.
+// The log file is rotated following LogRotation,
+// and the LogConfig keys.
+func openLog() (io.Closer, error) {
+	if logFile == "" {
+		return nil, nil
+	}
+	rot, err := logRotation()
+	if err != nil {
+		return nil, err
+	}
+	f, err := openRotFile(logFile, rot)
+	if err != nil {
+		return nil, err
+	}
+	jh := slog.NewJSONHandler(f, &slog.HandlerOptions{Level: slog.LevelDebug})
+	logger = slog.New(teeHandler{logger.Handler(), jh})
+	return f, nil
+}
+
+// teeHandler is a log handler
+// that sends log records to multiple handlers.
+type teeHandler []slog.Handler
+
+func (t teeHandler) Enabled(ctx context.Context, l slog.Level) bool {
+	for _, h := range t {
+		if h.Enabled(ctx, l) {
+			return true
+		}
+	}
+	return false
+}
+
+func (t teeHandler) Handle(ctx context.Context, r slog.Record) error {
+	var err error
+	for _, h := range t {
+		if !h.Enabled(ctx, r.Level) {
+			continue
+		}
+		if e := h.Handle(ctx, r.Clone()); e != nil && err == nil {
+			err = e
+		}
+	}
+	return err
+}
+
+func (t teeHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	n := make(teeHandler, 0, len(t))
+	for _, h := range t {
+		n = append(n, h.WithAttrs(attrs))
+	}
+	return n
+}
+
+func (t teeHandler) WithGroup(name string) slog.Handler {
+	n := make(teeHandler, 0, len(t))
+	for _, h := range t {
+		n = append(n, h.WithGroup(name))
+	}
+	return n
+}
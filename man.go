@@ -0,0 +1,87 @@
+// Copyright (c) 2015, J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD-style license that can be found in the LICENSE file.
+
+package cmdapp
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// WriteMan writes a manual page (in roff format)
+// of the application and its commands.
+func WriteMan(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	name := appName()
+	fmt.Fprintf(bw, ".TH %s 1\n", roff(strings.ToUpper(name)))
+	fmt.Fprintf(bw, ".SH NAME\n%s \\- %s\n", roff(name), roff(Short))
+	fmt.Fprintf(bw, ".SH SYNOPSIS\n.B %s\n[help] <command> [<args>...]\n", roff(name))
+
+	fmt.Fprintf(bw, ".SH COMMANDS\n")
+	var topics []Command
+	walkCommands(func(path string, c Command, parents []*Group) {
+		if !c.Runnable() {
+			topics = append(topics, c)
+			return
+		}
+		fmt.Fprintf(bw, ".SS \"%s %s %s\"\n", roff(name), roff(path), roff(c.Args()))
+		fmt.Fprintf(bw, "%s\n", roff(capitalize(shortText(c))))
+		manText(bw, longText(c))
+		for _, f := range pathFlags(c, parents) {
+			fmt.Fprintf(bw, ".TP\n.B \\-%s\n%s\n", roff(f.Name), roff(f.Usage))
+		}
+		for _, v := range commandEnv(c) {
+			fmt.Fprintf(bw, ".TP\n.B %s\n%s\n", roff(v.Name), roff(capitalize(envDesc(v))))
+		}
+		if _, ok := c.(ExitStatusCommand); ok {
+			fmt.Fprintf(bw, ".PP\nExit status:\n")
+			for _, s := range exitStatus(c) {
+				fmt.Fprintf(bw, ".TP\n.B %d\n%s\n", s.Code, roff(capitalize(s.Desc)))
+			}
+		}
+		if e, ok := c.(Exampler); ok {
+			for _, x := range e.Examples() {
+				fmt.Fprintf(bw, ".PP\n")
+				if x.Desc != "" {
+					fmt.Fprintf(bw, "%s:\n", roff(capitalize(x.Desc)))
+				}
+				fmt.Fprintf(bw, ".RS\n.nf\n%s %s %s\n.fi\n.RE\n", roff(name), roff(path), roff(x.line(docShell)))
+			}
+		}
+	})
+
+	if len(topics) > 0 {
+		fmt.Fprintf(bw, ".SH \"HELP TOPICS\"\n")
+	}
+	for _, c := range topics {
+		fmt.Fprintf(bw, ".SS \"%s\"\n", roff(capitalize(title(c))))
+		manText(bw, longText(c))
+	}
+	return bw.Flush()
+}
+
+// manText writes a text as roff paragraphs.
+func manText(w io.Writer, text string) {
+	for _, p := range strings.Split(strings.TrimSpace(text), "\n\n") {
+		if strings.TrimSpace(p) == "" {
+			continue
+		}
+		fmt.Fprintf(w, ".PP\n")
+		for _, ln := range strings.Split(p, "\n") {
+			fmt.Fprintf(w, "%s\n", roff(ln))
+		}
+	}
+}
+
+// roff escapes a text line for roff.
+func roff(s string) string {
+	s = strings.Replace(s, "\\", "\\e", -1)
+	s = strings.Replace(s, "-", "\\-", -1)
+	if strings.HasPrefix(s, ".") || strings.HasPrefix(s, "'") {
+		s = "\\&" + s
+	}
+	return s
+}
@@ -0,0 +1,72 @@
+// Copyright (c) 2015, J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD-style license that can be found in the LICENSE file.
+
+package cmdapp
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// WriteMarkdown writes the documentation
+// of the application and its commands
+// in Markdown format.
+func WriteMarkdown(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	name := appName()
+	fmt.Fprintf(bw, "# %s\n\n%s\n\n", name, capitalize(Short))
+	fmt.Fprintf(bw, "## Usage\n\n    %s [help] <command> [<args>...]\n\n", name)
+
+	fmt.Fprintf(bw, "## Commands\n\n")
+	var topics []Command
+	walkCommands(func(path string, c Command, parents []*Group) {
+		if !c.Runnable() {
+			topics = append(topics, c)
+			return
+		}
+		fmt.Fprintf(bw, "### %s\n\n%s\n\n", path, capitalize(shortText(c)))
+		fmt.Fprintf(bw, "    %s %s %s\n\n", name, path, c.Args())
+		fmt.Fprintf(bw, "%s\n\n", strings.TrimSpace(longText(c)))
+		if flags := pathFlags(c, parents); len(flags) > 0 {
+			fmt.Fprintf(bw, "Flags:\n\n")
+			for _, f := range flags {
+				fmt.Fprintf(bw, "- `-%s`: %s\n", f.Name, f.Usage)
+			}
+			fmt.Fprintf(bw, "\n")
+		}
+		if vars := commandEnv(c); len(vars) > 0 {
+			fmt.Fprintf(bw, "Environment variables:\n\n")
+			for _, v := range vars {
+				fmt.Fprintf(bw, "- `%s`: %s\n", v.Name, envDesc(v))
+			}
+			fmt.Fprintf(bw, "\n")
+		}
+		if _, ok := c.(ExitStatusCommand); ok {
+			fmt.Fprintf(bw, "Exit status:\n\n")
+			for _, s := range exitStatus(c) {
+				fmt.Fprintf(bw, "- `%d`: %s\n", s.Code, s.Desc)
+			}
+			fmt.Fprintf(bw, "\n")
+		}
+		if e, ok := c.(Exampler); ok && len(e.Examples()) > 0 {
+			fmt.Fprintf(bw, "Examples:\n\n")
+			for _, x := range e.Examples() {
+				if x.Desc != "" {
+					fmt.Fprintf(bw, "    # %s\n", x.Desc)
+				}
+				fmt.Fprintf(bw, "    %s %s %s\n\n", name, path, x.line(docShell))
+			}
+		}
+	})
+
+	if len(topics) > 0 {
+		fmt.Fprintf(bw, "## Help topics\n\n")
+	}
+	for _, c := range topics {
+		fmt.Fprintf(bw, "### %s\n\n%s\n\n", capitalize(title(c)), strings.TrimSpace(longText(c)))
+	}
+	return bw.Flush()
+}
@@ -0,0 +1,66 @@
+// Copyright (c) 2015, J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD-style license that can be found in the LICENSE file.
+
+package cmdapp
+
+import (
+	"context"
+	"sync"
+)
+
+// A RunFunc runs a command
+// with the given context and arguments.
+type RunFunc func(ctx context.Context, c Command, args []string) error
+
+// A Middleware wraps the run of a command,
+// for example to time the command,
+// check the credentials of the user,
+// or recover from panics.
+// It returns a RunFunc
+// that should call next to run the command.
+type Middleware func(next RunFunc) RunFunc
+
+// middlewares are the middlewares set with Use.
+var (
+	mwMutex     sync.Mutex
+	middlewares []Middleware
+)
+
+// Use adds middlewares that wrap the run of every command,
+// after its flags are parsed
+// and its arguments validated.
+// The first middleware added
+// is the outermost.
+//
+// Groups are not wrapped,
+// only the commands of the group.
+func Use(mw ...Middleware) {
+	mwMutex.Lock()
+	defer mwMutex.Unlock()
+	middlewares = append(middlewares, mw...)
+}
+
+// runFunc runs a command,
+// using RunContext if the command is a CommandContext.
+func runFunc(ctx context.Context, c Command, args []string) error {
+	if cc, ok := c.(CommandContext); ok {
+		return cc.RunContext(ctx, args)
+	}
+	return c.Run(args)
+}
+
+// chain returns the run function of a command
+// wrapped by the middlewares.
+func chain(c Command) RunFunc {
+	run := RunFunc(runFunc)
+	if _, ok := c.(*Group); ok {
+		return run
+	}
+	mwMutex.Lock()
+	defer mwMutex.Unlock()
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		run = middlewares[i](run)
+	}
+	return run
+}
@@ -0,0 +1,227 @@
+// Copyright (c) 2015, J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD-style license that can be found in the LICENSE file.
+
+package cmdapp
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// migrateCmd is the migrate-usage command.
+type migrateCmd struct{}
+
+func init() {
+	addBuiltin(migrateCmd{})
+}
+
+const migrateLong = `
+Command migrate-usage reads shell scripts or shell history files, and prints
+the invocations of the application that use deprecated commands or flags,
+with the modern equivalent. If no file is given, it reads the standard input,
+for example:
+
+    history | <app> migrate-usage
+
+The files are not modified.
+`
+
+func (m migrateCmd) Name() string              { return "migrate-usage" }
+func (m migrateCmd) Args() string              { return "[<file>...]" }
+func (m migrateCmd) Short() string             { return "finds deprecated usage in scripts" }
+func (m migrateCmd) Long() string              { return migrateLong }
+func (m migrateCmd) Register(fs *flag.FlagSet) {}
+func (m migrateCmd) Runnable() bool            { return true }
+
+func (m migrateCmd) Run(args []string) error {
+	n := 0
+	if len(args) == 0 {
+		var err error
+		n, err = migrateFile(os.Stdin, "<stdin>")
+		if err != nil {
+			return err
+		}
+	}
+	for _, a := range args {
+		f, err := os.Open(a)
+		if err != nil {
+			return err
+		}
+		c, err := migrateFile(f, a)
+		f.Close()
+		if err != nil {
+			return err
+		}
+		n += c
+	}
+	if n == 0 {
+		fmt.Fprintln(Output(), Mark(Success, "no deprecated usage found"))
+		return nil
+	}
+	fmt.Fprintln(Output(), Mark(Warning, fmt.Sprintf("found %s", Plural(n, "deprecated usage", "deprecated usages"))))
+	return nil
+}
+
+// migrateFile prints the deprecated usage in a file,
+// and returns the number of deprecated usages.
+func migrateFile(r io.Reader, name string) (int, error) {
+	n := 0
+	s := bufio.NewScanner(r)
+	for ln := 1; s.Scan(); ln++ {
+		line, notes := migrateLine(s.Text())
+		if len(notes) == 0 {
+			continue
+		}
+		for _, nt := range notes {
+			fmt.Fprintf(Output(), "%s:%d: %s\n", name, ln, nt)
+		}
+		fmt.Fprintf(Output(), "    %s\n", strings.TrimSpace(line))
+		n += len(notes)
+	}
+	return n, s.Err()
+}
+
+// A span is the position of a word in a line.
+type span struct {
+	start, end int
+}
+
+// An edit is a replacement of a part of a line.
+type edit struct {
+	span
+	text string
+}
+
+// migrateLine returns a line
+// with the deprecated usage of the application replaced,
+// and notes about the replacements.
+func migrateLine(line string) (string, []string) {
+	var edits []edit
+	var notes []string
+	for _, seg := range segments(line) {
+		e, n := migrateSegment(line, seg)
+		edits = append(edits, e...)
+		notes = append(notes, n...)
+	}
+	sort.Slice(edits, func(i, j int) bool { return edits[i].start > edits[j].start })
+	for _, e := range edits {
+		line = line[:e.start] + e.text + line[e.end:]
+	}
+	return line, notes
+}
+
+// segments returns the words of a line
+// grouped by shell commands.
+func segments(line string) [][]span {
+	var segs [][]span
+	var cur []span
+	start := -1
+	for i, r := range line + " " {
+		sep := strings.ContainsRune(";|&()`", r)
+		if r == ' ' || r == '\t' || sep {
+			if start >= 0 {
+				cur = append(cur, span{start, i})
+				start = -1
+			}
+			if sep && len(cur) > 0 {
+				segs = append(segs, cur)
+				cur = nil
+			}
+			continue
+		}
+		if start < 0 {
+			start = i
+		}
+	}
+	if len(cur) > 0 {
+		segs = append(segs, cur)
+	}
+	return segs
+}
+
+// migrateSegment returns the edits and notes
+// of a shell command.
+func migrateSegment(line string, words []span) ([]edit, []string) {
+	app := strings.TrimSuffix(appName(), ".exe")
+	word := func(s span) string { return strings.Trim(line[s.start:s.end], `"'`) }
+
+	i := 0
+	for ; i < len(words); i++ {
+		if strings.TrimSuffix(filepath.Base(word(words[i])), ".exe") == app {
+			break
+		}
+	}
+	if i >= len(words) {
+		return nil, nil
+	}
+
+	// application flags
+	for i++; i < len(words); i++ {
+		w := word(words[i])
+		if len(w) < 2 || w[0] != '-' {
+			break
+		}
+		if !takesValue(appFlagSet, w) {
+			continue
+		}
+		i++
+	}
+	if i >= len(words) {
+		return nil, nil
+	}
+
+	var edits []edit
+	var notes []string
+	cmd := word(words[i])
+	if d, ok := deprecation(cmd, ""); ok {
+		notes = append(notes, depNote(fmt.Sprintf("command %q", cmd), d, d.Replacement))
+		if d.Replacement != "" {
+			edits = append(edits, edit{words[i], d.Replacement})
+		}
+	}
+	for _, s := range words[i+1:] {
+		w := line[s.start:s.end]
+		if w == "--" {
+			break
+		}
+		if len(w) < 2 || w[0] != '-' {
+			continue
+		}
+		name := strings.TrimLeft(w, "-")
+		if j := strings.Index(name, "="); j >= 0 {
+			name = name[:j]
+		}
+		d, ok := deprecation(cmd, name)
+		if !ok {
+			continue
+		}
+		notes = append(notes, depNote(fmt.Sprintf("flag -%s of %q", name, cmd), d, "-"+d.Replacement))
+		if d.Replacement != "" {
+			pos := s.start + strings.Index(w, name)
+			edits = append(edits, edit{span{pos, pos + len(name)}, d.Replacement})
+		}
+	}
+	return edits, notes
+}
+
+// takesValue reports whether an application flag
+// takes its value from the next word.
+func takesValue(fs *flag.FlagSet, w string) bool {
+	name := strings.TrimLeft(w, "-")
+	if fs == nil || strings.Contains(name, "=") {
+		return false
+	}
+	f := fs.Lookup(name)
+	if f == nil {
+		return false
+	}
+	b, ok := f.Value.(interface{ IsBoolFlag() bool })
+	return !ok || !b.IsBoolFlag()
+}
@@ -0,0 +1,55 @@
+// Copyright (c) 2015, J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD-style license that can be found in the LICENSE file.
+
+package cmdapp
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// normName returns the normalized form of a command name,
+// used to register and look up commands and aliases,
+// so names that look the same are the same name.
+// The name is normalized to NFC,
+// and case folded.
+func normName(name string) string {
+	return strings.Map(foldRune, norm.NFC.String(name))
+}
+
+// foldRune returns the case folded form of a rune:
+// the lower case of the smallest rune
+// that is equivalent under simple case folding.
+func foldRune(r rune) rune {
+	min := r
+	for f := unicode.SimpleFold(r); f != r; f = unicode.SimpleFold(f) {
+		if f < min {
+			min = f
+		}
+	}
+	return unicode.ToLower(min)
+}
+
+// codePoints returns a name
+// with the code points of its runes,
+// to diagnose names that look the same.
+func codePoints(name string) string {
+	cp := make([]string, 0, len(name))
+	for _, r := range name {
+		cp = append(cp, fmt.Sprintf("U+%04X", r))
+	}
+	return fmt.Sprintf("%q [%s]", name, strings.Join(cp, " "))
+}
+
+// nameConflict returns a description of two names
+// with the same normalized form.
+func nameConflict(a, b string) string {
+	if a == b {
+		return fmt.Sprintf("%q", a)
+	}
+	return fmt.Sprintf("%s and %s", codePoints(a), codePoints(b))
+}
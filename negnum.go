@@ -0,0 +1,70 @@
+// Copyright (c) 2015, J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD-style license that can be found in the LICENSE file.
+
+package cmdapp
+
+import (
+	"flag"
+	"strconv"
+	"strings"
+)
+
+// negativeArgs returns the arguments of a command
+// with a "--" added before the first argument,
+// in a flag position,
+// that is a negative number,
+// so it is parsed as an argument
+// instead of an unknown flag.
+// Arguments are not changed
+// if the command has flags named with a digit.
+func negativeArgs(fs *flag.FlagSet, args []string) []string {
+	numFlag := false
+	fs.VisitAll(func(f *flag.Flag) {
+		if len(f.Name) == 1 && f.Name[0] >= '0' && f.Name[0] <= '9' {
+			numFlag = true
+		}
+	})
+	if numFlag {
+		return args
+	}
+
+	for i := 0; i < len(args); i++ {
+		a := args[i]
+		if a == "--" || len(a) < 2 || a[0] != '-' {
+			return args
+		}
+		if isNumber(a) {
+			na := make([]string, 0, len(args)+1)
+			na = append(na, args[:i]...)
+			na = append(na, "--")
+			return append(na, args[i:]...)
+		}
+		name := strings.TrimLeft(a, "-")
+		if strings.Contains(name, "=") {
+			continue
+		}
+		f := fs.Lookup(name)
+		if f == nil {
+			continue
+		}
+		if b, ok := f.Value.(interface{ IsBoolFlag() bool }); ok && b.IsBoolFlag() {
+			continue
+		}
+		// the next argument is the flag value
+		i++
+	}
+	return args
+}
+
+// isNumber reports whether a string
+// starting with a dash
+// is a negative number,
+// as -3 or -0.5.
+func isNumber(s string) bool {
+	if len(s) < 2 || (s[1] != '.' && (s[1] < '0' || s[1] > '9')) {
+		return false
+	}
+	_, err := strconv.ParseFloat(s, 64)
+	return err == nil
+}
@@ -0,0 +1,92 @@
+// Copyright (c) 2015, J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD-style license that can be found in the LICENSE file.
+
+package cmdapp
+
+import (
+	"flag"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// numFlagSet returns a flag set
+// used to test the negative numbers.
+func numFlagSet() *flag.FlagSet {
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	fs.Bool("v", false, "")
+	fs.Int("n", 0, "")
+	fs.Float64("x", 0, "")
+	fs.String("s", "", "")
+	return fs
+}
+
+func TestNegativeArgs(t *testing.T) {
+	tests := []struct {
+		args []string
+		want []string
+	}{
+		{nil, nil},
+		{[]string{"-3"}, []string{"--", "-3"}},
+		{[]string{"-v", "-0.5", "1"}, []string{"-v", "--", "-0.5", "1"}},
+		{[]string{"-n", "-3", "-4"}, []string{"-n", "-3", "--", "-4"}},
+		{[]string{"-n=-3", "-4"}, []string{"-n=-3", "--", "-4"}},
+		{[]string{"-s", "x", "a", "-3"}, []string{"-s", "x", "a", "-3"}},
+		{[]string{"--", "-3"}, []string{"--", "-3"}},
+		{[]string{"-unknown", "-3"}, []string{"-unknown", "--", "-3"}},
+		{[]string{"-e3"}, []string{"-e3"}},
+		{[]string{"-.5"}, []string{"--", "-.5"}},
+	}
+	for _, test := range tests {
+		got := negativeArgs(numFlagSet(), test.args)
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("negativeArgs(%q) = %q, want %q", test.args, got, test.want)
+		}
+	}
+
+	// flags named with a digit
+	fs := numFlagSet()
+	fs.Bool("1", false, "")
+	args := []string{"-1", "-3"}
+	if got := negativeArgs(fs, args); !reflect.DeepEqual(got, args) {
+		t.Errorf("negativeArgs with digit flags = %q, want %q", got, args)
+	}
+}
+
+// FuzzNegativeArgs checks that negativeArgs
+// only adds a "--" before a negative number,
+// and that the arguments can be parsed.
+// The arguments are separated by new lines.
+func FuzzNegativeArgs(f *testing.F) {
+	f.Add("-3")
+	f.Add("-v\n-0.5\n1")
+	f.Add("-n\n-3\n-4")
+	f.Add("-s\n--\n-1e3")
+	f.Fuzz(func(t *testing.T, in string) {
+		var args []string
+		if in != "" {
+			args = strings.Split(in, "\n")
+		}
+		got := negativeArgs(numFlagSet(), args)
+		if len(got) != len(args) {
+			if len(got) != len(args)+1 {
+				t.Fatalf("negativeArgs(%q) = %q: too many arguments", args, got)
+			}
+			i := 0
+			for i < len(args) && got[i] == args[i] {
+				i++
+			}
+			if got[i] != "--" || !isNumber(got[i+1]) {
+				t.Fatalf("negativeArgs(%q) = %q: %q is not a number", args, got, got[i+1])
+			}
+			got = append(got[:i:i], got[i+1:]...)
+		}
+		if !reflect.DeepEqual(got, args) {
+			t.Fatalf("negativeArgs(%q): arguments changed: %q", args, got)
+		}
+		numFlagSet().Parse(negativeArgs(numFlagSet(), args))
+	})
+}
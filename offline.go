@@ -0,0 +1,55 @@
+// Copyright (c) 2015, J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD-style license that can be found in the LICENSE file.
+
+package cmdapp
+
+import (
+	"flag"
+	"net/http"
+
+	"github.com/pkg/errors"
+)
+
+// ErrOffline is the error returned
+// by the requests of HTTPClient
+// that can not be served from the cache
+// in offline mode.
+var ErrOffline = errors.New("not available in offline mode")
+
+// offline is set with the -offline flag.
+var offline bool
+
+// registerOfflineFlags sets the offline flag
+// of the application.
+func registerOfflineFlags(fs *flag.FlagSet) {
+	fs.BoolVar(&offline, "offline", false, "do not access the network, use cached data")
+}
+
+// Offline returns true if the application
+// runs in offline mode,
+// set with the -offline flag.
+//
+// In offline mode the requests of HTTPClient
+// are served from the cache
+// or fail immediately with ErrOffline.
+// Commands that access the network by other means,
+// check for updates,
+// or send usage data,
+// should check Offline before doing it.
+func Offline() bool {
+	return offline
+}
+
+// offlineTransport is an HTTP transport
+// that fails in offline mode.
+type offlineTransport struct {
+	base http.RoundTripper
+}
+
+func (t offlineTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if Offline() {
+		return nil, ErrOffline
+	}
+	return t.base.RoundTrip(req)
+}
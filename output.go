@@ -0,0 +1,236 @@
+// Copyright (c) 2015, J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD-style license that can be found in the LICENSE file.
+
+package cmdapp
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// A Buffering is the buffering mode
+// of the command output.
+type Buffering int
+
+// Valid buffering modes.
+const (
+	// LineBuffered writes the output
+	// at the end of each line.
+	LineBuffered Buffering = iota
+
+	// Unbuffered writes the output
+	// as soon as it is written.
+	Unbuffered
+
+	// FullyBuffered writes the output
+	// when the buffer is full,
+	// Flush is called,
+	// or the command finishes.
+	FullyBuffered
+)
+
+// bufSize is the size of the output buffer
+// in FullyBuffered mode.
+const bufSize = 32 * 1024
+
+// OutputMode is the buffering mode of the writer returned by Output.
+// The mode is the same
+// whether the standard output is a terminal or a pipe,
+// by default output is line buffered.
+var OutputMode Buffering
+
+// out is the command output.
+var out = &outWriter{}
+
+// Output returns the writer for the results of a command,
+// that writes on the standard output
+// (or the file set with the -output-file flag)
+// using the OutputMode buffering.
+// It is safe for concurrent use.
+//
+// The output is flushed when the command finishes.
+// Commands that produce incremental results
+// and use FullyBuffered mode
+// should call Flush after each result.
+func Output() io.Writer {
+	return out
+}
+
+// Flush writes any buffered output
+// to the standard output.
+func Flush() error {
+	out.mu.Lock()
+	defer out.mu.Unlock()
+	return out.flush()
+}
+
+// outWriter is a buffered writer of the standard output.
+type outWriter struct {
+	mu   sync.Mutex
+	buf  []byte
+	file *os.File
+
+	// stdout is the standard output
+	// of the application,
+	// if nil,
+	// the standard output of the process.
+	stdout io.Writer
+}
+
+// std returns the standard output.
+// The mutex must be held.
+func (w *outWriter) std() io.Writer {
+	if w.stdout == nil {
+		return os.Stdout
+	}
+	return w.stdout
+}
+
+// dest returns the destination of the output.
+// The mutex must be held.
+func (w *outWriter) dest() io.Writer {
+	switch {
+	case w.file == nil:
+		return w.std()
+	case teeOutput:
+		return io.MultiWriter(w.std(), w.file)
+	}
+	return w.file
+}
+
+func (w *outWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	switch OutputMode {
+	case Unbuffered:
+		if err := w.flush(); err != nil {
+			return 0, err
+		}
+		return w.dest().Write(p)
+	case FullyBuffered:
+		w.buf = append(w.buf, p...)
+		if len(w.buf) >= bufSize {
+			if err := w.flush(); err != nil {
+				return 0, err
+			}
+		}
+		return len(p), nil
+	}
+
+	w.buf = append(w.buf, p...)
+	i := bytes.LastIndexByte(w.buf, '\n')
+	if i < 0 {
+		return len(p), nil
+	}
+	_, err := w.dest().Write(w.buf[:i+1])
+	w.buf = append(w.buf[:0], w.buf[i+1:]...)
+	if err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// flush writes the buffer.
+// The mutex must be held.
+func (w *outWriter) flush() error {
+	if len(w.buf) == 0 {
+		return nil
+	}
+	_, err := w.dest().Write(w.buf)
+	w.buf = w.buf[:0]
+	return err
+}
+
+// stdout returns the standard output
+// of the application.
+func stdout() io.Writer {
+	out.mu.Lock()
+	defer out.mu.Unlock()
+	return out.std()
+}
+
+// redirectOutput writes the output
+// in the given writer,
+// instead of the standard output
+// or the output file,
+// and returns the function
+// that restores the previous output.
+func redirectOutput(w io.Writer) (restore func()) {
+	out.mu.Lock()
+	defer out.mu.Unlock()
+	out.flush()
+	std, file := out.stdout, out.file
+	out.stdout, out.file = w, nil
+
+	return func() {
+		out.mu.Lock()
+		defer out.mu.Unlock()
+		out.flush()
+		out.stdout, out.file = std, file
+	}
+}
+
+// Output file flags.
+var (
+	outputFile string
+	teeOutput  bool
+)
+
+// registerOutputFlags sets the output file flags
+// of the application.
+func registerOutputFlags(fs *flag.FlagSet) {
+	fs.StringVar(&outputFile, "output-file", "", "write the output of the command in the given file")
+	fs.BoolVar(&teeOutput, "tee", false, "with -output-file, also write the output in the standard output")
+}
+
+// openOutputFile starts the writing of the output
+// in the file set with the -output-file flag.
+// The output is written in a temporary file,
+// that replaces the output file
+// only if the application ends without errors.
+// It returns the function that must be called
+// when the application ends,
+// or nil if no output file is set.
+func openOutputFile() (func(code int), error) {
+	if outputFile == "" {
+		if teeOutput {
+			return nil, errors.New("flag -tee requires -output-file")
+		}
+		return nil, nil
+	}
+	f, err := os.CreateTemp(filepath.Dir(outputFile), "."+filepath.Base(outputFile)+".*")
+	if err != nil {
+		return nil, errors.Wrap(err, "output file")
+	}
+	f.Chmod(0644)
+
+	out.mu.Lock()
+	out.file = f
+	out.mu.Unlock()
+
+	return func(code int) {
+		out.mu.Lock()
+		out.file = nil
+		out.mu.Unlock()
+
+		err := f.Close()
+		if err == nil && code == 0 {
+			err = os.Rename(f.Name(), outputFile)
+		}
+		if err != nil {
+			fmt.Fprintf(Stderr, "%s: output file: %v\n", Name, err)
+		}
+		if err != nil || code != 0 {
+			os.Remove(f.Name())
+		}
+	}, nil
+}
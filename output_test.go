@@ -0,0 +1,77 @@
+// Copyright (c) 2015, J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD-style license that can be found in the LICENSE file.
+
+package cmdapp
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestOutputFile(t *testing.T) {
+	defer func(f string, tee bool) { outputFile, teeOutput = f, tee }(outputFile, teeOutput)
+	oldErr := Stderr
+	Stderr = io.Discard
+	defer func() { Stderr = oldErr }()
+
+	tests := [][]string{
+		{"help"},
+		{"help", "config"},
+		{"help", "search", "config"},
+		{"config", "list"},
+		{"env"},
+		{"config", "get", "test.count"},
+		{"completion", "bash"},
+	}
+	for _, args := range tests {
+		for _, tee := range []bool{false, true} {
+			outputFile = filepath.Join(t.TempDir(), "out.txt")
+			teeOutput = tee
+			done, err := openOutputFile()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			r, w, err := os.Pipe()
+			if err != nil {
+				t.Fatal(err)
+			}
+			var stdout bytes.Buffer
+			copied := make(chan struct{})
+			go func() {
+				io.Copy(&stdout, r)
+				close(copied)
+			}()
+			old := os.Stdout
+			os.Stdout = w
+			code := Dispatch(args)
+			os.Stdout = old
+			w.Close()
+			<-copied
+			done(code)
+
+			if code != 0 {
+				t.Errorf("%v: exit code %d", args, code)
+				continue
+			}
+			b, err := os.ReadFile(outputFile)
+			if err != nil {
+				t.Errorf("%v: %v", args, err)
+				continue
+			}
+			if len(b) == 0 {
+				t.Errorf("%v: empty output file", args)
+			}
+			if tee && stdout.String() != string(b) {
+				t.Errorf("%v -tee: standard output %q, want %q", args, stdout.String(), b)
+			}
+			if !tee && stdout.Len() > 0 {
+				t.Errorf("%v: written in the standard output: %q", args, stdout.String())
+			}
+		}
+	}
+}
@@ -0,0 +1,152 @@
+// Copyright (c) 2015, J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD-style license that can be found in the LICENSE file.
+
+package cmdapp
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// A ChangeKind is the kind of a planned change.
+type ChangeKind int
+
+// Valid change kinds.
+const (
+	Create ChangeKind = iota
+	Update
+	Delete
+)
+
+var (
+	changeMarks  = []string{"+", "~", "-"}
+	changeColors = []string{"\x1b[32m", "\x1b[33m", "\x1b[31m"}
+	changeVerbs  = []string{"create", "update", "delete"}
+)
+
+// A Change is a change
+// that a command intends to do.
+type Change struct {
+	// Kind is the kind of change.
+	Kind ChangeKind
+
+	// Target is the object changed,
+	// for example a file name.
+	Target string
+
+	// Detail is an optional description of the change,
+	// for example a diff.
+	// Lines starting with '+' or '-'
+	// are colored as added or removed lines.
+	Detail string
+}
+
+// A Plan is the list of changes
+// that a command intends to do.
+type Plan []Change
+
+// ErrNotConfirmed is the error returned by Confirm
+// when the user rejects a plan.
+var ErrNotConfirmed = errors.New("changes not confirmed")
+
+// assumeYes is set by the -yes flag.
+var assumeYes bool
+
+// registerPlanFlags sets the confirmation flags
+// of the application.
+func registerPlanFlags(fs *flag.FlagSet) {
+	fs.BoolVar(&assumeYes, "yes", false, "apply planned changes without confirmation")
+}
+
+// Confirm shows a plan in the error output
+// and asks the user to confirm it.
+// It returns nil if the changes should be applied.
+//
+// If the -yes flag is set,
+// the plan is shown
+// and the changes are applied without confirmation.
+// If the plan is empty,
+// or the application is in dry-run mode,
+// the changes are not applied,
+// and it returns ErrNotConfirmed.
+// If the application is not interactive,
+// it returns an error
+// asking to use the -yes flag.
+func Confirm(p Plan) error {
+	if len(p) == 0 {
+		fmt.Fprintf(Stderr, "No changes.\n")
+		return ErrNotConfirmed
+	}
+	p.write(Stderr, Color())
+	switch {
+	case DryRun:
+		return ErrNotConfirmed
+	case assumeYes:
+		return nil
+	case !Interactive():
+		return errors.New("confirmation required, use -yes to apply the changes")
+	}
+
+	fmt.Fprintf(Stderr, "Apply these changes? [y/N] ")
+	ans, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && err != io.EOF {
+		return errors.Wrap(err, "confirm")
+	}
+	switch strings.ToLower(strings.TrimSpace(ans)) {
+	case "y", "yes":
+		return nil
+	}
+	return ErrNotConfirmed
+}
+
+// write writes a plan,
+// optionally using colors.
+func (p Plan) write(w io.Writer, color bool) {
+	var count [3]int
+	for _, c := range p {
+		k := c.Kind
+		if k < Create || k > Delete {
+			k = Update
+		}
+		count[k]++
+		writeColor(w, color, changeColors[k], changeMarks[k]+" "+c.Target)
+		for _, ln := range strings.Split(strings.TrimRight(c.Detail, "\n"), "\n") {
+			if ln == "" {
+				continue
+			}
+			col := ""
+			switch {
+			case strings.HasPrefix(ln, "+"):
+				col = changeColors[Create]
+			case strings.HasPrefix(ln, "-"):
+				col = changeColors[Delete]
+			}
+			writeColor(w, color, col, "    "+ln)
+		}
+	}
+
+	var sum []string
+	for k, n := range count {
+		if n > 0 {
+			sum = append(sum, fmt.Sprintf("%d to %s", n, changeVerbs[k]))
+		}
+	}
+	fmt.Fprintf(w, "\nPlan: %s.\n", strings.Join(sum, ", "))
+}
+
+// writeColor writes a line
+// with an ANSI color.
+func writeColor(w io.Writer, color bool, col, ln string) {
+	if !color || col == "" {
+		fmt.Fprintf(w, "%s\n", ln)
+		return
+	}
+	fmt.Fprintf(w, "%s%s\x1b[0m\n", col, ln)
+}
@@ -0,0 +1,92 @@
+// Copyright (c) 2015, J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD-style license that can be found in the LICENSE file.
+
+package cmdapp
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/pkg/errors"
+)
+
+func TestPlanWrite(t *testing.T) {
+	tests := []struct {
+		name  string
+		plan  Plan
+		color bool
+		want  string
+	}{
+		{
+			name: "kinds",
+			plan: Plan{
+				{Kind: Create, Target: "a.txt"},
+				{Kind: Update, Target: "b.txt"},
+				{Kind: Delete, Target: "c.txt"},
+				{Kind: Create, Target: "d.txt"},
+			},
+			want: "+ a.txt\n~ b.txt\n- c.txt\n+ d.txt\n\nPlan: 2 to create, 1 to update, 1 to delete.\n",
+		},
+		{
+			name: "detail",
+			plan: Plan{
+				{Kind: Update, Target: "b.txt", Detail: " same\n-old\n+new\n\n"},
+			},
+			want: "~ b.txt\n     same\n    -old\n    +new\n\nPlan: 1 to update.\n",
+		},
+		{
+			name: "unknown kind",
+			plan: Plan{{Kind: ChangeKind(7), Target: "x"}},
+			want: "~ x\n\nPlan: 1 to update.\n",
+		},
+		{
+			name:  "color",
+			plan:  Plan{{Kind: Delete, Target: "c.txt", Detail: "-old\n ctx"}},
+			color: true,
+			want:  "\x1b[31m- c.txt\x1b[0m\n\x1b[31m    -old\x1b[0m\n     ctx\n\nPlan: 1 to delete.\n",
+		},
+	}
+	for _, test := range tests {
+		var buf bytes.Buffer
+		test.plan.write(&buf, test.color)
+		if buf.String() != test.want {
+			t.Errorf("%s: got %q, want %q", test.name, buf.String(), test.want)
+		}
+	}
+}
+
+func TestConfirm(t *testing.T) {
+	defer func(dry, yes bool, w io.Writer) { DryRun, assumeYes, Stderr = dry, yes, w }(DryRun, assumeYes, Stderr)
+	Stderr = io.Discard
+	if Interactive() {
+		t.Skip("interactive terminal")
+	}
+
+	plan := Plan{{Kind: Create, Target: "a.txt"}}
+	tests := []struct {
+		name string
+		plan Plan
+		dry  bool
+		yes  bool
+		err  error // nil, ErrNotConfirmed, or other for any other error
+	}{
+		{name: "empty plan", yes: true, err: ErrNotConfirmed},
+		{name: "dry run", plan: plan, dry: true, yes: true, err: ErrNotConfirmed},
+		{name: "yes", plan: plan, yes: true},
+		{name: "not interactive", plan: plan, err: errors.New("other")},
+	}
+	for _, test := range tests {
+		DryRun, assumeYes = test.dry, test.yes
+		err := Confirm(test.plan)
+		switch {
+		case test.err == nil && err != nil:
+			t.Errorf("%s: unexpected error: %v", test.name, err)
+		case test.err == ErrNotConfirmed && err != ErrNotConfirmed:
+			t.Errorf("%s: got error %v, want %v", test.name, err, ErrNotConfirmed)
+		case test.err != nil && test.err != ErrNotConfirmed && (err == nil || err == ErrNotConfirmed):
+			t.Errorf("%s: got error %v, want a confirmation error", test.name, err)
+		}
+	}
+}
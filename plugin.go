@@ -0,0 +1,162 @@
+// Copyright (c) 2015, J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD-style license that can be found in the LICENSE file.
+
+package cmdapp
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"syscall"
+)
+
+// pluginsOn is true if the plugins are enabled.
+var (
+	pluginMutex sync.Mutex
+	pluginsOn   bool
+)
+
+// EnablePlugins enables the external plugins
+// of the application.
+//
+// As in git,
+// if the application is called with an unknown command 'foo',
+// and there is an executable '<app>-foo' in the PATH,
+// the executable is run with the remaining arguments,
+// and its exit code is the exit code of the application.
+// The plugins found in the PATH
+// are listed in the help output.
+func EnablePlugins() {
+	pluginMutex.Lock()
+	defer pluginMutex.Unlock()
+	pluginsOn = true
+}
+
+// usePlugins reports whether the plugins are enabled.
+func usePlugins() bool {
+	pluginMutex.Lock()
+	defer pluginMutex.Unlock()
+	return pluginsOn
+}
+
+// findPlugin returns the path of the executable
+// of a plugin.
+func findPlugin(name string) (string, bool) {
+	if !usePlugins() || name == "" || strings.ContainsAny(name, `/\`) {
+		return "", false
+	}
+	path, err := exec.LookPath(appName() + "-" + name)
+	if err != nil {
+		return "", false
+	}
+	return path, true
+}
+
+// plugins returns the names of the plugins
+// found in the PATH,
+// that are not shadowed by a command.
+func plugins() []string {
+	if !usePlugins() {
+		return nil
+	}
+	prefix := appName() + "-"
+	seen := make(map[string]bool)
+	var names []string
+	for _, dir := range filepath.SplitList(os.Getenv("PATH")) {
+		files, err := os.ReadDir(dir)
+		if err != nil {
+			continue
+		}
+		for _, f := range files {
+			name := f.Name()
+			if runtime.GOOS == "windows" {
+				name = strings.TrimSuffix(name, filepath.Ext(name))
+			}
+			if !strings.HasPrefix(name, prefix) || len(name) == len(prefix) {
+				continue
+			}
+			name = strings.TrimPrefix(name, prefix)
+			if seen[name] {
+				continue
+			}
+			if _, ok := lookup(name); ok {
+				continue
+			}
+			if _, ok := findPlugin(name); !ok {
+				continue
+			}
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// printPlugins prints the plugins found in the PATH.
+func printPlugins(w io.Writer) {
+	names := plugins()
+	if len(names) == 0 {
+		return
+	}
+	fmt.Fprintf(w, "Plugin commands:\n\n")
+	for _, nm := range names {
+		fmt.Fprintf(w, "    %s\n", nm)
+	}
+	fmt.Fprintf(w, "\nUse '%s <plugin> -help' for more information about a plugin.\n\n", Name)
+}
+
+// runPlugin runs the executable of a plugin
+// and returns its exit code.
+// The signals received by the application
+// are sent to the plugin.
+func runPlugin(path string, args []string) int {
+	Flush()
+	logger.Info("plugin", "path", path, "args", args)
+	cmd := exec.Command(path, args...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = stdout()
+	cmd.Stderr = stderr{}
+	if err := cmd.Start(); err != nil {
+		fmt.Fprintf(Stderr, "%s: plugin: %v\n", Name, err)
+		return 1
+	}
+
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, os.Interrupt, syscall.SIGTERM)
+	done := make(chan struct{})
+	go func() {
+		for {
+			select {
+			case s := <-sig:
+				cmd.Process.Signal(s)
+			case <-done:
+				return
+			}
+		}
+	}()
+	err := cmd.Wait()
+	signal.Stop(sig)
+	close(done)
+
+	if err != nil {
+		logger.Info("plugin: done", "path", path, "error", err.Error())
+	}
+	if cmd.ProcessState == nil {
+		return 1
+	}
+	code := cmd.ProcessState.ExitCode()
+	if code < 0 {
+		// killed by a signal
+		return 1
+	}
+	return code
+}
@@ -0,0 +1,96 @@
+// Copyright (c) 2015, J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD-style license that can be found in the LICENSE file.
+
+package cmdapp
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestPlugins(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the plugins are shell scripts")
+	}
+	pluginMutex.Lock()
+	prev := pluginsOn
+	pluginsOn = true
+	pluginMutex.Unlock()
+	defer func() {
+		pluginMutex.Lock()
+		pluginsOn = prev
+		pluginMutex.Unlock()
+	}()
+
+	dir := t.TempDir()
+	scripts := []struct {
+		name string
+		mode os.FileMode
+		body string
+	}{
+		{"plugapp-hello", 0755, "#!/bin/sh\necho hello \"$@\"\nexit 4\n"},
+		{"plugapp-greet", 0755, "#!/bin/sh\necho plugin\n"},
+		{"plugapp-noexec", 0644, "#!/bin/sh\necho noexec\n"},
+		{"other-tool", 0755, "#!/bin/sh\necho other\n"},
+	}
+	for _, s := range scripts {
+		if err := os.WriteFile(filepath.Join(dir, s.name), []byte(s.body), s.mode); err != nil {
+			t.Fatal(err)
+		}
+	}
+	t.Setenv("PATH", dir)
+
+	var out bytes.Buffer
+	a := NewApp("plugapp", "a test application")
+	a.Stdout, a.Stderr = &out, io.Discard
+	a.Add(&mountCmd{name: "greet", run: func(c *mountCmd, args []string) error {
+		io.WriteString(Output(), "command\n")
+		return nil
+	}})
+
+	tests := []struct {
+		args []string
+		code int
+		want string
+	}{
+		{args: []string{"hello", "a", "b"}, code: 4, want: "hello a b\n"},
+		{args: []string{"greet"}, want: "command\n"},
+		{args: []string{"noexec"}, code: 1},
+		{args: []string{"../other-tool"}, code: 1},
+	}
+	for _, test := range tests {
+		out.Reset()
+		if code := a.Dispatch(test.args); code != test.code {
+			t.Errorf("%q: exit code %d, want %d", test.args, code, test.code)
+		}
+		if test.want != "" && out.String() != test.want {
+			t.Errorf("%q: output %q, want %q", test.args, out.String(), test.want)
+		}
+	}
+
+	out.Reset()
+	if code := a.Dispatch([]string{"help"}); code != 0 {
+		t.Fatalf("help: exit code %d", code)
+	}
+	if !strings.Contains(out.String(), "Plugin commands:\n\n    hello\n") {
+		t.Errorf("help without the plugins:\n%s", out.String())
+	}
+	for _, nm := range []string{"noexec", "other-tool", "    greet\n\nUse"} {
+		if strings.Contains(out.String(), nm) {
+			t.Errorf("help lists %q as a plugin:\n%s", nm, out.String())
+		}
+	}
+
+	pluginMutex.Lock()
+	pluginsOn = false
+	pluginMutex.Unlock()
+	if code := a.Dispatch([]string{"hello"}); code == 4 {
+		t.Errorf("plugin run with the plugins disabled")
+	}
+}
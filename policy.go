@@ -0,0 +1,153 @@
+// Copyright (c) 2015, J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD-style license that can be found in the LICENSE file.
+
+package cmdapp
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// A GroupCommand is a command
+// that belongs to a group of commands,
+// for example the team that maintains it.
+type GroupCommand interface {
+	Command
+
+	// Group returns the name of the group of the command.
+	Group() string
+}
+
+// A Policy is a set of rules
+// that the commands of an application must follow.
+// The commands of the framework
+// are not checked.
+type Policy struct {
+	// MinLong is the minimum length,
+	// in characters,
+	// of the long description of a command.
+	MinLong int
+
+	// RequireGroup requires that every runnable command
+	// is a GroupCommand with a non-empty group.
+	RequireGroup bool
+}
+
+// policy is the policy of the application.
+var (
+	policyMutex sync.Mutex
+	policy      *Policy
+)
+
+// SetPolicy enables the strict registration of commands.
+// Commands are checked against the policy
+// when they are added,
+// and the commands already added
+// are checked when the policy is set.
+// A command that breaks the policy
+// will trigger a panic.
+//
+// Every command must have a short description,
+// and the flags mentioned in the arguments of a command
+// must be the flags defined by the command
+// (a command with many flags can use '[<flags>]'
+// in its arguments).
+func SetPolicy(p Policy) {
+	policyMutex.Lock()
+	policy = &p
+	policyMutex.Unlock()
+	if err := CheckPolicy(); err != nil {
+		panic(fmt.Sprintf("cmdapp: policy: %v", err))
+	}
+}
+
+// CheckPolicy returns the commands
+// that break the policy of the application,
+// as an Errors list,
+// or nil if no policy is set.
+// It can be used in tests.
+func CheckPolicy() error {
+	var errs Errors
+	for _, c := range sortedCommands() {
+		errs.Add(checkPolicy(c))
+	}
+	return errs.Err()
+}
+
+// checkPolicy checks a command
+// against the policy of the application.
+func checkPolicy(c Command) error {
+	policyMutex.Lock()
+	p := policy
+	policyMutex.Unlock()
+	if p == nil || isBuiltin(c) {
+		return nil
+	}
+
+	var msg []string
+	if strings.TrimSpace(c.Short()) == "" {
+		msg = append(msg, "empty short description")
+	}
+	if n := len([]rune(strings.TrimSpace(c.Long()))); n < p.MinLong {
+		msg = append(msg, fmt.Sprintf("long description of %d characters, want at least %d", n, p.MinLong))
+	}
+	if c.Runnable() {
+		msg = append(msg, checkArgs(c)...)
+		if p.RequireGroup {
+			if g, ok := c.(GroupCommand); !ok || g.Group() == "" {
+				msg = append(msg, "without group")
+			}
+		}
+	}
+	if len(msg) == 0 {
+		return nil
+	}
+	return errors.Errorf("command %s: %s", c.Name(), strings.Join(msg, "; "))
+}
+
+// argFlag matches a flag in the arguments of a command.
+var argFlag = regexp.MustCompile(`(?:^|[\s\[|])-{1,2}([\w][\w.-]*)`)
+
+// checkArgs checks that the arguments of a command
+// are consistent with its flags.
+func checkArgs(c Command) []string {
+	defined := make(map[string]bool)
+	for _, f := range commandFlags(c) {
+		defined[f.Name] = true
+	}
+	args := c.Args()
+	mentioned := make(map[string]bool)
+	var msg []string
+	for _, m := range argFlag.FindAllStringSubmatch(args, -1) {
+		nm := m[1]
+		mentioned[nm] = true
+		if !defined[nm] {
+			msg = append(msg, fmt.Sprintf("undefined flag -%s in arguments", nm))
+		}
+	}
+	if strings.Contains(args, "[<flags>]") {
+		return msg
+	}
+	for _, f := range commandFlags(c) {
+		if !mentioned[f.Name] {
+			msg = append(msg, fmt.Sprintf("flag -%s not in arguments", f.Name))
+		}
+	}
+	return msg
+}
+
+// isBuiltin reports whether a command
+// is a command of the framework.
+func isBuiltin(c Command) bool {
+	if _, ok := c.(help); ok {
+		return true
+	}
+	mutex.RLock()
+	defer mutex.RUnlock()
+	return reg.builtins[normName(c.Name())]
+}
@@ -0,0 +1,113 @@
+// Copyright (c) 2015, J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD-style license that can be found in the LICENSE file.
+
+package cmdapp
+
+import (
+	"flag"
+	"strings"
+	"testing"
+)
+
+// policyCmd is a command
+// with configurable descriptions.
+type policyCmd struct {
+	args, short, long string
+	group             string
+	topic             bool
+}
+
+func (c *policyCmd) Name() string  { return "policy-test" }
+func (c *policyCmd) Args() string  { return c.args }
+func (c *policyCmd) Short() string { return c.short }
+func (c *policyCmd) Long() string  { return c.long }
+func (c *policyCmd) Register(fs *flag.FlagSet) {
+	if c.topic {
+		return
+	}
+	fs.Bool("v", false, "verbose")
+	fs.String("o", "", "output file")
+}
+func (c *policyCmd) Runnable() bool          { return !c.topic }
+func (c *policyCmd) Run(args []string) error { return nil }
+func (c *policyCmd) Group() string           { return c.group }
+
+func TestCheckPolicy(t *testing.T) {
+	defer func(p *Policy) {
+		policyMutex.Lock()
+		policy = p
+		policyMutex.Unlock()
+	}(policy)
+
+	long := "A long description of the command."
+	tests := []struct {
+		name   string
+		policy *Policy
+		cmd    *policyCmd
+		want   []string
+	}{
+		{
+			name: "no policy",
+			cmd:  &policyCmd{},
+		},
+		{
+			name:   "valid",
+			policy: &Policy{MinLong: 10, RequireGroup: true},
+			cmd:    &policyCmd{args: "[-v] [-o <file>] <item>", short: "a test", long: long, group: "core"},
+		},
+		{
+			name:   "many flags",
+			policy: &Policy{},
+			cmd:    &policyCmd{args: "[<flags>] <item>", short: "a test"},
+		},
+		{
+			name:   "empty short",
+			policy: &Policy{},
+			cmd:    &policyCmd{args: "[-v] [-o <file>]", short: " "},
+			want:   []string{"empty short description"},
+		},
+		{
+			name:   "short long",
+			policy: &Policy{MinLong: 100},
+			cmd:    &policyCmd{args: "[<flags>]", short: "a test", long: long},
+			want:   []string{"long description of 34 characters, want at least 100"},
+		},
+		{
+			name:   "flags",
+			policy: &Policy{},
+			cmd:    &policyCmd{args: "[-v] [--quiet] <item>", short: "a test"},
+			want:   []string{"undefined flag -quiet in arguments", "flag -o not in arguments"},
+		},
+		{
+			name:   "without group",
+			policy: &Policy{RequireGroup: true},
+			cmd:    &policyCmd{args: "[<flags>]", short: "a test"},
+			want:   []string{"without group"},
+		},
+		{
+			name:   "topic",
+			policy: &Policy{RequireGroup: true},
+			cmd:    &policyCmd{args: "-x", short: "a topic", topic: true},
+		},
+	}
+	for _, test := range tests {
+		policyMutex.Lock()
+		policy = test.policy
+		policyMutex.Unlock()
+		err := checkPolicy(test.cmd)
+		if len(test.want) == 0 {
+			if err != nil {
+				t.Errorf("%s: unexpected error: %v", test.name, err)
+			}
+			continue
+		}
+		if err == nil {
+			t.Errorf("%s: expecting error %q", test.name, test.want)
+			continue
+		}
+		if want := "command policy-test: " + strings.Join(test.want, "; "); err.Error() != want {
+			t.Errorf("%s: error %q, want %q", test.name, err, want)
+		}
+	}
+}
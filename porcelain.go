@@ -0,0 +1,35 @@
+// Copyright (c) 2015, J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD-style license that can be found in the LICENSE file.
+
+package cmdapp
+
+import "flag"
+
+// porcelain is set by the -porcelain flag.
+var porcelain bool
+
+// registerPorcelainFlags sets the porcelain flag
+// of the application.
+func registerPorcelainFlags(fs *flag.FlagSet) {
+	fs.BoolVar(&porcelain, "porcelain", false, "use stable output for scripts")
+}
+
+// Porcelain reports whether the output
+// should be for scripts.
+// It is set with the -porcelain flag.
+//
+// In porcelain mode
+// the output should be easy to parse,
+// without decorations,
+// and it must not change between minor versions.
+// The framework does not use colors,
+// status markers,
+// hyperlinks,
+// or animations,
+// and progress is only reported
+// if it is explicitly requested with the -progress flag.
+// Bytes and Duration return exact values.
+func Porcelain() bool {
+	return porcelain
+}
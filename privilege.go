@@ -0,0 +1,151 @@
+// Copyright (c) 2015, J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD-style license that can be found in the LICENSE file.
+
+package cmdapp
+
+import (
+	"fmt"
+	"runtime"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// A Privilege is a privilege
+// required to run a command.
+//
+// Other than Admin,
+// a privilege is the name of a Linux capability,
+// for example "CAP_NET_BIND_SERVICE".
+// In other systems,
+// a capability requires Admin.
+type Privilege string
+
+// Admin is the privilege of the root user,
+// or the administrator in Windows.
+const Admin Privilege = "admin"
+
+// A PrivilegedCommand is a command
+// that requires privileges to run,
+// for example to install files in system directories.
+// The privileges are checked before the command is run,
+// either from the command line,
+// from a script,
+// with Call,
+// or as a prerequisite,
+// except with DryRun.
+type PrivilegedCommand interface {
+	Command
+
+	// Privileges returns the privileges required by the command.
+	Privileges() []Privilege
+}
+
+// AllowElevation,
+// if set,
+// allows a command that requires Admin
+// to be run again with administrator privileges,
+// in Windows,
+// asking the user for confirmation.
+// Only the command given in the command line
+// of an application started with Run
+// can be elevated.
+// In other systems the user should re-run the command
+// with sudo.
+var AllowElevation bool
+
+// missingPrivilege returns the first privilege of a command
+// that the process does not have.
+func missingPrivilege(c Command) (Privilege, bool) {
+	pc, ok := c.(PrivilegedCommand)
+	if !ok || DryRun {
+		return "", false
+	}
+	for _, p := range pc.Privileges() {
+		if p == Admin {
+			if !isAdmin() {
+				return p, true
+			}
+			continue
+		}
+		if !hasCapability(p) {
+			return p, true
+		}
+	}
+	return "", false
+}
+
+// checkPrivileges checks that the process
+// has the privileges required by a command.
+func checkPrivileges(c Command) error {
+	if p, ok := missingPrivilege(c); ok {
+		return privilegeError(p)
+	}
+	return nil
+}
+
+// elevateCommand runs the application again
+// with administrator privileges,
+// if AllowElevation is set,
+// the process can be elevated,
+// and it lacks a privilege required by a command.
+// The cmdLine is the command line of the application
+// (without the program name),
+// if nil,
+// the application is not run again.
+// If the application is run again,
+// elevated is set to true
+// with the exit code of the elevated process.
+func elevateCommand(c Command, cmdLine []string) (code int, elevated bool) {
+	if !AllowElevation || !canElevate || cmdLine == nil {
+		return 0, false
+	}
+	p, ok := missingPrivilege(c)
+	if !ok {
+		return 0, false
+	}
+	logger.Info("elevate", "command", c.Name(), "privilege", string(p))
+	code, err := elevate(cmdLine)
+	if err != nil {
+		logger.Info("elevate", "error", err.Error())
+		return 0, false
+	}
+	return code, true
+}
+
+// privilegeError returns the error
+// of a missing privilege,
+// with the way to get it.
+func privilegeError(p Privilege) error {
+	if runtime.GOOS == "windows" {
+		return errors.New("requires administrator privileges, re-run from an elevated prompt (Run as administrator)")
+	}
+	what := "root privileges"
+	if p != Admin {
+		what = fmt.Sprintf("capability %s", strings.ToUpper(string(p)))
+	}
+	return errors.Errorf("requires %s, re-run with sudo", what)
+}
+
+// privilegesText returns the text of the privileges
+// required by a command,
+// shown in the help of the command.
+func privilegesText(c Command) string {
+	pc, ok := c.(PrivilegedCommand)
+	if !ok {
+		return ""
+	}
+	var ps []string
+	for _, p := range pc.Privileges() {
+		if p == Admin {
+			ps = append(ps, "administrator (root) privileges")
+			continue
+		}
+		ps = append(ps, "capability "+strings.ToUpper(string(p)))
+	}
+	if len(ps) == 0 {
+		return ""
+	}
+	return "Requires " + strings.Join(ps, ", ") + "."
+}
@@ -0,0 +1,81 @@
+// Copyright (c) 2015, J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD-style license that can be found in the LICENSE file.
+
+//go:build !windows
+
+package cmdapp
+
+import (
+	"flag"
+	"io"
+	"testing"
+)
+
+// privCmd is a command that requires a privilege.
+type privCmd struct {
+	p   Privilege
+	ran bool
+}
+
+func (c *privCmd) Name() string              { return "priv" }
+func (c *privCmd) Args() string              { return "" }
+func (c *privCmd) Short() string             { return "requires a privilege" }
+func (c *privCmd) Long() string              { return "Requires a privilege." }
+func (c *privCmd) Register(fs *flag.FlagSet) {}
+func (c *privCmd) Runnable() bool            { return true }
+func (c *privCmd) Privileges() []Privilege   { return []Privilege{c.p} }
+func (c *privCmd) Run(args []string) error {
+	c.ran = true
+	return nil
+}
+
+// missingCapability returns a capability
+// that the process does not have.
+func missingCapability() (Privilege, bool) {
+	for name := range capabilities {
+		if !hasCapability(Privilege(name)) {
+			return Privilege(name), true
+		}
+	}
+	return "", false
+}
+
+func TestPrivilegeCheck(t *testing.T) {
+	p, ok := missingCapability()
+	if !ok {
+		t.Skip("the process has all the capabilities")
+	}
+
+	tests := []struct {
+		name string
+		run  func(c *privCmd) bool
+	}{
+		{"command", func(c *privCmd) bool {
+			a := NewApp("privapp", "a test application")
+			a.Stdout, a.Stderr = io.Discard, io.Discard
+			a.Add(c)
+			return a.Dispatch([]string{"priv"}) != 0
+		}},
+		{"group", func(c *privCmd) bool {
+			a := NewApp("privapp", "a test application")
+			a.Stdout, a.Stderr = io.Discard, io.Discard
+			g := &Group{Cmd: "grp", Desc: "a group"}
+			g.Add(c)
+			a.Add(g)
+			return a.Dispatch([]string{"grp", "priv"}) != 0
+		}},
+		{"invoked", func(c *privCmd) bool {
+			return invoke(c, nil) != nil
+		}},
+	}
+	for _, test := range tests {
+		c := &privCmd{p: p}
+		if !test.run(c) {
+			t.Errorf("%s: command without %s: want an error", test.name, p)
+		}
+		if c.ran {
+			t.Errorf("%s: command without %s: run", test.name, p)
+		}
+	}
+}
@@ -0,0 +1,120 @@
+// Copyright (c) 2015, J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD-style license that can be found in the LICENSE file.
+
+package cmdapp
+
+import (
+	"os"
+	"strings"
+	"syscall"
+	"unsafe"
+
+	"github.com/pkg/errors"
+)
+
+// canElevate is set if the application
+// can be run again with administrator privileges.
+const canElevate = true
+
+// isAdmin reports whether the process
+// is run with an elevated token.
+func isAdmin() bool {
+	t, err := syscall.OpenCurrentProcessToken()
+	if err != nil {
+		return false
+	}
+	defer t.Close()
+	var elevated, n uint32
+	if err := syscall.GetTokenInformation(t, syscall.TokenElevation, (*byte)(unsafe.Pointer(&elevated)), uint32(unsafe.Sizeof(elevated)), &n); err != nil {
+		return false
+	}
+	return elevated != 0
+}
+
+// hasCapability reports whether the process
+// has a capability,
+// in Windows it requires an elevated process.
+func hasCapability(p Privilege) bool {
+	return isAdmin()
+}
+
+var procShellExecuteExW = syscall.NewLazyDLL("shell32.dll").NewProc("ShellExecuteExW")
+
+// shellExecuteInfo is the SHELLEXECUTEINFOW structure.
+type shellExecuteInfo struct {
+	cbSize       uint32
+	fMask        uint32
+	hwnd         uintptr
+	lpVerb       *uint16
+	lpFile       *uint16
+	lpParameters *uint16
+	lpDirectory  *uint16
+	nShow        int32
+	hInstApp     uintptr
+	lpIDList     uintptr
+	lpClass      *uint16
+	hkeyClass    uintptr
+	dwHotKey     uint32
+	hIcon        uintptr
+	hProcess     syscall.Handle
+}
+
+const (
+	seeMaskNoCloseProcess = 0x40
+	swShowNormal          = 1
+)
+
+// elevate runs the application again
+// with administrator privileges,
+// using the 'runas' verb,
+// so the user is asked for confirmation.
+// It waits for the elevated process
+// and returns its exit code.
+func elevate(args []string) (int, error) {
+	exe, err := os.Executable()
+	if err != nil {
+		return 0, errors.Wrap(err, "elevate")
+	}
+	dir, err := os.Getwd()
+	if err != nil {
+		return 0, errors.Wrap(err, "elevate")
+	}
+	q := make([]string, 0, len(args))
+	for _, a := range args {
+		q = append(q, syscall.EscapeArg(a))
+	}
+
+	info := shellExecuteInfo{
+		fMask: seeMaskNoCloseProcess,
+		nShow: swShowNormal,
+	}
+	info.cbSize = uint32(unsafe.Sizeof(info))
+	if info.lpVerb, err = syscall.UTF16PtrFromString("runas"); err != nil {
+		return 0, errors.Wrap(err, "elevate")
+	}
+	if info.lpFile, err = syscall.UTF16PtrFromString(exe); err != nil {
+		return 0, errors.Wrap(err, "elevate")
+	}
+	if info.lpParameters, err = syscall.UTF16PtrFromString(strings.Join(q, " ")); err != nil {
+		return 0, errors.Wrap(err, "elevate")
+	}
+	if info.lpDirectory, err = syscall.UTF16PtrFromString(dir); err != nil {
+		return 0, errors.Wrap(err, "elevate")
+	}
+	if r, _, err := procShellExecuteExW.Call(uintptr(unsafe.Pointer(&info))); r == 0 {
+		return 0, errors.Wrap(err, "elevate")
+	}
+	if info.hProcess == 0 {
+		return 0, errors.New("elevate: no process")
+	}
+	defer syscall.CloseHandle(info.hProcess)
+	if _, err := syscall.WaitForSingleObject(info.hProcess, syscall.INFINITE); err != nil {
+		return 0, errors.Wrap(err, "elevate")
+	}
+	var code uint32
+	if err := syscall.GetExitCodeProcess(info.hProcess, &code); err != nil {
+		return 0, errors.Wrap(err, "elevate")
+	}
+	return int(code), nil
+}
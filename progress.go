@@ -0,0 +1,296 @@
+// Copyright (c) 2015, J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD-style license that can be found in the LICENSE file.
+
+package cmdapp
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// A Progress reports the progress of a task.
+// The framework renders it according to the output:
+// a progress bar in a terminal,
+// log lines in a continuous integration environment
+// or when the output is not interactive,
+// JSON lines,
+// or nothing at all.
+type Progress interface {
+	// Start starts a task.
+	Start(title string)
+
+	// Update updates the amount of work done.
+	// If the total is unknown,
+	// it should be 0.
+	Update(n, total int64)
+
+	// Done finishes the task.
+	Done()
+}
+
+// A ProgressStyle is the way in which progress is shown.
+type ProgressStyle int
+
+// Valid progress styles.
+const (
+	// ProgressAuto uses a progress bar
+	// if the output can be animated,
+	// and log lines otherwise.
+	ProgressAuto ProgressStyle = iota
+
+	// ProgressBar uses a progress bar.
+	ProgressBar
+
+	// ProgressLog writes a line
+	// for each tenth of the work done.
+	ProgressLog
+
+	// ProgressJSON writes a JSON object per line.
+	ProgressJSON
+
+	// ProgressNone does not show progress.
+	ProgressNone
+)
+
+var progressNames = []string{"auto", "bar", "log", "json", "none"}
+
+func (p ProgressStyle) String() string {
+	if p < 0 || int(p) >= len(progressNames) {
+		return "auto"
+	}
+	return progressNames[p]
+}
+
+// Set sets the progress style from its name,
+// so it can be used as a flag value.
+func (p *ProgressStyle) Set(s string) error {
+	for i, nm := range progressNames {
+		if strings.ToLower(s) == nm {
+			*p = ProgressStyle(i)
+			return nil
+		}
+	}
+	return errors.Errorf("unknown progress style %q", s)
+}
+
+// ProgressOutput is the style used
+// to show the progress of a task.
+// It can be set with the -progress flag.
+var ProgressOutput ProgressStyle
+
+// callProgress is the progress
+// used during a call.
+var (
+	progMutex    sync.Mutex
+	inCall       bool
+	callProgress Progress
+)
+
+// NewProgress returns a progress reporter for a task,
+// that writes on Stderr
+// using the style set by ProgressOutput.
+//
+// When a command is run with Call,
+// it returns the progress given in the call options,
+// or a progress that shows nothing.
+func NewProgress() Progress {
+	progMutex.Lock()
+	defer progMutex.Unlock()
+	if inCall {
+		if callProgress != nil {
+			return callProgress
+		}
+		return noProgress{}
+	}
+
+	style := ProgressOutput
+	if style == ProgressAuto {
+		style = ProgressLog
+		if Porcelain() {
+			style = ProgressNone
+		}
+		if Animated() {
+			style = ProgressBar
+		}
+	}
+	switch style {
+	case ProgressBar:
+		enableVT()
+		return &barProgress{}
+	case ProgressLog:
+		if gitHubActions() {
+			return &githubProgress{}
+		}
+		return &logProgress{}
+	case ProgressJSON:
+		return &jsonProgress{}
+	}
+	return noProgress{}
+}
+
+// noProgress is a progress that shows nothing.
+type noProgress struct{}
+
+func (noProgress) Start(string)      {}
+func (noProgress) Update(n, t int64) {}
+func (noProgress) Done()             {}
+
+// barWidth is the width of the progress bar.
+const barWidth = 30
+
+// barDelay is the minimum time
+// between redraws of the progress bar.
+const barDelay = 100 * time.Millisecond
+
+// barProgress shows a progress bar.
+type barProgress struct {
+	mu    sync.Mutex
+	title string
+	n, t  int64
+	last  time.Time
+}
+
+func (p *barProgress) Start(title string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.title = title
+	p.draw()
+}
+
+func (p *barProgress) Update(n, total int64) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.n, p.t = n, total
+	if time.Since(p.last) < barDelay {
+		return
+	}
+	p.draw()
+}
+
+func (p *barProgress) Done() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.draw()
+	fmt.Fprintln(Stderr)
+}
+
+// draw draws the progress bar.
+func (p *barProgress) draw() {
+	p.last = time.Now()
+	if p.t <= 0 {
+		fmt.Fprintf(Stderr, "\r\x1b[K%s %d", p.title, p.n)
+		return
+	}
+	f := float64(p.n) / float64(p.t)
+	if f > 1 {
+		f = 1
+	}
+	full := int(f * barWidth)
+	bar := strings.Repeat("#", full) + strings.Repeat(".", barWidth-full)
+	fmt.Fprintf(Stderr, "\r\x1b[K%s [%s] %3d%% (%d/%d)", p.title, bar, int(f*100), p.n, p.t)
+}
+
+// logDelay is the minimum time
+// between lines of a progress with unknown total.
+const logDelay = 5 * time.Second
+
+// logProgress writes a line
+// for each tenth of the work done.
+type logProgress struct {
+	mu    sync.Mutex
+	title string
+	step  int64
+	last  time.Time
+}
+
+func (p *logProgress) Start(title string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.title = title
+	p.last = time.Now()
+	fmt.Fprintf(Stderr, "%s: started\n", p.title)
+}
+
+func (p *logProgress) Update(n, total int64) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if total <= 0 {
+		if time.Since(p.last) < logDelay {
+			return
+		}
+		p.last = time.Now()
+		fmt.Fprintf(Stderr, "%s: %d\n", p.title, n)
+		return
+	}
+	step := n * 10 / total
+	if step <= p.step || step >= 10 {
+		return
+	}
+	p.step = step
+	fmt.Fprintf(Stderr, "%s: %d%% (%d/%d)\n", p.title, step*10, n, total)
+}
+
+func (p *logProgress) Done() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	fmt.Fprintf(Stderr, "%s: done\n", p.title)
+}
+
+// jsonProgress writes the progress as JSON lines.
+type jsonProgress struct {
+	mu    sync.Mutex
+	title string
+	pct   int64
+}
+
+// progressEvent is a progress event
+// written as a JSON line.
+type progressEvent struct {
+	Event string `json:"event"`
+	Title string `json:"title"`
+	N     int64  `json:"n,omitempty"`
+	Total int64  `json:"total,omitempty"`
+}
+
+func (p *jsonProgress) Start(title string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.title = title
+	p.pct = -1
+	p.write(progressEvent{Event: "start", Title: title})
+}
+
+func (p *jsonProgress) Update(n, total int64) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if total > 0 {
+		// one event for each percent
+		pct := n * 100 / total
+		if pct == p.pct {
+			return
+		}
+		p.pct = pct
+	}
+	p.write(progressEvent{Event: "update", Title: p.title, N: n, Total: total})
+}
+
+func (p *jsonProgress) Done() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.write(progressEvent{Event: "done", Title: p.title})
+}
+
+// write writes an event.
+func (p *jsonProgress) write(e progressEvent) {
+	b, err := json.Marshal(e)
+	if err != nil {
+		return
+	}
+	fmt.Fprintf(Stderr, "%s\n", b)
+}
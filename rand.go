@@ -0,0 +1,102 @@
+// Copyright (c) 2015, J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD-style license that can be found in the LICENSE file.
+
+package cmdapp
+
+import (
+	"flag"
+	"math/rand"
+	"strconv"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// rnd is the random number generator
+// of the application.
+var (
+	randMutex sync.Mutex
+	seed      int64
+	seedSet   bool
+	rnd       *rand.Rand
+)
+
+// registerRandFlags sets the flags of the random numbers.
+func registerRandFlags(fs *flag.FlagSet) {
+	fs.Func("seed", "seed for random numbers, for reproducible results", func(s string) error {
+		v, err := strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			return errors.Errorf("invalid seed %q", s)
+		}
+		SetSeed(v)
+		return nil
+	})
+}
+
+// SetSeed sets the seed of the random number generator
+// returned by Rand.
+func SetSeed(s int64) {
+	randMutex.Lock()
+	defer randMutex.Unlock()
+	seed, seedSet = s, true
+	rnd = nil
+}
+
+// Seed returns the seed of the random number generator
+// returned by Rand.
+func Seed() int64 {
+	Rand()
+	randMutex.Lock()
+	defer randMutex.Unlock()
+	return seed
+}
+
+// Rand returns the random number generator
+// of the application,
+// that commands should use to sample or shuffle.
+// It is safe for concurrent use.
+//
+// The seed is set with the -seed flag,
+// so results can be reproduced.
+// If it is not set,
+// a seed is taken from the clock.
+// The seed is logged at the info level.
+func Rand() *rand.Rand {
+	randMutex.Lock()
+	defer randMutex.Unlock()
+	if rnd != nil {
+		return rnd
+	}
+	if !seedSet {
+		seed, seedSet = Now().UnixNano(), true
+	}
+	logger.Info("random seed", "seed", seed)
+	rnd = rand.New(&lockedSource{src: rand.NewSource(seed).(rand.Source64)})
+	return rnd
+}
+
+// lockedSource is a random source
+// safe for concurrent use.
+type lockedSource struct {
+	mu  sync.Mutex
+	src rand.Source64
+}
+
+func (s *lockedSource) Int63() int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.src.Int63()
+}
+
+func (s *lockedSource) Uint64() uint64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.src.Uint64()
+}
+
+func (s *lockedSource) Seed(v int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.src.Seed(v)
+}
@@ -0,0 +1,87 @@
+// Copyright (c) 2015, J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD-style license that can be found in the LICENSE file.
+
+package cmdapp
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Markers of the commands section of a README file.
+const (
+	readmeStart = "<!-- commands:start -->"
+	readmeEnd   = "<!-- commands:end -->"
+)
+
+// WriteCommandTable writes a Markdown table
+// with the name, arguments,
+// and short description of each command.
+func WriteCommandTable(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, "| Command | Usage | Description |\n")
+	fmt.Fprintf(bw, "| --- | --- | --- |\n")
+	walkCommands(func(path string, c Command, _ []*Group) {
+		if !c.Runnable() {
+			return
+		}
+		usage := strings.TrimSpace(appName() + " " + path + " " + c.Args())
+		fmt.Fprintf(bw, "| %s | `%s` | %s |\n", path, cellText(usage), cellText(capitalize(shortText(c))))
+	})
+	return bw.Flush()
+}
+
+// cellText escapes the pipes of a table cell.
+func cellText(s string) string {
+	return strings.Replace(s, "|", "\\|", -1)
+}
+
+// UpdateReadme updates the commands section
+// of a README file
+// with the table of commands.
+// The section is the text between the lines
+// '<!-- commands:start -->' and '<!-- commands:end -->'.
+// If the file does not have the markers,
+// a "Commands" section with the markers
+// is added at the end of the file.
+//
+// The command 'help readme' updates the file README.md
+// of the current directory.
+func UpdateReadme(name string) error {
+	data, err := os.ReadFile(name)
+	if err != nil && !os.IsNotExist(err) {
+		return errors.Wrap(err, "cmdapp: readme")
+	}
+	var tbl bytes.Buffer
+	WriteCommandTable(&tbl)
+	section := readmeStart + "\n\n" + tbl.String() + "\n" + readmeEnd
+
+	text := string(data)
+	i := strings.Index(text, readmeStart)
+	j := strings.Index(text, readmeEnd)
+	switch {
+	case i >= 0 && j > i:
+		text = text[:i] + section + text[j+len(readmeEnd):]
+	case i >= 0 || j >= 0:
+		return errors.Errorf("cmdapp: readme: %s: unmatched commands markers", name)
+	default:
+		if text != "" && !strings.HasSuffix(text, "\n") {
+			text += "\n"
+		}
+		if text != "" {
+			text += "\n"
+		}
+		text += "## Commands\n\n" + section + "\n"
+	}
+	if err := os.WriteFile(name, []byte(text), 0644); err != nil {
+		return errors.Wrap(err, "cmdapp: readme")
+	}
+	return nil
+}
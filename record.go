@@ -0,0 +1,187 @@
+// Copyright (c) 2015, J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD-style license that can be found in the LICENSE file.
+
+package cmdapp
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// A Session is the record of a run of the application,
+// used to replay it.
+type Session struct {
+	Time time.Time `json:"time"`
+
+	// Program is the name used to run the application.
+	Program string `json:"program"`
+
+	// Args are the arguments of the application.
+	Args []string `json:"args"`
+
+	// Env are the environment variables
+	// read by the application,
+	// other than secret variables.
+	Env map[string]string `json:"env,omitempty"`
+
+	Stdin    string `json:"stdin,omitempty"`
+	Stdout   string `json:"stdout,omitempty"`
+	Stderr   string `json:"stderr,omitempty"`
+	ExitCode int    `json:"exit_code"`
+}
+
+// ReadSession reads a session file.
+func ReadSession(name string) (*Session, error) {
+	b, err := os.ReadFile(name)
+	if err != nil {
+		return nil, errors.Wrap(err, "session")
+	}
+	s := &Session{}
+	if err := json.Unmarshal(b, s); err != nil {
+		return nil, errors.Wrapf(err, "session %s", name)
+	}
+	return s, nil
+}
+
+// Write writes a session file.
+func (s *Session) Write(name string) error {
+	b, err := json.MarshalIndent(s, "", "\t")
+	if err != nil {
+		return errors.Wrap(err, "session")
+	}
+	if err := WriteSecret(name, append(b, '\n')); err != nil {
+		return errors.Wrap(err, "session")
+	}
+	return nil
+}
+
+// recordFile is the file of the recorded session.
+var recordFile string
+
+// registerRecordFlags sets the flags of the session recording.
+func registerRecordFlags(fs *flag.FlagSet) {
+	fs.StringVar(&recordFile, "record", "", "record the session in the given file, to be replayed")
+}
+
+// A recorder records the session of the application.
+type recorder struct {
+	once sync.Once
+	s    Session
+
+	stdin  syncBuffer
+	stdout bytes.Buffer
+	stderr syncBuffer
+
+	oldOut  *os.File
+	oldErr  io.Writer
+	outW    *os.File
+	outDone chan struct{}
+}
+
+// startRecord starts the recording of the session,
+// if the -record flag is set
+// in the given application flags.
+func startRecord(fs *flag.FlagSet) (*recorder, error) {
+	if recordFile == "" {
+		return nil, nil
+	}
+	r := &recorder{
+		s: Session{
+			Time:    Now(),
+			Program: os.Args[0],
+			Env:     make(map[string]string),
+		},
+	}
+	fs.Visit(func(f *flag.Flag) {
+		if f.Name == "record" {
+			return
+		}
+		r.s.Args = append(r.s.Args, "-"+f.Name+"="+f.Value.String())
+	})
+	r.s.Args = append(r.s.Args, fs.Args()...)
+	for _, v := range EnvVars() {
+		if val, ok := os.LookupEnv(v.Name); ok && !v.Secret {
+			r.s.Env[v.Name] = val
+		}
+	}
+
+	// standard input
+	inR, inW, err := os.Pipe()
+	if err != nil {
+		return nil, errors.Wrap(err, "record")
+	}
+	go func(in *os.File) {
+		io.Copy(io.MultiWriter(inW, &r.stdin), in)
+		inW.Close()
+	}(os.Stdin)
+	os.Stdin = inR
+
+	// standard output
+	outR, outW, err := os.Pipe()
+	if err != nil {
+		return nil, errors.Wrap(err, "record")
+	}
+	r.oldOut, r.outW = os.Stdout, outW
+	r.outDone = make(chan struct{})
+	go func() {
+		io.Copy(io.MultiWriter(r.oldOut, &r.stdout), outR)
+		outR.Close()
+		close(r.outDone)
+	}()
+	os.Stdout = outW
+
+	r.oldErr = Stderr
+	Stderr = io.MultiWriter(r.oldErr, &r.stderr)
+	return r, nil
+}
+
+// finish finishes the recording
+// and writes the session file.
+// Only the first call is recorded.
+func (r *recorder) finish(code int) {
+	if r == nil {
+		return
+	}
+	r.once.Do(func() {
+		Flush()
+		os.Stdout = r.oldOut
+		r.outW.Close()
+		<-r.outDone
+		Stderr = r.oldErr
+
+		r.s.Stdin = r.stdin.String()
+		r.s.Stdout = r.stdout.String()
+		r.s.Stderr = r.stderr.String()
+		r.s.ExitCode = code
+		if err := r.s.Write(recordFile); err != nil {
+			fmt.Fprintf(Stderr, "%s: %v\n", Name, err)
+		}
+	})
+}
+
+// syncBuffer is a buffer safe for concurrent use.
+type syncBuffer struct {
+	mu sync.Mutex
+	b  bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.b.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.b.String()
+}
@@ -0,0 +1,224 @@
+// Copyright (c) 2015, J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD-style license that can be found in the LICENSE file.
+
+package cmdapp
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestRecord(t *testing.T) {
+	defer func(f string, w io.Writer) { recordFile, Stderr = f, w }(recordFile, Stderr)
+	defer func(in, out *os.File) { os.Stdin, os.Stdout = in, out }(os.Stdin, os.Stdout)
+	null, err := os.Open(os.DevNull)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer null.Close()
+	nullOut, err := os.OpenFile(os.DevNull, os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer nullOut.Close()
+	Stderr = io.Discard
+
+	tests := []struct {
+		args   []string
+		stdout string
+		stderr string
+		code   int
+		want   []string
+	}{
+		{
+			args:   []string{"-strict", "greet", "x"},
+			stdout: "hello x\n",
+			want:   []string{"-strict=true", "greet", "x"},
+		},
+		{
+			args:   []string{"-log-level", "debug", "fail"},
+			stderr: "failed\n",
+			code:   1,
+			want:   []string{"-log-level=debug", "fail"},
+		},
+		{
+			args: []string{"greet"},
+			want: []string{"greet"},
+		},
+	}
+	for _, test := range tests {
+		recordFile = ""
+		os.Stdin, os.Stdout = null, nullOut
+		fs := flag.NewFlagSet("recapp", flag.ContinueOnError)
+		fs.String("record", "", "")
+		fs.Bool("strict", false, "")
+		fs.String("log-level", "info", "")
+		name := filepath.Join(t.TempDir(), "session.json")
+		if err := fs.Parse(append([]string{"-record", name}, test.args...)); err != nil {
+			t.Fatal(err)
+		}
+		recordFile = name
+
+		r, err := startRecord(fs)
+		if err != nil {
+			t.Fatalf("%q: %v", test.args, err)
+		}
+		fmt.Fprint(Output(), test.stdout)
+		fmt.Fprint(Stderr, test.stderr)
+		r.finish(test.code)
+
+		s, err := ReadSession(name)
+		if err != nil {
+			t.Fatalf("%q: %v", test.args, err)
+		}
+		if !reflect.DeepEqual(s.Args, test.want) {
+			t.Errorf("%q: args %q, want %q", test.args, s.Args, test.want)
+		}
+		if s.Stdout != test.stdout || s.Stderr != test.stderr || s.ExitCode != test.code {
+			t.Errorf("%q: got %q, %q, %d, want %q, %q, %d", test.args, s.Stdout, s.Stderr, s.ExitCode, test.stdout, test.stderr, test.code)
+		}
+	}
+}
+
+func TestSession(t *testing.T) {
+	tests := []*Session{
+		{},
+		{
+			Time:     time.Date(2020, 3, 4, 5, 6, 7, 0, time.UTC),
+			Program:  "app",
+			Args:     []string{"-v", "greet", "a b"},
+			Env:      map[string]string{"APP_NAME": "x"},
+			Stdin:    "in\n",
+			Stdout:   "out\n",
+			Stderr:   "err\n",
+			ExitCode: 2,
+		},
+	}
+	for i, s := range tests {
+		name := filepath.Join(t.TempDir(), "session.json")
+		if err := s.Write(name); err != nil {
+			t.Fatalf("session %d: %v", i, err)
+		}
+		got, err := ReadSession(name)
+		if err != nil {
+			t.Fatalf("session %d: %v", i, err)
+		}
+		if !reflect.DeepEqual(got, s) {
+			t.Errorf("session %d: got %+v, want %+v", i, got, s)
+		}
+	}
+
+	bad := filepath.Join(t.TempDir(), "bad.json")
+	if err := os.WriteFile(bad, []byte("{"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ReadSession(bad); err == nil {
+		t.Errorf("invalid session: expecting an error")
+	}
+}
+
+func TestFirstDiff(t *testing.T) {
+	tests := []struct {
+		want, got string
+		diff      string
+	}{
+		{"a\nb\n", "a\nb\n", ""},
+		{"a\nb\n", "a\nc\n", `line 2: want "b", got "c"`},
+		{"a\n", "a\nb\n", `line 2: want "", got "b"`},
+		{"a\nb\n", "a", `line 2: want "b", got ""`},
+		{"", "x", `line 1: want "", got "x"`},
+	}
+	for _, test := range tests {
+		if d := firstDiff(test.want, test.got); d != test.diff {
+			t.Errorf("firstDiff(%q, %q) = %q, want %q", test.want, test.got, d, test.diff)
+		}
+	}
+}
+
+// replayHelper is the environment variable
+// that makes the test binary
+// a replayed application.
+const replayHelper = "CMDAPP_REPLAY_HELPER"
+
+// TestReplayHelper is the application
+// run by the replayed sessions:
+// it prints the value of the helper variable,
+// and the standard input,
+// and exits with code 3.
+func TestReplayHelper(t *testing.T) {
+	v := os.Getenv(replayHelper)
+	if v == "" {
+		t.Skip("helper of TestReplay")
+	}
+	in, _ := io.ReadAll(os.Stdin)
+	fmt.Printf("%s %s", v, in)
+	os.Exit(3)
+}
+
+func TestReplay(t *testing.T) {
+	t.Setenv(replayHelper, "hello")
+	args := []string{"-test.run=^TestReplayHelper$"}
+
+	tests := []struct {
+		name   string
+		s      Session
+		update bool
+		code   int
+	}{
+		{
+			name: "match",
+			s:    Session{Args: args, Stdin: "world\n", Stdout: "hello world\n", ExitCode: 3},
+		},
+		{
+			name: "other output",
+			s:    Session{Args: args, Stdin: "world\n", Stdout: "hello all\n", ExitCode: 3},
+			code: 1,
+		},
+		{
+			name: "other exit code",
+			s:    Session{Args: args, Stdin: "world\n", Stdout: "hello world\n"},
+			code: 1,
+		},
+		{
+			name:   "update",
+			s:      Session{Args: args, Stdin: "world\n", Stdout: "hello all\n"},
+			update: true,
+		},
+	}
+	for _, test := range tests {
+		name := filepath.Join(t.TempDir(), "session.json")
+		if err := test.s.Write(name); err != nil {
+			t.Fatal(err)
+		}
+		a := NewApp("replayapp", "a test application")
+		a.Stdout, a.Stderr = io.Discard, io.Discard
+
+		args := []string{"replay", name}
+		if test.update {
+			args = []string{"replay", "-update", name}
+		}
+		if code := a.Dispatch(args); code != test.code {
+			t.Errorf("%s: exit code %d, want %d", test.name, code, test.code)
+		}
+		if !test.update {
+			continue
+		}
+		s, err := ReadSession(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if s.Stdout != "hello world\n" || s.ExitCode != 3 {
+			t.Errorf("%s: session not updated: %q, %d", test.name, s.Stdout, s.ExitCode)
+		}
+		if code := a.Dispatch([]string{"replay", name}); code != 0 {
+			t.Errorf("%s: replay after update: exit code %d", test.name, code)
+		}
+	}
+}
@@ -0,0 +1,165 @@
+// Copyright (c) 2015, J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD-style license that can be found in the LICENSE file.
+
+package cmdapp
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// replayCmd is the replay command.
+type replayCmd struct {
+	update bool
+}
+
+func init() {
+	addBuiltin(&replayCmd{})
+}
+
+const replayLong = `
+Command replay runs again a session recorded with the -record flag of the
+application, for example:
+
+    <app> -record session.json <command> [<args>...]
+
+The application is run with the recorded arguments, environment variables,
+and standard input, and its output and exit code are compared with the
+recorded ones. The differences are reported as an error.
+
+Secret environment variables are not recorded, so they are taken from the
+current environment.
+
+The flags are:
+
+    -update
+      Replaces the recorded output and exit code with the results of the
+      new run.
+`
+
+func (r *replayCmd) Name() string   { return "replay" }
+func (r *replayCmd) Args() string   { return "[-update] <session-file>" }
+func (r *replayCmd) Short() string  { return "replays a recorded session" }
+func (r *replayCmd) Long() string   { return replayLong }
+func (r *replayCmd) Runnable() bool { return true }
+
+func (r *replayCmd) Register(fs *flag.FlagSet) {
+	fs.BoolVar(&r.update, "update", false, "update the recorded output")
+}
+
+func (r *replayCmd) Run(args []string) error {
+	if len(args) != 1 {
+		return errors.New("expecting a session file")
+	}
+	s, err := ReadSession(args[0])
+	if err != nil {
+		return err
+	}
+	exe, err := os.Executable()
+	if err != nil {
+		return err
+	}
+
+	cmd := exec.Command(exe, s.Args...)
+	if s.Program != "" {
+		cmd.Args[0] = s.Program
+	}
+	cmd.Env = replayEnv(s.Env)
+	cmd.Stdin = strings.NewReader(s.Stdin)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	code := 0
+	if err := cmd.Run(); err != nil {
+		ee, ok := err.(*exec.ExitError)
+		if !ok {
+			return err
+		}
+		code = ee.ExitCode()
+	}
+
+	if r.update {
+		s.Time = Now()
+		s.Stdout = stdout.String()
+		s.Stderr = stderr.String()
+		s.ExitCode = code
+		if err := s.Write(args[0]); err != nil {
+			return err
+		}
+		fmt.Fprintln(Output(), Mark(Success, "session updated"))
+		return nil
+	}
+
+	var diff []string
+	if code != s.ExitCode {
+		diff = append(diff, fmt.Sprintf("    exit code: want %d, got %d", s.ExitCode, code))
+	}
+	if d := firstDiff(s.Stdout, stdout.String()); d != "" {
+		diff = append(diff, "    stdout: "+d)
+	}
+	if d := firstDiff(s.Stderr, stderr.String()); d != "" {
+		diff = append(diff, "    stderr: "+d)
+	}
+	if len(diff) > 0 {
+		return errors.Errorf("session %s differs:\n%s", args[0], strings.Join(diff, "\n"))
+	}
+	fmt.Fprintln(Output(), Mark(Success, "session matches"))
+	return nil
+}
+
+// replayEnv returns the environment of a replayed session:
+// the current environment,
+// without the variables read by the application,
+// and the recorded variables.
+func replayEnv(rec map[string]string) []string {
+	read := make(map[string]bool)
+	for _, v := range EnvVars() {
+		if !v.Secret {
+			read[v.Name] = true
+		}
+	}
+	var env []string
+	for _, e := range os.Environ() {
+		nm := e
+		if i := strings.Index(e, "="); i >= 0 {
+			nm = e[:i]
+		}
+		if read[nm] {
+			continue
+		}
+		env = append(env, e)
+	}
+	for nm, val := range rec {
+		env = append(env, nm+"="+val)
+	}
+	return env
+}
+
+// firstDiff returns a description
+// of the first line that differs between two texts.
+func firstDiff(want, got string) string {
+	if want == got {
+		return ""
+	}
+	wl := strings.Split(want, "\n")
+	gl := strings.Split(got, "\n")
+	for i := 0; ; i++ {
+		var w, g string
+		if i < len(wl) {
+			w = wl[i]
+		}
+		if i < len(gl) {
+			g = gl[i]
+		}
+		if w != g || i >= len(wl) || i >= len(gl) {
+			return fmt.Sprintf("line %d: want %q, got %q", i+1, w, g)
+		}
+	}
+}
@@ -0,0 +1,181 @@
+// Copyright (c) 2015, J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD-style license that can be found in the LICENSE file.
+
+package cmdapp
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+)
+
+// A Report is the summary of a run of the application,
+// written as JSON with the -report flag.
+type Report struct {
+	// Command is the name of the command.
+	Command string `json:"command"`
+
+	// Args are the arguments of the command.
+	Args []string `json:"args"`
+
+	// Start is the time the command started.
+	Start time.Time `json:"start"`
+
+	// Duration is the duration of the command,
+	// in seconds.
+	Duration float64 `json:"duration"`
+
+	// ExitCode is the exit code of the application.
+	ExitCode int `json:"exit_code"`
+
+	// Error is the error of the command,
+	// if any.
+	Error string `json:"error,omitempty"`
+
+	// Warnings is the number of warnings
+	// reported by the command.
+	Warnings int `json:"warnings"`
+
+	// Artifacts are the files produced by the command.
+	Artifacts []Artifact `json:"artifacts"`
+}
+
+// An Artifact is a file produced by a command,
+// registered with ReportArtifact.
+type Artifact struct {
+	// Path is the path of the file.
+	Path string `json:"path"`
+
+	// Desc is a short description of the file.
+	Desc string `json:"desc,omitempty"`
+}
+
+// ShowReport,
+// if set,
+// prints a summary of the run
+// in Stderr,
+// when the application finishes.
+// It is not printed in porcelain mode.
+var ShowReport bool
+
+// reportFile is the file of the JSON report,
+// set with the -report flag.
+var reportFile string
+
+// registerReportFlags sets the report flags
+// of the application.
+func registerReportFlags(fs *flag.FlagSet) {
+	fs.StringVar(&reportFile, "report", "", "write a JSON summary of the run to `file`")
+}
+
+// report is the report of the running command.
+var (
+	reportMutex sync.Mutex
+	report      *Report
+)
+
+// ReportArtifact registers a file produced by the command,
+// that is included in the report of the run.
+func ReportArtifact(path, desc string) {
+	reportMutex.Lock()
+	defer reportMutex.Unlock()
+	if report == nil {
+		return
+	}
+	report.Artifacts = append(report.Artifacts, Artifact{Path: path, Desc: desc})
+}
+
+// startReport starts the report of a command,
+// given by its path from the application.
+// Only the first command of the run is reported.
+func startReport(path string, args []string) {
+	reportMutex.Lock()
+	defer reportMutex.Unlock()
+	if report == nil || report.Command != "" {
+		return
+	}
+	report.Command = path
+	report.Args = append([]string{}, args...)
+	report.Start = Now()
+}
+
+// reportError sets the error of the report.
+func reportError(err error) {
+	reportMutex.Lock()
+	defer reportMutex.Unlock()
+	if report == nil {
+		return
+	}
+	report.Error = err.Error()
+}
+
+// openReport enables the report of the run,
+// if it is requested.
+// It returns the function that writes the report
+// when the application finishes.
+func openReport() func(code int) {
+	if reportFile == "" && !ShowReport {
+		return nil
+	}
+	reportMutex.Lock()
+	report = &Report{Artifacts: []Artifact{}}
+	reportMutex.Unlock()
+	return finishReport
+}
+
+// finishReport writes the report of the run.
+func finishReport(code int) {
+	reportMutex.Lock()
+	r := *report
+	reportMutex.Unlock()
+	if r.Command == "" {
+		return
+	}
+	r.ExitCode = code
+	r.Warnings = Warnings()
+	r.Duration = Since(r.Start).Seconds()
+
+	if ShowReport && !Porcelain() {
+		printReport(Stderr, &r)
+	}
+	if reportFile == "" {
+		return
+	}
+	b, err := json.MarshalIndent(&r, "", "\t")
+	if err != nil {
+		logger.Info("report", "error", err.Error())
+		return
+	}
+	if err := WriteFileAtomic(reportFile, append(b, '\n'), SharedMode); err != nil {
+		fmt.Fprintf(Stderr, "%s: report: %v\n", Name, err)
+	}
+}
+
+// printReport prints the summary of a run.
+func printReport(w io.Writer, r *Report) {
+	status := "ok"
+	if r.ExitCode != 0 {
+		status = fmt.Sprintf("failed (exit code %d)", r.ExitCode)
+	}
+	fmt.Fprintf(w, "\nSummary:\n")
+	fmt.Fprintf(w, "    %-10s %s\n", "command:", strings.TrimSpace(r.Command+" "+strings.Join(r.Args, " ")))
+	fmt.Fprintf(w, "    %-10s %s\n", "status:", status)
+	fmt.Fprintf(w, "    %-10s %s\n", "duration:", time.Duration(r.Duration*float64(time.Second)).Round(time.Millisecond))
+	fmt.Fprintf(w, "    %-10s %d\n", "warnings:", r.Warnings)
+	if len(r.Artifacts) == 0 {
+		return
+	}
+	fmt.Fprintf(w, "    %-10s\n", "artifacts:")
+	for _, a := range r.Artifacts {
+		if a.Desc == "" {
+			fmt.Fprintf(w, "        %s\n", a.Path)
+			continue
+		}
+		fmt.Fprintf(w, "        %s (%s)\n", a.Path, a.Desc)
+	}
+}
@@ -0,0 +1,137 @@
+// Copyright (c) 2015, J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD-style license that can be found in the LICENSE file.
+
+package cmdapp
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+func TestReport(t *testing.T) {
+	defer func(file string, show, p bool, w io.Writer, c Clock) {
+		reportFile, ShowReport, porcelain, Stderr = file, show, p, w
+		SetClock(c)
+		reportMutex.Lock()
+		report = nil
+		reportMutex.Unlock()
+	}(reportFile, ShowReport, porcelain, Stderr, getClock())
+
+	clock := NewFakeClock(time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC))
+	SetClock(clock)
+
+	a := NewApp("reportapp", "a test application")
+	a.Stdout = io.Discard
+	a.Add(&mountCmd{name: "build", run: func(c *mountCmd, args []string) error {
+		clock.Advance(1500 * time.Millisecond)
+		Warn("a warning")
+		ReportArtifact("out.txt", "the output")
+		ReportArtifact("log.txt", "")
+		if len(args) > 0 && args[0] == "fail" {
+			return errors.New("boom")
+		}
+		return nil
+	}})
+
+	arts := []Artifact{{Path: "out.txt", Desc: "the output"}, {Path: "log.txt"}}
+	tests := []struct {
+		name    string
+		args    []string
+		json    bool
+		show    bool
+		porc    bool
+		want    *Report
+		summary []string
+	}{
+		{name: "no report", args: []string{"build"}},
+		{
+			name: "json",
+			args: []string{"build", "-n", "2", "x"},
+			json: true,
+			want: &Report{Command: "build", Args: []string{"x"}, Duration: 1.5, Warnings: 1, Artifacts: arts},
+		},
+		{
+			name: "failed",
+			args: []string{"build", "fail"},
+			json: true,
+			show: true,
+			want: &Report{Command: "build", Args: []string{"fail"}, Duration: 1.5, ExitCode: 1, Error: "boom", Warnings: 1, Artifacts: arts},
+			summary: []string{
+				"command:   build fail\n",
+				"status:    failed (exit code 1)\n",
+				"duration:  1.5s\n",
+				"warnings:  1\n",
+				"artifacts:\n        out.txt (the output)\n        log.txt\n",
+			},
+		},
+		{
+			name:    "summary",
+			args:    []string{"build"},
+			show:    true,
+			summary: []string{"command:   build\n", "status:    ok\n"},
+		},
+		{name: "porcelain", args: []string{"build"}, show: true, porc: true},
+	}
+	for _, test := range tests {
+		var errOut bytes.Buffer
+		Stderr = &errOut
+		ShowReport, porcelain = test.show, test.porc
+		reportFile = ""
+		if test.json {
+			reportFile = filepath.Join(t.TempDir(), "report.json")
+		}
+		reportMutex.Lock()
+		report = nil
+		reportMutex.Unlock()
+
+		done := openReport()
+		if done == nil {
+			if test.json || test.show {
+				t.Errorf("%s: report not opened", test.name)
+			}
+			continue
+		}
+		done(a.Dispatch(test.args))
+
+		got := errOut.String()
+		if test.porc && strings.Contains(got, "Summary:") {
+			t.Errorf("%s: summary printed in porcelain mode: %q", test.name, got)
+		}
+		for _, s := range test.summary {
+			if !strings.Contains(got, s) {
+				t.Errorf("%s: summary %q, want %q", test.name, got, s)
+			}
+		}
+
+		if !test.json {
+			continue
+		}
+		b, err := os.ReadFile(reportFile)
+		if err != nil {
+			t.Errorf("%s: %v", test.name, err)
+			continue
+		}
+		var r Report
+		if err := json.Unmarshal(b, &r); err != nil {
+			t.Errorf("%s: %v", test.name, err)
+			continue
+		}
+		if ws := clock.Now().Add(-1500 * time.Millisecond); !r.Start.Equal(ws) {
+			t.Errorf("%s: start %v, want %v", test.name, r.Start, ws)
+		}
+		r.Start = time.Time{}
+		if !reflect.DeepEqual(&r, test.want) {
+			t.Errorf("%s: got %+v, want %+v", test.name, r, *test.want)
+		}
+	}
+}
@@ -0,0 +1,63 @@
+// Copyright (c) 2015, J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD-style license that can be found in the LICENSE file.
+
+package cmdapp
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/pkg/errors"
+)
+
+// ErrNoRoot is the error returned by FindRoot
+// when no project root is found.
+var ErrNoRoot = errors.New("project root not found")
+
+// RootMarkers are the names of the files or directories
+// that mark the root of a project,
+// used by Root.
+// By default,
+// a directory with a '.git' directory is a project root.
+var RootMarkers = []string{".git"}
+
+// FindRoot returns the root of the project
+// that contains the working directory:
+// the nearest directory,
+// walking up from the working directory,
+// that has a file or directory
+// with any of the given names.
+// If no directory is found,
+// it returns ErrNoRoot.
+func FindRoot(markers ...string) (string, error) {
+	dir, err := os.Getwd()
+	if err != nil {
+		return "", errors.Wrap(err, "project root")
+	}
+	for {
+		for _, m := range markers {
+			if _, err := os.Stat(filepath.Join(dir, m)); err == nil {
+				return dir, nil
+			}
+		}
+		up := filepath.Dir(dir)
+		if up == dir {
+			return "", ErrNoRoot
+		}
+		dir = up
+	}
+}
+
+// Root returns the root of the project
+// that contains the working directory,
+// using RootMarkers.
+// It returns false if the working directory
+// is not inside a project.
+func Root() (string, bool) {
+	dir, err := FindRoot(RootMarkers...)
+	if err != nil {
+		return "", false
+	}
+	return dir, true
+}
@@ -0,0 +1,251 @@
+// Copyright (c) 2015, J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD-style license that can be found in the LICENSE file.
+
+package cmdapp
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// A Rotation is a rotation policy of the log file.
+// When the log file is rotated,
+// it is renamed with the time of the rotation as suffix,
+// and a new log file is created.
+type Rotation struct {
+	// MaxSize is the size, in bytes,
+	// at which the log file is rotated.
+	// If zero,
+	// the file is not rotated by size.
+	MaxSize int64
+
+	// MaxAge is the age of the log file
+	// at which it is rotated.
+	// If zero,
+	// the file is not rotated by age.
+	MaxAge time.Duration
+
+	// MaxBackups is the number of rotated files
+	// that are kept.
+	// If zero,
+	// all rotated files are kept.
+	MaxBackups int
+
+	// Retention is the time a rotated file is kept.
+	// If zero,
+	// rotated files are kept regardless of its age.
+	Retention time.Duration
+}
+
+// LogRotation is the rotation policy of the log file.
+// By default,
+// the log file is never rotated.
+// The keys of LogConfig,
+// if they are set,
+// take precedence over LogRotation.
+var LogRotation Rotation
+
+// LogConfig are the configuration keys
+// of the rotation policy of the log file.
+// They are not part of the configuration schema
+// unless the application defines them,
+// with:
+//
+//	cmdapp.DefineConfig(cmdapp.LogConfig...)
+var LogConfig = []Key{
+	{Name: "log.max-size", Type: IntKey, Desc: "size, in bytes, at which the log file is rotated"},
+	{Name: "log.max-age", Type: DurationKey, Desc: "age at which the log file is rotated"},
+	{Name: "log.max-backups", Type: IntKey, Desc: "number of rotated log files that are kept"},
+	{Name: "log.retention", Type: DurationKey, Desc: "time a rotated log file is kept"},
+}
+
+// logRotation returns the rotation policy of the log file,
+// LogRotation with the values
+// of the LogConfig keys that are set.
+func logRotation() (Rotation, error) {
+	rot := LogRotation
+	var err error
+	if v := ConfigValue("log.max-size"); v != "" {
+		if rot.MaxSize, err = strconv.ParseInt(v, 10, 64); err != nil {
+			return rot, rotationError("log.max-size", v)
+		}
+	}
+	if v := ConfigValue("log.max-age"); v != "" {
+		if rot.MaxAge, err = time.ParseDuration(v); err != nil {
+			return rot, rotationError("log.max-age", v)
+		}
+	}
+	if v := ConfigValue("log.max-backups"); v != "" {
+		if rot.MaxBackups, err = strconv.Atoi(v); err != nil {
+			return rot, rotationError("log.max-backups", v)
+		}
+	}
+	if v := ConfigValue("log.retention"); v != "" {
+		if rot.Retention, err = time.ParseDuration(v); err != nil {
+			return rot, rotationError("log.retention", v)
+		}
+	}
+	return rot, nil
+}
+
+// rotationError returns the error
+// of an invalid value of a rotation key.
+func rotationError(key, val string) error {
+	return errors.Errorf("log: config key %s: invalid value %q", key, val)
+}
+
+// rotateFmt is the time format used as suffix of a rotated file.
+const rotateFmt = "20060102T150405.000"
+
+// rotFile is a log file that rotates
+// following a rotation policy.
+type rotFile struct {
+	mu    sync.Mutex
+	name  string
+	rot   Rotation
+	f     *os.File
+	size  int64
+	start time.Time
+}
+
+// openRotFile opens a log file
+// with a rotation policy.
+func openRotFile(name string, rot Rotation) (*rotFile, error) {
+	r := &rotFile{name: name, rot: rot}
+	if err := r.open(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func (r *rotFile) open() error {
+	f, err := os.OpenFile(r.name, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return errors.Wrap(err, "log")
+	}
+	st, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return errors.Wrap(err, "log")
+	}
+	r.f = f
+	r.size = st.Size()
+	r.start = Now()
+	if r.size > 0 {
+		r.start = logStart(r.name, st.ModTime())
+	}
+	return nil
+}
+
+// logStart returns the creation time of a log file,
+// the time of its first record.
+// If the first record has no time,
+// it returns the given time.
+func logStart(name string, def time.Time) time.Time {
+	f, err := os.Open(name)
+	if err != nil {
+		return def
+	}
+	defer f.Close()
+	ln, err := bufio.NewReader(f).ReadBytes('\n')
+	if err != nil && len(ln) == 0 {
+		return def
+	}
+	var rec struct {
+		Time time.Time `json:"time"`
+	}
+	if err := json.Unmarshal(ln, &rec); err != nil || rec.Time.IsZero() {
+		return def
+	}
+	return rec.Time
+}
+
+func (r *rotFile) Write(p []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.needRotation(len(p)) {
+		if err := r.rotate(); err != nil {
+			return 0, err
+		}
+	}
+	n, err := r.f.Write(p)
+	r.size += int64(n)
+	return n, err
+}
+
+// needRotation reports whether the file should be rotated
+// before writing n bytes.
+func (r *rotFile) needRotation(n int) bool {
+	if r.size == 0 {
+		return false
+	}
+	if r.rot.MaxSize > 0 && r.size+int64(n) > r.rot.MaxSize {
+		return true
+	}
+	if r.rot.MaxAge > 0 && Since(r.start) > r.rot.MaxAge {
+		return true
+	}
+	return false
+}
+
+// rotate renames the current file,
+// removes old rotated files,
+// and opens a new file.
+func (r *rotFile) rotate() error {
+	if err := r.f.Close(); err != nil {
+		return errors.Wrap(err, "log")
+	}
+	bk := r.name + "." + Now().Format(rotateFmt)
+	if err := os.Rename(r.name, bk); err != nil {
+		return errors.Wrap(err, "log")
+	}
+	r.prune()
+	return r.open()
+}
+
+// prune removes the rotated files
+// outside of the retention policy.
+func (r *rotFile) prune() {
+	if r.rot.MaxBackups <= 0 && r.rot.Retention <= 0 {
+		return
+	}
+	files, _ := filepath.Glob(r.name + ".*")
+	var bks []string
+	rotated := make(map[string]time.Time)
+	for _, f := range files {
+		if t, err := time.ParseInLocation(rotateFmt, f[len(r.name)+1:], time.Local); err == nil {
+			bks = append(bks, f)
+			rotated[f] = t
+		}
+	}
+	// newest first
+	sort.Sort(sort.Reverse(sort.StringSlice(bks)))
+	for i, f := range bks {
+		if r.rot.MaxBackups > 0 && i >= r.rot.MaxBackups {
+			os.Remove(f)
+			continue
+		}
+		if r.rot.Retention <= 0 {
+			continue
+		}
+		if Since(rotated[f]) > r.rot.Retention {
+			os.Remove(f)
+		}
+	}
+}
+
+func (r *rotFile) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.f.Close()
+}
@@ -0,0 +1,98 @@
+// Copyright (c) 2015, J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD-style license that can be found in the LICENSE file.
+
+package cmdapp
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestRotationAge(t *testing.T) {
+	now := time.Date(2020, 1, 1, 12, 0, 0, 0, time.Local)
+	SetClock(NewFakeClock(now))
+	defer SetClock(nil)
+
+	tests := []struct {
+		name  string
+		first string
+		want  bool
+	}{
+		{"old file", `{"time":"` + now.Add(-2*time.Hour).Format(time.RFC3339Nano) + `","msg":"a"}`, true},
+		{"new file", `{"time":"` + now.Add(-30*time.Minute).Format(time.RFC3339Nano) + `","msg":"a"}`, false},
+		{"without time", `not a record`, false},
+	}
+	for _, test := range tests {
+		name := filepath.Join(t.TempDir(), "app.log")
+		// the modification time is the current time,
+		// as the file was written by a recent run
+		if err := os.WriteFile(name, []byte(test.first+"\n"+`{"msg":"b"}`+"\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		r, err := openRotFile(name, Rotation{MaxAge: time.Hour})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := r.Write([]byte(`{"msg":"c"}` + "\n")); err != nil {
+			t.Fatal(err)
+		}
+		r.Close()
+
+		bks, _ := filepath.Glob(name + ".*")
+		if got := len(bks) > 0; got != test.want {
+			t.Errorf("%s: rotated %v, want %v", test.name, got, test.want)
+		}
+	}
+}
+
+func TestLogRotation(t *testing.T) {
+	cfgMutex.Lock()
+	prev := cfgValues
+	cfgMutex.Unlock()
+	defer func() {
+		cfgMutex.Lock()
+		cfgValues = prev
+		cfgMutex.Unlock()
+	}()
+	defer func(r Rotation) { LogRotation = r }(LogRotation)
+	LogRotation = Rotation{MaxSize: 100, MaxBackups: 3}
+
+	tests := []struct {
+		vals map[string]string
+		want Rotation
+		err  bool
+	}{
+		{map[string]string{}, Rotation{MaxSize: 100, MaxBackups: 3}, false},
+		{map[string]string{
+			"log.max-size":    "2048",
+			"log.max-age":     "24h",
+			"log.max-backups": "5",
+			"log.retention":   "720h",
+		}, Rotation{MaxSize: 2048, MaxAge: 24 * time.Hour, MaxBackups: 5, Retention: 720 * time.Hour}, false},
+		{map[string]string{"log.max-age": "1d"}, Rotation{}, true},
+		{map[string]string{"log.max-size": "big"}, Rotation{}, true},
+	}
+	for _, test := range tests {
+		cfgMutex.Lock()
+		cfgValues = test.vals
+		cfgMutex.Unlock()
+
+		rot, err := logRotation()
+		if test.err {
+			if err == nil {
+				t.Errorf("%v: want an error", test.vals)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%v: %v", test.vals, err)
+			continue
+		}
+		if rot != test.want {
+			t.Errorf("%v: got %+v, want %+v", test.vals, rot, test.want)
+		}
+	}
+}
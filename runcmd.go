@@ -0,0 +1,78 @@
+// Copyright (c) 2015, J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD-style license that can be found in the LICENSE file.
+
+package cmdapp
+
+import (
+	"flag"
+
+	"github.com/pkg/errors"
+)
+
+// runCmd is the run command.
+type runCmd struct {
+	jobs   int
+	output TaskOutput
+}
+
+func init() {
+	addBuiltin(&runCmd{})
+}
+
+const runCmdLong = `
+Command run executes the given commands, as well as its prerequisites, each
+command after its prerequisites. Commands are run with its default flags and
+without arguments. A prerequisite that is up to date is skipped.
+
+The flags are:
+
+    -j <number>
+      Sets the maximum number of commands run concurrently. Independent
+      commands can be run at the same time. By default only one command
+      is run at a time.
+
+    -output-mode <mode>
+      Sets how the output of concurrent commands is shown. Valid values are
+      'prefix' (the default), that prefixes each line with the command name,
+      'group', that writes the output of a command when it finishes, and
+      'raw', that writes the output as is. Only commands that accept an
+      output writer are prefixed or grouped.
+`
+
+func (r *runCmd) Name() string   { return "run" }
+func (r *runCmd) Args() string   { return "[-j <number>] [-output-mode <mode>] <command>..." }
+func (r *runCmd) Short() string  { return "runs commands and their prerequisites" }
+func (r *runCmd) Long() string   { return runCmdLong }
+func (r *runCmd) Runnable() bool { return true }
+
+func (r *runCmd) Register(fs *flag.FlagSet) {
+	r.output = PrefixOutput
+	fs.IntVar(&r.jobs, "j", 1, "maximum number of concurrent commands")
+	fs.Var(&r.output, "output-mode", "output of concurrent commands: prefix, group, or raw")
+}
+
+func (r *runCmd) Run(args []string) error {
+	if len(args) == 0 {
+		return errors.New("expecting a command")
+	}
+	var cmds []Command
+	targets := make(map[string]bool)
+	for _, a := range args {
+		nm := normName(a)
+		c, ok := lookup(nm)
+		if !ok || !c.Runnable() {
+			return errors.Errorf("unknown command %s", a)
+		}
+		if c == Command(r) {
+			return errors.New("run can not run itself")
+		}
+		cmds = append(cmds, c)
+		targets[nm] = true
+	}
+	g, err := newGraph(cmds)
+	if err != nil {
+		return err
+	}
+	return g.run(targets, r.jobs, r.output)
+}
@@ -0,0 +1,161 @@
+// Copyright (c) 2015, J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD-style license that can be found in the LICENSE file.
+
+package cmdapp
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestRunCmd(t *testing.T) {
+	tests := []struct {
+		name string
+		cmds []*depCmd
+		args []string
+		code int
+		want string
+	}{
+		{
+			name: "order",
+			cmds: []*depCmd{
+				{name: "build", requires: []string{"fetch", "gen"}},
+				{name: "fetch"},
+				{name: "gen", requires: []string{"fetch"}},
+				{name: "test", requires: []string{"build"}},
+			},
+			args: []string{"run", "test", "gen"},
+			want: "fetch gen build test",
+		},
+		{
+			name: "up to date",
+			cmds: []*depCmd{
+				{name: "build", requires: []string{"fetch"}, upToDate: true},
+				{name: "fetch", upToDate: true},
+			},
+			args: []string{"run", "build"},
+			want: "build",
+		},
+		{
+			name: "invalid target",
+			cmds: []*depCmd{
+				{name: "build", requires: []string{"fetch"}, needArg: true},
+				{name: "fetch"},
+			},
+			args: []string{"run", "build"},
+			code: ExitUsage,
+			want: "fetch",
+		},
+		{
+			name: "invalid prerequisite",
+			cmds: []*depCmd{
+				{name: "build", requires: []string{"fetch"}},
+				{name: "fetch", needArg: true},
+			},
+			args: []string{"run", "build"},
+			code: ExitUsage,
+		},
+		{
+			name: "cycle",
+			cmds: []*depCmd{
+				{name: "build", requires: []string{"gen"}},
+				{name: "gen", requires: []string{"fetch"}},
+				{name: "fetch", requires: []string{"build"}},
+			},
+			args: []string{"run", "build"},
+			code: 1,
+		},
+		{
+			name: "self",
+			cmds: []*depCmd{
+				{name: "build", requires: []string{"build"}},
+			},
+			args: []string{"run", "build"},
+			code: 1,
+		},
+		{
+			name: "unknown command",
+			cmds: []*depCmd{{name: "build"}},
+			args: []string{"run", "deploy"},
+			code: 1,
+		},
+		{
+			name: "run itself",
+			cmds: []*depCmd{{name: "build"}},
+			args: []string{"run", "run"},
+			code: 1,
+		},
+	}
+	for _, test := range tests {
+		a, log := depApp(test.cmds)
+		if code := a.Dispatch(test.args); code != test.code {
+			t.Errorf("%s: exit code %d, want %d", test.name, code, test.code)
+		}
+		if got := strings.Join(*log, " "); got != test.want {
+			t.Errorf("%s: run %q, want %q", test.name, got, test.want)
+		}
+	}
+}
+
+// TestRunCmdJobs checks that concurrent commands
+// are run after their prerequisites.
+func TestRunCmdJobs(t *testing.T) {
+	cmds := []*depCmd{
+		{name: "a"},
+		{name: "b"},
+		{name: "c", requires: []string{"a"}},
+		{name: "d", requires: []string{"b", "c"}},
+		{name: "e", requires: []string{"a"}},
+	}
+	for i := 0; i < 20; i++ {
+		a, log := depApp(cmds)
+		if code := a.Dispatch([]string{"run", "-j", "4", "-output-mode", "raw", "d", "e"}); code != 0 {
+			t.Fatalf("exit code %d", code)
+		}
+		pos := make(map[string]int)
+		for i, nm := range *log {
+			pos[nm] = i
+		}
+		if len(pos) != len(cmds) {
+			t.Fatalf("run %v, want all the commands once", *log)
+		}
+		for _, c := range cmds {
+			for _, r := range c.requires {
+				if pos[r] > pos[c.name] {
+					t.Errorf("run %v: %s before its prerequisite %s", *log, c.name, r)
+				}
+			}
+		}
+	}
+}
+
+func TestRunCmdOutput(t *testing.T) {
+	var out bytes.Buffer
+	a := NewApp("testapp", "a test application")
+	a.Stdout, a.Stderr = &out, io.Discard
+	a.Add(&echoCmd{})
+
+	// the commands are run in order,
+	// so the output mode is reset on each run
+	tests := []struct {
+		args []string
+		want string
+	}{
+		{[]string{"run", "-j", "2", "echo-test"}, "[echo-test]"},
+		{[]string{"run", "-j", "2", "-output-mode", "raw", "echo-test"}, ""},
+		{[]string{"run", "-j", "2", "echo-test"}, "[echo-test]"},
+	}
+	for _, test := range tests {
+		out.Reset()
+		if code := a.Dispatch(test.args); code != 0 {
+			t.Errorf("%v: exit code %d", test.args, code)
+			continue
+		}
+		if got := strings.TrimSpace(out.String()); got != test.want {
+			t.Errorf("%v: output %q, want %q", test.args, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,179 @@
+// Copyright (c) 2015, J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD-style license that can be found in the LICENSE file.
+
+package cmdapp
+
+import (
+	"bytes"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/pkg/errors"
+)
+
+// File modes of the files and directories
+// written by the application.
+const (
+	// SecretMode is the mode of the files
+	// that only the user can read,
+	// for example tokens or configuration files.
+	SecretMode fs.FileMode = 0600
+
+	// SharedMode is the mode of the files
+	// that can be read by other users.
+	SharedMode fs.FileMode = 0644
+
+	// SecretDirMode is the mode of the directories
+	// that only the user can read.
+	SecretDirMode fs.FileMode = 0700
+
+	// SharedDirMode is the mode of the directories
+	// that can be read by other users.
+	SharedDirMode fs.FileMode = 0755
+)
+
+// WriteFileAtomic writes data to a file,
+// with the given mode.
+// The file is written in a temporary file
+// in the same directory,
+// that replaces the file when it is complete,
+// so readers never see a partial file.
+//
+// The mode is set exactly,
+// regardless of the umask,
+// and of the mode of a previous file,
+// so a file with secrets
+// is never readable by other users.
+// Missing parent directories are created,
+// private if the file is private.
+// If the file is a symbolic link,
+// the target of the link is written,
+// and the link is kept.
+//
+// It writes the file in the operating system,
+// commands that should respect DryRun
+// should use FS.
+func WriteFileAtomic(name string, data []byte, perm fs.FileMode) error {
+	return WriteAtomic(name, perm, func(w io.Writer) error {
+		_, err := io.Copy(w, bytes.NewReader(data))
+		return err
+	})
+}
+
+// WriteSecret writes data to a file
+// that only the user can read.
+func WriteSecret(name string, data []byte) error {
+	return WriteFileAtomic(name, data, SecretMode)
+}
+
+// WriteAtomic writes a file with the given mode,
+// as WriteFileAtomic,
+// with the content written by a function.
+// If the function returns an error,
+// the file is not changed.
+func WriteAtomic(name string, perm fs.FileMode, write func(io.Writer) error) error {
+	name, err := linkTarget(name)
+	if err != nil {
+		return errors.Wrapf(err, "write %s", name)
+	}
+	dir := filepath.Dir(name)
+	if err := MkdirAllMode(dir, dirMode(perm)); err != nil {
+		return errors.Wrapf(err, "write %s", name)
+	}
+	f, err := os.CreateTemp(dir, "."+filepath.Base(name)+".*")
+	if err != nil {
+		return errors.Wrapf(err, "write %s", name)
+	}
+	if err := f.Chmod(perm); err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return errors.Wrapf(err, "write %s", name)
+	}
+	if err := write(f); err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return errors.Wrapf(err, "write %s", name)
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(f.Name())
+		return errors.Wrapf(err, "write %s", name)
+	}
+	if err := os.Rename(f.Name(), name); err != nil {
+		os.Remove(f.Name())
+		return errors.Wrapf(err, "write %s", name)
+	}
+	return nil
+}
+
+// MkdirAllMode creates a directory,
+// and any necessary parents,
+// with the given mode.
+// The directory is created with a temporary name,
+// and renamed when its mode is set,
+// so it is never accessible with a wider mode.
+// If the directory already exists,
+// its mode is not changed.
+func MkdirAllMode(name string, perm fs.FileMode) error {
+	if fi, err := os.Stat(name); err == nil && fi.IsDir() {
+		return nil
+	}
+	parent := filepath.Dir(name)
+	if err := os.MkdirAll(parent, perm|0700); err != nil {
+		return errors.Wrapf(err, "mkdir %s", name)
+	}
+	tmp, err := os.MkdirTemp(parent, "."+filepath.Base(name)+".*")
+	if err != nil {
+		return errors.Wrapf(err, "mkdir %s", name)
+	}
+	if err := os.Chmod(tmp, perm); err != nil {
+		os.Remove(tmp)
+		return errors.Wrapf(err, "mkdir %s", name)
+	}
+	if err := os.Rename(tmp, name); err != nil {
+		os.Remove(tmp)
+		// the directory was created by other process
+		if fi, serr := os.Stat(name); serr == nil && fi.IsDir() {
+			return nil
+		}
+		return errors.Wrapf(err, "mkdir %s", name)
+	}
+	return nil
+}
+
+// maxLinks is the maximum number of symbolic links
+// followed to find the file written by WriteAtomic.
+const maxLinks = 255
+
+// linkTarget returns the file
+// pointed by a chain of symbolic links,
+// or the name if it is not a link.
+// The target might not exist.
+func linkTarget(name string) (string, error) {
+	for i := 0; i < maxLinks; i++ {
+		fi, err := os.Lstat(name)
+		if err != nil || fi.Mode()&fs.ModeSymlink == 0 {
+			return name, nil
+		}
+		t, err := os.Readlink(name)
+		if err != nil {
+			return name, err
+		}
+		if !filepath.IsAbs(t) {
+			t = filepath.Join(filepath.Dir(name), t)
+		}
+		name = t
+	}
+	return name, errors.New("too many links")
+}
+
+// dirMode returns the mode of the directories
+// of a file with the given mode.
+func dirMode(perm fs.FileMode) fs.FileMode {
+	if perm&0077 == 0 {
+		return SecretDirMode
+	}
+	return SharedDirMode
+}
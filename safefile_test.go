@@ -0,0 +1,120 @@
+// Copyright (c) 2015, J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD-style license that can be found in the LICENSE file.
+
+package cmdapp
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func TestWriteFileAtomic(t *testing.T) {
+	tests := []struct {
+		name string
+		perm fs.FileMode
+		prev fs.FileMode // mode of a previous file, if any
+		link string      // the file is a link to this file
+	}{
+		{name: "new secret", perm: SecretMode},
+		{name: "new shared", perm: SharedMode},
+		{name: "previous shared", perm: SecretMode, prev: 0644},
+		{name: "previous secret", perm: SharedMode, prev: 0600},
+		{name: "link", perm: SecretMode, prev: 0600, link: "dotfiles/config"},
+		{name: "dangling link", perm: SecretMode, link: "dotfiles/config"},
+		{name: "new directory", perm: SecretMode, link: "new/dir/config"},
+	}
+	for _, test := range tests {
+		dir := t.TempDir()
+		name := filepath.Join(dir, "config")
+		target := name
+		if test.link != "" {
+			if runtime.GOOS == "windows" {
+				continue
+			}
+			target = filepath.Join(dir, filepath.FromSlash(test.link))
+			if err := os.Symlink(filepath.FromSlash(test.link), name); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if test.prev != 0 {
+			if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.WriteFile(target, []byte("old\n"), test.prev); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		if err := WriteFileAtomic(name, []byte("new\n"), test.perm); err != nil {
+			t.Errorf("%s: %v", test.name, err)
+			continue
+		}
+		b, err := os.ReadFile(target)
+		if err != nil {
+			t.Errorf("%s: %v", test.name, err)
+			continue
+		}
+		if string(b) != "new\n" {
+			t.Errorf("%s: got %q, want %q", test.name, b, "new\n")
+		}
+		if test.link != "" {
+			fi, err := os.Lstat(name)
+			if err != nil || fi.Mode()&fs.ModeSymlink == 0 {
+				t.Errorf("%s: the link was replaced", test.name)
+			}
+		}
+		if runtime.GOOS == "windows" {
+			continue
+		}
+		fi, err := os.Stat(target)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if fi.Mode().Perm() != test.perm {
+			t.Errorf("%s: mode %v, want %v", test.name, fi.Mode().Perm(), test.perm)
+		}
+	}
+}
+
+func TestMkdirAllMode(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("no unix permissions")
+	}
+	tests := []struct {
+		name string
+		perm fs.FileMode
+		prev fs.FileMode // mode of a previous directory, if any
+		want fs.FileMode
+	}{
+		{name: "new secret", perm: SecretDirMode, want: SecretDirMode},
+		{name: "new shared", perm: SharedDirMode, want: SharedDirMode},
+		{name: "previous shared", perm: SecretDirMode, prev: 0755, want: 0755},
+		{name: "previous secret", perm: SharedDirMode, prev: 0700, want: 0700},
+	}
+	for _, test := range tests {
+		name := filepath.Join(t.TempDir(), "a", "b")
+		if test.prev != 0 {
+			if err := os.MkdirAll(name, test.prev); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.Chmod(name, test.prev); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := MkdirAllMode(name, test.perm); err != nil {
+			t.Errorf("%s: %v", test.name, err)
+			continue
+		}
+		fi, err := os.Stat(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !fi.IsDir() || fi.Mode().Perm() != test.want {
+			t.Errorf("%s: mode %v, want %v", test.name, fi.Mode(), test.want)
+		}
+	}
+}
@@ -0,0 +1,186 @@
+// Copyright (c) 2015, J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD-style license that can be found in the LICENSE file.
+
+package cmdapp
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// script is the run-script command.
+type script struct {
+	stop bool
+}
+
+func init() {
+	addBuiltin(&script{})
+}
+
+const scriptLong = `
+Command run-script reads a script file and runs each line of the script as a
+command of the application. If the file is '-', or the application is called
+with '-' as the command, the script is read from the standard input.
+
+Each line of the script is a command with its flags and arguments, as it is
+written in the command line, without the application name. Arguments can be
+quoted using single or double quotes. Blank lines are ignored, as well as
+comments, that start with '#' and continue to the end of the line.
+
+A script can be made executable adding as the first line:
+
+    #!/usr/bin/env -S <app> run-script
+
+The -S option is required by env to split the application name from the
+command.
+
+By default, if a command fails, the error is reported and the script
+continues with the next line. The line 'set -e' in the script stops the
+script at the first failed command, and 'set +e' restores the default
+behavior.
+
+The flags are:
+
+    -e
+      Stops the script at the first failed command, as 'set -e'.
+`
+
+func (s *script) Name() string   { return "run-script" }
+func (s *script) Args() string   { return "[-e] <file>" }
+func (s *script) Short() string  { return "runs a script of commands" }
+func (s *script) Long() string   { return scriptLong }
+func (s *script) Runnable() bool { return true }
+
+func (s *script) Register(fs *flag.FlagSet) {
+	fs.BoolVar(&s.stop, "e", false, "stop at the first failed command")
+}
+
+func (s *script) Run(args []string) error {
+	if len(args) != 1 {
+		return errors.New("expecting a script file")
+	}
+	name := args[0]
+	var in io.Reader = os.Stdin
+	if name != "-" {
+		f, err := os.Open(name)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		in = f
+	} else {
+		name = "<stdin>"
+	}
+	return runScript(in, name, s.stop)
+}
+
+// runScript runs the commands of a script.
+func runScript(r io.Reader, name string, stop bool) error {
+	failed := 0
+	sc := bufio.NewScanner(r)
+	for ln := 1; sc.Scan(); ln++ {
+		args, err := splitWords(strings.TrimRight(sc.Text(), "\r"))
+		if err != nil {
+			return errors.Errorf("%s:%d: %v", name, ln, err)
+		}
+		if len(args) == 0 {
+			continue
+		}
+		if args[0] == "set" && len(args) == 2 && (args[1] == "-e" || args[1] == "+e") {
+			stop = args[1] == "-e"
+			continue
+		}
+
+		args, err = expandAlias(args)
+		if err == nil {
+			c, ok := lookup(args[0])
+			if !ok || !c.Runnable() {
+				err = errors.Errorf("unknown subcommand %s", args[0])
+			} else {
+				err = invoke(c, args[1:])
+			}
+		}
+		if err == nil {
+			continue
+		}
+		if stop {
+			return errors.Errorf("%s:%d: %s: %v", name, ln, args[0], err)
+		}
+		fmt.Fprintf(Stderr, "%s:%d: %s: %v\n", name, ln, args[0], err)
+		failed++
+	}
+	if err := sc.Err(); err != nil {
+		return err
+	}
+	if failed > 0 {
+		return errors.Errorf("%s: %s failed", name, Plural(failed, "command", ""))
+	}
+	return nil
+}
+
+// splitWords splits a line into words,
+// using single and double quotes
+// and backslash escapes
+// as in a shell.
+// An unquoted '#' at the start of a word
+// starts a comment.
+func splitWords(line string) ([]string, error) {
+	var words []string
+	var w strings.Builder
+	inWord := false
+	var quote rune
+	escape := false
+	for _, r := range line {
+		switch {
+		case escape:
+			w.WriteRune(r)
+			escape = false
+		case quote == '\'':
+			if r == '\'' {
+				quote = 0
+				continue
+			}
+			w.WriteRune(r)
+		case r == '\\':
+			escape = true
+			inWord = true
+		case quote == '"':
+			if r == '"' {
+				quote = 0
+				continue
+			}
+			w.WriteRune(r)
+		case r == '\'' || r == '"':
+			quote = r
+			inWord = true
+		case r == '#' && !inWord:
+			return words, nil
+		case r == ' ' || r == '\t':
+			if inWord {
+				words = append(words, w.String())
+				w.Reset()
+				inWord = false
+			}
+		default:
+			w.WriteRune(r)
+			inWord = true
+		}
+	}
+	if quote != 0 {
+		return nil, errors.New("unterminated quote")
+	}
+	if escape {
+		return nil, errors.New("trailing backslash")
+	}
+	if inWord {
+		words = append(words, w.String())
+	}
+	return words, nil
+}
@@ -0,0 +1,72 @@
+// Copyright (c) 2015, J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD-style license that can be found in the LICENSE file.
+
+package cmdapp
+
+import (
+	"reflect"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestSplitWords(t *testing.T) {
+	tests := []struct {
+		line string
+		want []string
+		err  bool
+	}{
+		{line: "", want: nil},
+		{line: "  help  config ", want: []string{"help", "config"}},
+		{line: `set name "John Doe"`, want: []string{"set", "name", "John Doe"}},
+		{line: `echo 'it'\''s' "a \"b\""`, want: []string{"echo", "it's", `a "b"`}},
+		{line: `a\ b c`, want: []string{"a b", "c"}},
+		{line: "run # a comment", want: []string{"run"}},
+		{line: "a#b", want: []string{"a#b"}},
+		{line: `''`, want: []string{""}},
+		{line: `"abc`, err: true},
+		{line: `abc\`, err: true},
+	}
+	for _, test := range tests {
+		got, err := splitWords(test.line)
+		if test.err {
+			if err == nil {
+				t.Errorf("splitWords(%q): expecting error", test.line)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("splitWords(%q): unexpected error: %v", test.line, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("splitWords(%q) = %q, want %q", test.line, got, test.want)
+		}
+	}
+}
+
+// FuzzSplitWords checks that the words of a line
+// quoted for a POSIX shell
+// are split into the same words.
+func FuzzSplitWords(f *testing.F) {
+	f.Add(`set name "John Doe"`)
+	f.Add(`echo 'it'\''s' # comment`)
+	f.Add(`a\ b	c ''`)
+	f.Fuzz(func(t *testing.T, line string) {
+		words, err := splitWords(line)
+		if err != nil || !utf8.ValidString(line) {
+			return
+		}
+		q := QuoteArgs("sh", words)
+		got, err := splitWords(q)
+		if err != nil {
+			t.Fatalf("splitWords(%q): %v", q, err)
+		}
+		if len(got) == 0 && len(words) == 0 {
+			return
+		}
+		if !reflect.DeepEqual(got, words) {
+			t.Fatalf("splitWords(%q) = %q, want %q", q, got, words)
+		}
+	})
+}
@@ -0,0 +1,242 @@
+// Copyright (c) 2015, J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD-style license that can be found in the LICENSE file.
+
+package cmdapp
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+	"unicode/utf8"
+)
+
+// snippetLen is the maximum length of a search context snippet.
+const snippetLen = 60
+
+// Weights of a term found in each field of a command.
+const (
+	nameWeight  = 10
+	shortWeight = 3
+	longWeight  = 1
+)
+
+// An Index is a full-text index of the help content
+// of a set of commands and help topics.
+type Index struct {
+	cmds  []Command
+	terms map[string]map[int]int // term -> command -> score
+}
+
+// A Hit is a result of a search in an index.
+type Hit struct {
+	Command Command
+	Score   int
+
+	// Snippet is the context of the first query word
+	// in the long description of the command.
+	Snippet string
+}
+
+// NewIndex builds an index from a list of commands.
+func NewIndex(cmds []Command) *Index {
+	ix := &Index{
+		cmds:  cmds,
+		terms: make(map[string]map[int]int),
+	}
+	for i, c := range cmds {
+		ix.add(i, c.Name(), nameWeight)
+		ix.add(i, shortText(c), shortWeight)
+		ix.add(i, longText(c), longWeight)
+	}
+	return ix
+}
+
+// add adds the words of a text to the index.
+func (ix *Index) add(id int, text string, weight int) {
+	for _, w := range words(text) {
+		p, ok := ix.terms[w]
+		if !ok {
+			p = make(map[int]int)
+			ix.terms[w] = p
+		}
+		p[id] += weight
+	}
+}
+
+// Search returns the commands that contain all the words of the query,
+// sorted by relevance.
+// A query word matches the words of the index
+// that contain it,
+// for example 'conf' matches 'config',
+// but whole word matches are ranked higher.
+func (ix *Index) Search(query string) []Hit {
+	q := words(query)
+	if len(q) == 0 {
+		return nil
+	}
+	scores := make(map[int]int)
+	for i, w := range q {
+		p := ix.match(w)
+		if i == 0 {
+			for id, s := range p {
+				scores[id] = s
+			}
+			continue
+		}
+		for id := range scores {
+			s, ok := p[id]
+			if !ok {
+				delete(scores, id)
+				continue
+			}
+			scores[id] += s
+		}
+	}
+
+	hits := make([]Hit, 0, len(scores))
+	for id, s := range scores {
+		c := ix.cmds[id]
+		hits = append(hits, Hit{
+			Command: c,
+			Score:   s,
+			Snippet: findSnippet(longText(c), q[0]),
+		})
+	}
+	sort.Slice(hits, func(i, j int) bool {
+		if hits[i].Score != hits[j].Score {
+			return hits[i].Score > hits[j].Score
+		}
+		return hits[i].Command.Name() < hits[j].Command.Name()
+	})
+	return hits
+}
+
+// wordBonus is the factor of the score
+// of a whole word match.
+const wordBonus = 2
+
+// match returns the score of the commands
+// with words that contain a query word.
+func (ix *Index) match(w string) map[int]int {
+	m := make(map[int]int)
+	for t, p := range ix.terms {
+		if !strings.Contains(t, w) {
+			continue
+		}
+		f := 1
+		if t == w {
+			f = wordBonus
+		}
+		for id, s := range p {
+			m[id] += s * f
+		}
+	}
+	return m
+}
+
+// words returns the lower case words of a text.
+func words(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// helpIndex is the index of the registered commands.
+var (
+	indexMutex   sync.Mutex
+	helpIndex    *Index
+	indexVersion int
+)
+
+// HelpIndex returns the index of the registered commands and help topics.
+// The index is built on first use,
+// and rebuilt if new commands are added.
+func HelpIndex() *Index {
+	mutex.RLock()
+	cmds, v := reg.sorted, reg.version
+	mutex.RUnlock()
+
+	indexMutex.Lock()
+	defer indexMutex.Unlock()
+	if helpIndex != nil && indexVersion == v {
+		return helpIndex
+	}
+	helpIndex, indexVersion = NewIndex(cmds), v
+	return helpIndex
+}
+
+// search prints the commands and help topics
+// that contain the words of a query.
+func search(w io.Writer, query string) {
+	hits := HelpIndex().Search(query)
+	if len(hits) == 0 {
+		fmt.Fprintf(w, "No help topic matches %q.\n", query)
+		return
+	}
+	for _, h := range hits {
+		fmt.Fprintf(w, "    %-16s %s\n", h.Command.Name(), shortText(h.Command))
+		if h.Snippet != "" {
+			fmt.Fprintf(w, "    %-16s ...%s...\n", "", h.Snippet)
+		}
+	}
+}
+
+// findSnippet returns the context of the first line
+// in which a word is found.
+func findSnippet(text, word string) string {
+	for _, ln := range strings.Split(text, "\n") {
+		i, n := indexFold(ln, word)
+		if i < 0 {
+			continue
+		}
+		return snippet(ln, i, n)
+	}
+	return ""
+}
+
+// indexFold returns the byte position of the first instance
+// of a word in a line,
+// ignoring case,
+// and the length in bytes of the instance in the line,
+// that can be different from the length of the word.
+// It returns -1 if the word is not found.
+func indexFold(ln, word string) (int, int) {
+	n := utf8.RuneCountInString(word)
+	if n == 0 {
+		return -1, 0
+	}
+	for i := range ln {
+		j := i
+		for k := 0; k < n && j < len(ln); k++ {
+			_, sz := utf8.DecodeRuneInString(ln[j:])
+			j += sz
+		}
+		if strings.EqualFold(ln[i:j], word) {
+			return i, j - i
+		}
+	}
+	return -1, 0
+}
+
+// snippet returns the text around a position of a line.
+func snippet(ln string, pos, n int) string {
+	start := pos - (snippetLen-n)/2
+	if start < 0 {
+		start = 0
+	}
+	end := start + snippetLen
+	if end > len(ln) {
+		end = len(ln)
+	}
+	for start > 0 && !utf8.RuneStart(ln[start]) {
+		start--
+	}
+	for end < len(ln) && !utf8.RuneStart(ln[end]) {
+		end++
+	}
+	return strings.TrimSpace(ln[start:end])
+}
@@ -0,0 +1,74 @@
+// Copyright (c) 2015, J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD-style license that can be found in the LICENSE file.
+
+package cmdapp
+
+import (
+	"flag"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestFindSnippet(t *testing.T) {
+	tests := []struct {
+		text string
+		word string
+		want string
+	}{
+		{"Reads the Config file.", "config", "Reads the Config file."},
+		{"first line\nsecond line with config", "config", "second line with config"},
+		{"no match", "config", ""},
+		{strings.Repeat("Ⱥ", 40) + " config", "config", strings.Repeat("Ⱥ", 13) + " config"},
+		{"ȺȺȺ CONFIG ȺȺȺ", "config", "ȺȺȺ CONFIG ȺȺȺ"},
+		{"Straße", "STRASSE", ""},
+	}
+	for _, test := range tests {
+		got := findSnippet(test.text, test.word)
+		if got != test.want {
+			t.Errorf("findSnippet(%q, %q) = %q, want %q", test.text, test.word, got, test.want)
+		}
+	}
+}
+
+// searchCmd is a command used to test the search.
+type searchCmd struct {
+	name, short, long string
+}
+
+func (c searchCmd) Name() string              { return c.name }
+func (c searchCmd) Args() string              { return "" }
+func (c searchCmd) Short() string             { return c.short }
+func (c searchCmd) Long() string              { return c.long }
+func (c searchCmd) Register(fs *flag.FlagSet) {}
+func (c searchCmd) Runnable() bool            { return true }
+func (c searchCmd) Run(args []string) error   { return nil }
+
+func TestIndexSearch(t *testing.T) {
+	ix := NewIndex([]Command{
+		searchCmd{"config", "reads and modifies the configuration", "Reads the configuration file."},
+		searchCmd{"conf", "prints the conf", "Prints the conf."},
+		searchCmd{"remote", "manages remotes", "Adds and removes remote repositories."},
+	})
+	tests := []struct {
+		query string
+		want  []string
+	}{
+		{"conf", []string{"conf", "config"}},
+		{"config", []string{"config"}},
+		{"CONFIGURATION file", []string{"config"}},
+		{"remote repo", []string{"remote"}},
+		{"missing", nil},
+		{"", nil},
+	}
+	for _, test := range tests {
+		var got []string
+		for _, h := range ix.Search(test.query) {
+			got = append(got, h.Command.Name())
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("search %q: got %v, want %v", test.query, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,151 @@
+// Copyright (c) 2015, J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD-style license that can be found in the LICENSE file.
+
+package cmdapp
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// UserShell returns the name of the shell of the user
+// (bash, zsh, fish, sh, powershell, or cmd),
+// or an empty string if it is unknown.
+func UserShell() string {
+	if sh := filepath.Base(os.Getenv("SHELL")); sh != "" && sh != "." {
+		sh = strings.TrimSuffix(sh, ".exe")
+		switch sh {
+		case "bash", "zsh", "fish":
+			return sh
+		case "sh", "dash", "ksh", "mksh", "ash":
+			return "sh"
+		case "pwsh", "powershell":
+			return "powershell"
+		}
+	}
+	if runtime.GOOS != "windows" {
+		if os.Getenv("PSModulePath") != "" {
+			return "powershell"
+		}
+		return ""
+	}
+	// PSModulePath is set for all the processes in Windows,
+	// but PROMPT is only defined by cmd.
+	if os.Getenv("PROMPT") != "" {
+		return "cmd"
+	}
+	return "powershell"
+}
+
+// Quote quotes a string,
+// if it is required,
+// so it is read as a single word by a shell.
+// Valid shells are the ones returned by UserShell;
+// an unknown shell is treated as a POSIX shell.
+func Quote(shell, s string) string {
+	switch shell {
+	case "fish":
+		if needQuote(s, " \t\n'\"\\$|&;<>()*?[]{}~#%") {
+			return fishQuote(s)
+		}
+	case "powershell":
+		if needQuote(s, " \t\n'\"`$|&;<>(){}[],@#\u2018\u2019\u201a\u201b\u201c\u201d\u201e") {
+			return psQuote(s)
+		}
+	case "cmd":
+		if needQuote(s, " \t\n\"&|<>^%!(),;=") {
+			return cmdQuote(s)
+		}
+	default:
+		if needQuote(s, " \t\n'\"\\$`|&;<>()*?[]{}~#!") {
+			return shQuote(s)
+		}
+	}
+	return s
+}
+
+// QuoteArgs returns a command line
+// from a list of arguments,
+// quoting them for a shell.
+func QuoteArgs(shell string, args []string) string {
+	w := make([]string, 0, len(args))
+	for _, a := range args {
+		w = append(w, Quote(shell, a))
+	}
+	return strings.Join(w, " ")
+}
+
+// needQuote reports whether a string is empty,
+// or has any of the special characters
+// of a shell.
+func needQuote(s, special string) bool {
+	return s == "" || strings.ContainsAny(s, special)
+}
+
+// shQuote quotes a string for a POSIX shell.
+func shQuote(s string) string {
+	return "'" + strings.Replace(s, "'", `'\''`, -1) + "'"
+}
+
+// fishQuote quotes a string for fish.
+func fishQuote(s string) string {
+	s = strings.Replace(s, `\`, `\\`, -1)
+	return "'" + strings.Replace(s, "'", `\'`, -1) + "'"
+}
+
+// psQuote quotes a string for powershell.
+// Powershell reads the typographic single quotes
+// as single quotes,
+// so they are doubled too.
+func psQuote(s string) string {
+	var b strings.Builder
+	b.WriteByte('\'')
+	for _, r := range s {
+		if strings.ContainsRune(psQuotes, r) {
+			b.WriteRune(r)
+		}
+		b.WriteRune(r)
+	}
+	b.WriteByte('\'')
+	return b.String()
+}
+
+// psQuotes are the runes
+// read as a single quote by powershell.
+const psQuotes = "'\u2018\u2019\u201a\u201b"
+
+// cmdQuote quotes a string for cmd,
+// following the rules used by Windows programs
+// to split the command line:
+// backslashes before a double quote are doubled,
+// and the double quote is escaped with a backslash.
+// Percent signs are escaped with a caret,
+// so they are not expanded as variables.
+func cmdQuote(s string) string {
+	var b strings.Builder
+	b.WriteByte('"')
+	slashes := 0
+	for _, r := range s {
+		switch r {
+		case '\\':
+			slashes++
+			b.WriteRune(r)
+			continue
+		case '"':
+			b.WriteString(strings.Repeat(`\`, slashes+1))
+		case '%':
+			b.WriteString(strings.Repeat(`\`, slashes))
+			b.WriteString(`"^%"`)
+			slashes = 0
+			continue
+		}
+		slashes = 0
+		b.WriteRune(r)
+	}
+	b.WriteString(strings.Repeat(`\`, slashes))
+	b.WriteByte('"')
+	return b.String()
+}
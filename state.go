@@ -0,0 +1,269 @@
+// Copyright (c) 2015, J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD-style license that can be found in the LICENSE file.
+
+package cmdapp
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// A StateStore is a store of small values of the user
+// kept between runs of the application,
+// for example the time of the last update check,
+// or the hints already shown.
+//
+// The values are stored as JSON
+// in the file state.json of the data directory.
+// The store is versioned,
+// when the application adds a migration
+// with a new version,
+// the stored values are migrated
+// when the store is read,
+// and written with the next change.
+type StateStore struct {
+	mu     sync.Mutex
+	loaded bool
+	values map[string]json.RawMessage
+}
+
+// A stateFile is the file of the state store.
+type stateFile struct {
+	Version int                        `json:"version"`
+	Values  map[string]json.RawMessage `json:"values"`
+}
+
+// A StateMigration changes the stored values
+// to a new version of the store.
+type StateMigration func(values map[string]json.RawMessage) error
+
+// migrations are the migrations of the state store,
+// by version.
+var (
+	migMutex   sync.Mutex
+	migrations = make(map[int]StateMigration)
+)
+
+// AddStateMigration adds a migration
+// to a version of the state store.
+// Versions start at 1,
+// and should be unique,
+// otherwise it will trigger a panic.
+//
+// The migrations are applied in order of version,
+// to the stored values of a previous version.
+func AddStateMigration(version int, m StateMigration) {
+	if version < 1 {
+		panic(fmt.Sprintf("cmdapp: invalid state version: %d", version))
+	}
+	migMutex.Lock()
+	defer migMutex.Unlock()
+	if _, dup := migrations[version]; dup {
+		panic(fmt.Sprintf("cmdapp: Repeated state version: %d", version))
+	}
+	migrations[version] = m
+}
+
+// stateVersion returns the current version of the store
+// and the migrations sorted by version.
+func stateVersion() (int, []int) {
+	migMutex.Lock()
+	defer migMutex.Unlock()
+	vers := make([]int, 0, len(migrations))
+	for v := range migrations {
+		vers = append(vers, v)
+	}
+	sort.Ints(vers)
+	if len(vers) == 0 {
+		return 0, nil
+	}
+	return vers[len(vers)-1], vers
+}
+
+// state is the state store of the application.
+var state = &StateStore{}
+
+// State returns the state store of the application.
+func State() *StateStore {
+	return state
+}
+
+// Get reads the value of a key into v.
+// It returns false if the key is not set.
+func (s *StateStore) Get(key string, v interface{}) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.load(); err != nil {
+		return false, err
+	}
+	raw, ok := s.values[key]
+	if !ok {
+		return false, nil
+	}
+	if err := json.Unmarshal(raw, v); err != nil {
+		return false, errors.Wrapf(err, "state: key %s", key)
+	}
+	return true, nil
+}
+
+// Set sets the value of a key
+// and writes the store.
+func (s *StateStore) Set(key string, v interface{}) error {
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return errors.Wrapf(err, "state: key %s", key)
+	}
+	return s.update(func(values map[string]json.RawMessage) {
+		values[key] = raw
+	})
+}
+
+// Delete removes a key
+// and writes the store.
+func (s *StateStore) Delete(key string) error {
+	return s.update(func(values map[string]json.RawMessage) {
+		delete(values, key)
+	})
+}
+
+// update changes the values of the store
+// and writes the store.
+// The stored values are read again before the change,
+// holding a file lock until the store is written,
+// so the changes of other runs of the application
+// are not lost.
+func (s *StateStore) update(f func(map[string]json.RawMessage)) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	unlock, err := lockState()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	s.loaded = false
+	if err := s.load(); err != nil {
+		return err
+	}
+	f(s.values)
+	return s.save()
+}
+
+// statePath returns the path of the file of the store.
+func statePath() (string, error) {
+	dir, err := DataDir()
+	if err != nil {
+		return "", errors.Wrap(err, "state")
+	}
+	return filepath.Join(dir, "state.json"), nil
+}
+
+// stateMutex excludes the goroutines of the process
+// that wait for the file lock of the store,
+// as in some systems (fcntl locks)
+// the file lock is held by the process.
+var stateMutex sync.Mutex
+
+// lockState waits for the file lock of the store,
+// and returns a function that releases it.
+func lockState() (func(), error) {
+	name, err := statePath()
+	if err != nil {
+		return nil, err
+	}
+	if err := MkdirAllMode(filepath.Dir(name), SecretDirMode); err != nil {
+		return nil, errors.Wrap(err, "state")
+	}
+
+	stateMutex.Lock()
+	f, err := os.OpenFile(name+".lock", os.O_CREATE|os.O_RDWR, SecretMode)
+	if err != nil {
+		stateMutex.Unlock()
+		return nil, errors.Wrap(err, "state")
+	}
+	if _, err := lockFD(f, true); err != nil {
+		f.Close()
+		stateMutex.Unlock()
+		return nil, errors.Wrap(err, "state: lock")
+	}
+	return func() {
+		unlockFD(f)
+		f.Close()
+		stateMutex.Unlock()
+	}, nil
+}
+
+// load reads the store,
+// and migrates the values of a previous version.
+// The mutex must be held.
+// The migrated values are written
+// only by update,
+// as it holds the file lock.
+func (s *StateStore) load() error {
+	if s.loaded {
+		return nil
+	}
+	name, err := statePath()
+	if err != nil {
+		return err
+	}
+	var sf stateFile
+	b, err := os.ReadFile(name)
+	if err != nil && !os.IsNotExist(err) {
+		return errors.Wrap(err, "state")
+	}
+	if err == nil {
+		if err := json.Unmarshal(b, &sf); err != nil {
+			return errors.Wrapf(err, "state: %s", name)
+		}
+	}
+	if sf.Values == nil {
+		sf.Values = make(map[string]json.RawMessage)
+	}
+	s.values = sf.Values
+
+	cur, vers := stateVersion()
+	if sf.Version > cur {
+		return errors.Errorf("state: %s: version %d is newer than the application version %d", name, sf.Version, cur)
+	}
+	if sf.Version < cur && len(b) > 0 {
+		for _, v := range vers {
+			if v <= sf.Version {
+				continue
+			}
+			migMutex.Lock()
+			m := migrations[v]
+			migMutex.Unlock()
+			logger.Info("state: migrate", "version", v)
+			if err := m(s.values); err != nil {
+				return errors.Wrapf(err, "state: migrate to version %d", v)
+			}
+		}
+	}
+	s.loaded = true
+	return nil
+}
+
+// save writes the store.
+// The mutex must be held.
+func (s *StateStore) save() error {
+	name, err := statePath()
+	if err != nil {
+		return err
+	}
+	cur, _ := stateVersion()
+	b, err := json.MarshalIndent(stateFile{Version: cur, Values: s.values}, "", "\t")
+	if err != nil {
+		return errors.Wrap(err, "state")
+	}
+	if err := WriteSecret(name, append(b, '\n')); err != nil {
+		return errors.Wrap(err, "state")
+	}
+	return nil
+}
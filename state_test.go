@@ -0,0 +1,92 @@
+// Copyright (c) 2015, J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD-style license that can be found in the LICENSE file.
+
+package cmdapp
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+)
+
+func TestStateStore(t *testing.T) {
+	t.Setenv("XDG_DATA_HOME", t.TempDir())
+
+	tests := []struct {
+		op    string
+		key   string
+		val   int
+		found bool
+	}{
+		{op: "get", key: "a"},
+		{op: "set", key: "a", val: 1},
+		{op: "get", key: "a", val: 1, found: true},
+		{op: "set", key: "b", val: 2},
+		{op: "set", key: "a", val: 3},
+		{op: "get", key: "a", val: 3, found: true},
+		{op: "get", key: "b", val: 2, found: true},
+		{op: "delete", key: "a"},
+		{op: "get", key: "a"},
+		{op: "get", key: "b", val: 2, found: true},
+	}
+	for i, test := range tests {
+		// a new store reads the values
+		// written by the previous one
+		s := &StateStore{}
+		switch test.op {
+		case "set":
+			if err := s.Set(test.key, test.val); err != nil {
+				t.Fatalf("%d: set %s: %v", i, test.key, err)
+			}
+		case "delete":
+			if err := s.Delete(test.key); err != nil {
+				t.Fatalf("%d: delete %s: %v", i, test.key, err)
+			}
+		case "get":
+			var v int
+			ok, err := s.Get(test.key, &v)
+			if err != nil {
+				t.Fatalf("%d: get %s: %v", i, test.key, err)
+			}
+			if ok != test.found || v != test.val {
+				t.Errorf("%d: get %s: got %d %v, want %d %v", i, test.key, v, ok, test.val, test.found)
+			}
+		}
+	}
+}
+
+// TestStateConcurrent checks that the changes
+// of different stores on the same file
+// are not lost.
+func TestStateConcurrent(t *testing.T) {
+	t.Setenv("XDG_DATA_HOME", t.TempDir())
+
+	const stores, keys = 8, 20
+	var wg sync.WaitGroup
+	for i := 0; i < stores; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			s := &StateStore{}
+			for j := 0; j < keys; j++ {
+				if err := s.Set(fmt.Sprintf("k%d-%d", i, j), j); err != nil {
+					t.Error(err)
+					return
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	s := &StateStore{}
+	for i := 0; i < stores; i++ {
+		for j := 0; j < keys; j++ {
+			key := fmt.Sprintf("k%d-%d", i, j)
+			var v int
+			if ok, err := s.Get(key, &v); err != nil || !ok || v != j {
+				t.Errorf("key %s: got %d %v %v, want %d", key, v, ok, err, j)
+			}
+		}
+	}
+}
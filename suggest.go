@@ -0,0 +1,124 @@
+// Copyright (c) 2015, J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD-style license that can be found in the LICENSE file.
+
+package cmdapp
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// editDistance returns the Levenshtein distance
+// between two strings.
+func editDistance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	cur := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		cur[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(rb)]
+}
+
+// suggest returns the candidates
+// that are similar to a word,
+// the most similar first.
+func suggest(word string, candidates []string) []string {
+	max := len([]rune(word)) / 3
+	if max < 1 {
+		max = 1
+	}
+	if max > 3 {
+		max = 3
+	}
+	type sugg struct {
+		s string
+		d int
+	}
+	var ss []sugg
+	for _, c := range candidates {
+		if d := editDistance(word, c); d <= max {
+			ss = append(ss, sugg{c, d})
+		}
+	}
+	sort.SliceStable(ss, func(i, j int) bool { return ss[i].d < ss[j].d })
+	res := make([]string, 0, len(ss))
+	for _, s := range ss {
+		res = append(res, s.s)
+	}
+	return res
+}
+
+// maxSuggestions is the maximum number of suggestions
+// of a mistyped command.
+const maxSuggestions = 3
+
+// didYouMean returns the suggestions
+// for a mistyped word,
+// the candidates most similar to the word,
+// as ", did you mean 'x'?",
+// or an empty string if there is no similar candidate.
+func didYouMean(word string, candidates []string) string {
+	s := suggest(word, candidates)
+	if len(s) == 0 {
+		return ""
+	}
+	d := editDistance(word, s[0])
+	var q []string
+	for _, c := range s {
+		if len(q) == maxSuggestions || editDistance(word, c) > d {
+			break
+		}
+		q = append(q, "'"+c+"'")
+	}
+	if len(q) == 1 {
+		return fmt.Sprintf(", did you mean %s?", q[0])
+	}
+	return fmt.Sprintf(", did you mean %s or %s?", strings.Join(q[:len(q)-1], ", "), q[len(q)-1])
+}
+
+// commandNames returns the names that can be used as a command:
+// the runnable commands,
+// the aliases,
+// and the plugins.
+func commandNames() []string {
+	seen := make(map[string]bool)
+	var names []string
+	addName := func(nm string) {
+		if seen[normName(nm)] {
+			return
+		}
+		seen[normName(nm)] = true
+		names = append(names, nm)
+	}
+	for _, c := range sortedCommands() {
+		if c.Runnable() {
+			addName(c.Name())
+		}
+	}
+	var al []string
+	for a := range aliases() {
+		al = append(al, a)
+	}
+	sort.Strings(al)
+	for _, a := range al {
+		addName(a)
+	}
+	for _, p := range plugins() {
+		addName(p)
+	}
+	return names
+}
@@ -0,0 +1,82 @@
+// Copyright (c) 2015, J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD-style license that can be found in the LICENSE file.
+
+package cmdapp
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestEditDistance(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"", "", 0},
+		{"abc", "", 3},
+		{"", "abc", 3},
+		{"build", "build", 0},
+		{"biuld", "build", 2},
+		{"buid", "build", 1},
+		{"buildd", "build", 1},
+		{"kitten", "sitting", 3},
+		{"año", "ano", 1},
+	}
+	for _, test := range tests {
+		if got := editDistance(test.a, test.b); got != test.want {
+			t.Errorf("editDistance(%q, %q) = %d, want %d", test.a, test.b, got, test.want)
+		}
+	}
+}
+
+func TestDidYouMean(t *testing.T) {
+	cands := []string{"build", "built", "config", "help", "install", "list", "lint"}
+	tests := []struct {
+		word string
+		want string
+	}{
+		{"buid", ", did you mean 'build'?"},
+		{"bild", ", did you mean 'build'?"},
+		{"buil", ", did you mean 'build' or 'built'?"},
+		{"lis", ", did you mean 'list'?"},
+		{"lnt", ", did you mean 'lint'?"},
+		{"lsit", ""},
+		{"instal", ", did you mean 'install'?"},
+		{"cnofgi", ""},
+		{"deploy", ""},
+		{"x", ""},
+	}
+	for _, test := range tests {
+		if got := didYouMean(test.word, cands); got != test.want {
+			t.Errorf("didYouMean(%q) = %q, want %q", test.word, got, test.want)
+		}
+	}
+}
+
+func TestUnknownCommand(t *testing.T) {
+	tests := []struct {
+		args []string
+		want string
+	}{
+		{[]string{"buld"}, "unknown subcommand buld, did you mean 'build'?"},
+		{[]string{"BUID"}, "unknown subcommand BUID, did you mean 'build'?"},
+		{[]string{"zzzzz"}, "unknown subcommand zzzzz\n"},
+	}
+	for _, test := range tests {
+		var errOut bytes.Buffer
+		a := NewApp("suggestapp", "a test application")
+		a.Stdout, a.Stderr = io.Discard, &errOut
+		a.Add(&mountCmd{name: "build", run: func(c *mountCmd, args []string) error { return nil }})
+
+		if code := a.Dispatch(test.args); code == 0 {
+			t.Errorf("%q: expecting an error", test.args)
+		}
+		if !strings.Contains(errOut.String(), test.want) {
+			t.Errorf("%q: error %q, want %q", test.args, errOut.String(), test.want)
+		}
+	}
+}
@@ -0,0 +1,148 @@
+// Copyright (c) 2015, J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD-style license that can be found in the LICENSE file.
+
+package cmdapp
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// An OutputCommand is a command
+// that writes its results in a given writer,
+// so its output can be grouped or prefixed
+// when it is run concurrently with other commands.
+type OutputCommand interface {
+	Command
+
+	// SetOutput sets the writer for the results of the command.
+	// If it is not called,
+	// the command should write to Output.
+	SetOutput(w io.Writer)
+}
+
+// A TaskOutput sets how the output of concurrent tasks
+// is multiplexed.
+type TaskOutput int
+
+// Valid task output modes.
+const (
+	// PrefixOutput writes each line as soon as it is complete,
+	// prefixed by the task name.
+	PrefixOutput TaskOutput = iota
+
+	// GroupOutput writes the whole output of a task
+	// when the task finishes.
+	GroupOutput
+
+	// RawOutput writes the output as it is written.
+	RawOutput
+)
+
+var taskOutputNames = []string{"prefix", "group", "raw"}
+
+func (t TaskOutput) String() string {
+	if t < 0 || int(t) >= len(taskOutputNames) {
+		return "prefix"
+	}
+	return taskOutputNames[t]
+}
+
+// Set sets the task output mode from its name,
+// so it can be used as a flag value.
+func (t *TaskOutput) Set(s string) error {
+	for i, nm := range taskOutputNames {
+		if strings.ToLower(s) == nm {
+			*t = TaskOutput(i)
+			return nil
+		}
+	}
+	return errors.Errorf("unknown output mode %q", s)
+}
+
+// taskWriter is the output of a concurrent task.
+type taskWriter struct {
+	mode   TaskOutput
+	prefix string
+
+	mu  sync.Mutex
+	buf []byte
+}
+
+// newTaskOutput sets the output of a command
+// run as a concurrent task,
+// and returns a function to be called
+// when the task finishes.
+func newTaskOutput(c Command, name string, mode TaskOutput) func() {
+	oc, ok := c.(OutputCommand)
+	if !ok {
+		return func() {}
+	}
+	if mode == RawOutput {
+		oc.SetOutput(Output())
+		return func() {}
+	}
+	w := &taskWriter{
+		mode:   mode,
+		prefix: "[" + name + "] ",
+	}
+	oc.SetOutput(w)
+	return w.close
+}
+
+func (w *taskWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.buf = append(w.buf, p...)
+	if w.mode == GroupOutput {
+		return len(p), nil
+	}
+
+	i := bytes.LastIndexByte(w.buf, '\n')
+	if i < 0 {
+		return len(p), nil
+	}
+	_, err := Output().Write(w.prefixed(w.buf[:i+1]))
+	w.buf = append(w.buf[:0], w.buf[i+1:]...)
+	if err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// prefixed returns a set of complete lines
+// with the task prefix.
+func (w *taskWriter) prefixed(lines []byte) []byte {
+	var b bytes.Buffer
+	for _, ln := range bytes.SplitAfter(lines, []byte("\n")) {
+		if len(ln) == 0 {
+			continue
+		}
+		b.WriteString(w.prefix)
+		b.Write(ln)
+	}
+	return b.Bytes()
+}
+
+// close writes any pending output of the task.
+func (w *taskWriter) close() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if len(w.buf) == 0 {
+		return
+	}
+	if w.buf[len(w.buf)-1] != '\n' {
+		w.buf = append(w.buf, '\n')
+	}
+	if w.mode == GroupOutput {
+		Output().Write(w.buf)
+	} else {
+		Output().Write(w.prefixed(w.buf))
+	}
+	w.buf = nil
+}
@@ -0,0 +1,217 @@
+// Copyright (c) 2015, J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD-style license that can be found in the LICENSE file.
+
+package cmdapp
+
+import (
+	"flag"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// A Mode sets whether a terminal feature is used.
+type Mode int
+
+// Valid terminal modes.
+const (
+	// Auto uses the feature
+	// if the terminal supports it.
+	Auto Mode = iota
+
+	// Always uses the feature.
+	Always
+
+	// Never disables the feature.
+	Never
+)
+
+// ColorMode sets whether the output can use colors.
+var ColorMode Mode
+
+// InteractiveMode sets whether the application
+// can interact with the user,
+// for example with prompts, spinners or a pager.
+var InteractiveMode Mode
+
+// ciVars are environment variables
+// set by continuous integration services.
+var ciVars = []string{
+	"CI",
+	"CONTINUOUS_INTEGRATION",
+	"BUILD_NUMBER",
+	"GITHUB_ACTIONS",
+	"GITLAB_CI",
+	"CIRCLECI",
+	"TRAVIS",
+	"BUILDKITE",
+	"DRONE",
+	"APPVEYOR",
+	"JENKINS_URL",
+	"TEAMCITY_VERSION",
+	"TF_BUILD",
+	"BITBUCKET_BUILD_NUMBER",
+	"CODEBUILD_BUILD_ID",
+}
+
+// IsCI reports whether the application is running
+// in a continuous integration environment.
+func IsCI() bool {
+	for _, v := range ciVars {
+		val, ok := os.LookupEnv(v)
+		if !ok {
+			continue
+		}
+		switch strings.ToLower(val) {
+		case "", "0", "false", "no":
+			continue
+		}
+		return true
+	}
+	return false
+}
+
+// Color reports whether the output can use colors.
+// With the Auto mode,
+// colors are used if the standard output is a terminal,
+// the NO_COLOR environment variable is not set,
+// the application is not running in a continuous integration environment,
+// and the terminal processes escape sequences
+// (in Windows, legacy consoles do not).
+func Color() bool {
+	switch ColorMode {
+	case Always:
+		enableVT()
+		return true
+	case Never:
+		return false
+	}
+	if Porcelain() || os.Getenv("NO_COLOR") != "" || os.Getenv("TERM") == "dumb" {
+		return false
+	}
+	return !IsCI() && isTerminal(os.Stdout) && enableVT()
+}
+
+// Interactive reports whether the application
+// can interact with the user.
+// With the Auto mode,
+// the application is interactive
+// if the standard input and output are terminals,
+// and the application is not running in a continuous integration environment.
+func Interactive() bool {
+	switch InteractiveMode {
+	case Always:
+		return true
+	case Never:
+		return false
+	}
+	return !IsCI() && isTerminal(os.Stdin) && isTerminal(os.Stdout)
+}
+
+// accessible is set by the -accessible flag.
+var accessible bool
+
+// registerTermFlags sets the terminal flags
+// of the application.
+func registerTermFlags(fs *flag.FlagSet) {
+	fs.BoolVar(&accessible, "accessible", false, "use output suitable for screen readers")
+	fs.Var(&Glyphs, "glyphs", "style of status markers: auto, emoji, ascii, or none")
+	fs.Var(&ProgressOutput, "progress", "style of progress: auto, bar, log, json, or none")
+}
+
+// envName returns the name of an environment variable
+// of the application,
+// prefixed with the application name in upper case.
+func envName(v string) string {
+	p := strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return unicode.ToUpper(r)
+		}
+		return '_'
+	}, appName())
+	return p + "_" + v
+}
+
+// Accessible reports whether the output
+// should be suitable for screen readers.
+// It is set with the -accessible flag,
+// or the <APP>_ACCESSIBLE environment variable,
+// where <APP> is the application name in upper case.
+//
+// In accessible mode
+// animations (as spinners and progress bars)
+// should be replaced by periodic plain text messages,
+// tables should not use box-drawing characters,
+// and information should never be given only by color.
+func Accessible() bool {
+	if accessible {
+		return true
+	}
+	switch strings.ToLower(os.Getenv(envName("ACCESSIBLE"))) {
+	case "", "0", "false", "no":
+		return false
+	}
+	return true
+}
+
+// Animated reports whether the output
+// can use animations,
+// as spinners and progress bars.
+func Animated() bool {
+	return Interactive() && !Accessible() && !Porcelain() && enableVT()
+}
+
+// HyperlinkMode sets whether URLs in the help output
+// are shown as terminal hyperlinks.
+var HyperlinkMode Mode
+
+// Hyperlinks reports whether the terminal supports
+// hyperlinks (OSC 8 escape sequences).
+// With the Auto mode,
+// known terminal emulators are detected,
+// and the FORCE_HYPERLINK environment variable
+// can be used to enable or disable them.
+func Hyperlinks() bool {
+	switch HyperlinkMode {
+	case Always:
+		return true
+	case Never:
+		return false
+	}
+	if v, ok := os.LookupEnv("FORCE_HYPERLINK"); ok {
+		return v != "0" && v != ""
+	}
+	if Porcelain() || IsCI() || !isTerminal(os.Stdout) || os.Getenv("TERM") == "dumb" || !enableVT() {
+		return false
+	}
+	switch os.Getenv("TERM_PROGRAM") {
+	case "iTerm.app", "WezTerm", "vscode", "Hyper", "ghostty":
+		return true
+	}
+	if os.Getenv("WT_SESSION") != "" || os.Getenv("KITTY_WINDOW_ID") != "" {
+		return true
+	}
+	if v, err := strconv.Atoi(os.Getenv("VTE_VERSION")); err == nil && v >= 5000 {
+		return true
+	}
+	return false
+}
+
+// urlRegexp matches an URL in a text.
+var urlRegexp = regexp.MustCompile(`https?://[^\s<>"'()\[\]]+`)
+
+// linkify replaces the URLs of a text
+// with terminal hyperlinks.
+func linkify(text string) string {
+	return urlRegexp.ReplaceAllStringFunc(text, func(u string) string {
+		trail := ""
+		if t := strings.TrimRight(u, ".,;:!?"); t != u {
+			trail = u[len(t):]
+			u = t
+		}
+		return "\x1b]8;;" + u + "\x1b\\" + u + "\x1b]8;;\x1b\\" + trail
+	})
+}
@@ -0,0 +1,16 @@
+// Copyright (c) 2015, J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD-style license that can be found in the LICENSE file.
+
+package cmdapp
+
+import "os"
+
+// isTerminal reports whether a file is a terminal.
+// In WASM the output is a terminal
+// only if the host sets the TERM environment variable,
+// for example a browser-based terminal.
+func isTerminal(f *os.File) bool {
+	t := os.Getenv("TERM")
+	return t != "" && t != "dumb"
+}
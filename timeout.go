@@ -0,0 +1,138 @@
+// Copyright (c) 2015, J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD-style license that can be found in the LICENSE file.
+
+package cmdapp
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// ExitTimeout is the exit code used
+// when a command is stopped
+// because it reached its time limit.
+const ExitTimeout = 124
+
+// A TimeoutCommand is a command
+// with a maximum run time.
+type TimeoutCommand interface {
+	Command
+
+	// Timeout returns the maximum run time of the command.
+	// If zero,
+	// the command has no time limit.
+	Timeout() time.Duration
+}
+
+// userTimeout is the time limit
+// set with the -timeout flag.
+var (
+	userTimeout time.Duration
+	timeoutSet  bool
+)
+
+// registerTimeoutFlags sets the time limit flag
+// of the application.
+func registerTimeoutFlags(fs *flag.FlagSet) {
+	timeoutSet = false
+	fs.Func("timeout", "maximum run time of the command (0 for no limit)", func(s string) error {
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return err
+		}
+		if d < 0 {
+			return errors.Errorf("negative duration %s", s)
+		}
+		userTimeout, timeoutSet = d, true
+		return nil
+	})
+}
+
+// commandTimeout returns the time limit of a command.
+// The -timeout flag
+// overrides the time limit declared by the command.
+func commandTimeout(c Command) time.Duration {
+	if timeoutSet {
+		return userTimeout
+	}
+	if t, ok := c.(TimeoutCommand); ok {
+		return t.Timeout()
+	}
+	return 0
+}
+
+// cmdCtx is the context of the running command.
+var (
+	ctxMutex sync.Mutex
+	cmdCtx   = context.Background()
+)
+
+// Context returns the context of the running command.
+// It is cancelled when the command reaches its time limit,
+// or when the application receives
+// an interrupt or terminate signal,
+// so commands that run for long should check it,
+// and pass it to Exec.
+// After the context is cancelled,
+// the command has the time set by GracePeriod
+// to return.
+func Context() context.Context {
+	ctxMutex.Lock()
+	defer ctxMutex.Unlock()
+	return cmdCtx
+}
+
+// startContext sets the context of a command,
+// derived from a parent context.
+// It returns a function that must be called
+// with the error of the command
+// when the command is done,
+// to restore the previous context
+// and to report if the time limit was reached.
+func startContext(parent context.Context, c Command) func(error) error {
+	d := commandTimeout(c)
+	cur, cancel := parent, context.CancelFunc(func() {})
+	if d > 0 {
+		cur, cancel = context.WithTimeout(parent, d)
+	}
+
+	ctxMutex.Lock()
+	prev := cmdCtx
+	cmdCtx = cur
+	ctxMutex.Unlock()
+
+	return func(err error) error {
+		timedOut := cur.Err() == context.DeadlineExceeded && parent.Err() == nil
+		cancel()
+		ctxMutex.Lock()
+		cmdCtx = prev
+		ctxMutex.Unlock()
+		if timedOut {
+			return &timeoutError{d: d, err: err}
+		}
+		return err
+	}
+}
+
+// A timeoutError is the error of a command
+// that reached its time limit.
+type timeoutError struct {
+	d   time.Duration
+	err error
+}
+
+func (e *timeoutError) Error() string {
+	if e.err == nil || errors.Cause(e.err) == context.DeadlineExceeded {
+		return fmt.Sprintf("timed out after %v", e.d)
+	}
+	return fmt.Sprintf("timed out after %v: %v", e.d, e.err)
+}
+
+func (e *timeoutError) Unwrap() error { return e.err }
+func (e *timeoutError) ExitCode() int { return ExitTimeout }
@@ -0,0 +1,350 @@
+// Copyright (c) 2015, J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD-style license that can be found in the LICENSE file.
+
+package cmdapp
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// TrashKeep is the number of operations
+// kept in the trash.
+var TrashKeep = 20
+
+// trash is the state of the trash.
+var (
+	trashMutex sync.Mutex
+	trashOn    bool
+	trashOp    *trashOperation
+)
+
+// EnableTrash enables the trash
+// of the application,
+// and adds the undo command.
+//
+// With the trash,
+// the files removed or replaced
+// through the file system returned by FS
+// are moved to the trash directory,
+// in the data directory of the application,
+// instead of being deleted,
+// so the last operation can be undone
+// with the undo command.
+func EnableTrash() {
+	trashMutex.Lock()
+	defer trashMutex.Unlock()
+	if trashOn {
+		return
+	}
+	trashOn = true
+	addBuiltin(&undoCmd{})
+}
+
+// useTrash reports whether the trash is enabled.
+func useTrash() bool {
+	trashMutex.Lock()
+	defer trashMutex.Unlock()
+	return trashOn
+}
+
+// trashDir returns the trash directory.
+func trashDir() (string, error) {
+	dir, err := DataDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "trash"), nil
+}
+
+// A trashOperation is the set of changes
+// done by a run of the application.
+type trashOperation struct {
+	dir string
+
+	Time    time.Time    `json:"time"`
+	Args    []string     `json:"args"`
+	Entries []trashEntry `json:"entries"`
+}
+
+// A trashEntry is a file changed in an operation.
+type trashEntry struct {
+	// Path is the absolute path of the file.
+	Path string `json:"path"`
+
+	// Stored is the name of the file in the trash,
+	// empty if the file did not exist.
+	Stored string `json:"stored,omitempty"`
+
+	// Dir is true if the file is a directory.
+	Dir bool `json:"dir,omitempty"`
+}
+
+// operation returns the operation of the current run,
+// creating it if necessary.
+// The trash mutex must be held.
+func operation() (*trashOperation, error) {
+	if trashOp != nil {
+		return trashOp, nil
+	}
+	dir, err := trashDir()
+	if err != nil {
+		return nil, err
+	}
+	now := Now()
+	op := &trashOperation{
+		dir:  filepath.Join(dir, strconv.FormatInt(now.UnixNano(), 10)),
+		Time: now,
+		Args: os.Args[1:],
+	}
+	if err := MkdirAllMode(op.dir, SecretDirMode); err != nil {
+		return nil, errors.Wrap(err, "trash")
+	}
+	pruneTrash(dir)
+	trashOp = op
+	return op, nil
+}
+
+// stash moves a file to the trash
+// before it is removed or replaced.
+// If the file does not exist,
+// it is recorded as created,
+// so undo removes it.
+func stash(name string) error {
+	trashMutex.Lock()
+	defer trashMutex.Unlock()
+	op, err := operation()
+	if err != nil {
+		return err
+	}
+	abs, err := filepath.Abs(name)
+	if err != nil {
+		return errors.Wrap(err, "trash")
+	}
+	e := trashEntry{Path: abs}
+	fi, err := os.Lstat(abs)
+	switch {
+	case os.IsNotExist(err):
+	case err != nil:
+		return errors.Wrap(err, "trash")
+	case fi.IsDir():
+		e.Dir = true
+	default:
+		e.Stored = strconv.Itoa(len(op.Entries))
+		if err := copyFile(filepath.Join(op.dir, e.Stored), abs, fi.Mode()); err != nil {
+			return errors.Wrap(err, "trash")
+		}
+	}
+	op.Entries = append(op.Entries, e)
+	return op.save()
+}
+
+// save writes the manifest of an operation.
+func (op *trashOperation) save() error {
+	data, err := json.MarshalIndent(op, "", "  ")
+	if err != nil {
+		return errors.Wrap(err, "trash")
+	}
+	if err := WriteSecret(filepath.Join(op.dir, "operation.json"), data); err != nil {
+		return errors.Wrap(err, "trash")
+	}
+	return nil
+}
+
+// copyFile copies a file.
+func copyFile(dst, src string, perm fs.FileMode) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, perm.Perm())
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+// pruneTrash removes the oldest operations
+// of the trash.
+func pruneTrash(dir string) {
+	ops := trashOperations(dir)
+	for len(ops) > TrashKeep {
+		os.RemoveAll(filepath.Join(dir, ops[0]))
+		ops = ops[1:]
+	}
+}
+
+// trashOperations returns the operations in the trash,
+// from the oldest to the newest.
+func trashOperations(dir string) []string {
+	ents, err := os.ReadDir(dir)
+	if err != nil {
+		return nil
+	}
+	var ops []string
+	for _, e := range ents {
+		if _, err := strconv.ParseInt(e.Name(), 10, 64); err == nil && e.IsDir() {
+			ops = append(ops, e.Name())
+		}
+	}
+	sort.Slice(ops, func(i, j int) bool {
+		if len(ops[i]) != len(ops[j]) {
+			return len(ops[i]) < len(ops[j])
+		}
+		return ops[i] < ops[j]
+	})
+	return ops
+}
+
+// trashFS is the operating system file system
+// that stashes the files in the trash
+// before removing or replacing them.
+type trashFS struct {
+	osFS
+}
+
+func (t trashFS) WriteFile(name string, data []byte, perm fs.FileMode) error {
+	if err := stash(name); err != nil {
+		return err
+	}
+	return t.osFS.WriteFile(name, data, perm)
+}
+
+func (t trashFS) Remove(name string) error {
+	if err := stash(name); err != nil {
+		return err
+	}
+	return t.osFS.Remove(name)
+}
+
+func (t trashFS) Rename(oldName, newName string) error {
+	if err := stash(oldName); err != nil {
+		return err
+	}
+	if err := stash(newName); err != nil {
+		return err
+	}
+	return t.osFS.Rename(oldName, newName)
+}
+
+// undoCmd is the undo command.
+type undoCmd struct {
+	list bool
+}
+
+const undoLong = `
+Command undo restores the files removed or replaced by the last operation of
+the application, and removes the files created by it.
+
+The files are kept in the trash directory, in the data directory of the
+application. Only the last operations are kept.
+
+The flags are:
+
+    -list
+      Lists the operations in the trash, instead of undoing the last one.
+`
+
+func (u *undoCmd) Name() string   { return "undo" }
+func (u *undoCmd) Args() string   { return "[-list]" }
+func (u *undoCmd) Short() string  { return "undoes the last file operation" }
+func (u *undoCmd) Long() string   { return undoLong }
+func (u *undoCmd) Runnable() bool { return true }
+
+func (u *undoCmd) Register(fs *flag.FlagSet) {
+	fs.BoolVar(&u.list, "list", false, "list the operations in the trash")
+}
+
+func (u *undoCmd) Run(args []string) error {
+	if len(args) > 0 {
+		return errors.New("too many arguments")
+	}
+	dir, err := trashDir()
+	if err != nil {
+		return err
+	}
+	ops := trashOperations(dir)
+	if u.list {
+		for i := len(ops) - 1; i >= 0; i-- {
+			op, err := readOperation(filepath.Join(dir, ops[i]))
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(Output(), "%s\t%s\t%s\n", op.Time.Format(time.RFC3339), Plural(len(op.Entries), "file", ""), strings.Join(op.Args, " "))
+		}
+		return nil
+	}
+	if len(ops) == 0 {
+		return errors.New("nothing to undo")
+	}
+
+	op, err := readOperation(filepath.Join(dir, ops[len(ops)-1]))
+	if err != nil {
+		return err
+	}
+	// restore in reverse order,
+	// so the first stashed version of a file is kept
+	for i := len(op.Entries) - 1; i >= 0; i-- {
+		e := op.Entries[i]
+		if err := e.restore(op.dir); err != nil {
+			return errors.Wrapf(err, "undo %s", e.Path)
+		}
+	}
+	return os.RemoveAll(op.dir)
+}
+
+// readOperation reads the manifest of an operation.
+func readOperation(dir string) (*trashOperation, error) {
+	data, err := os.ReadFile(filepath.Join(dir, "operation.json"))
+	if err != nil {
+		return nil, errors.Wrap(err, "trash")
+	}
+	op := &trashOperation{dir: dir}
+	if err := json.Unmarshal(data, op); err != nil {
+		return nil, errors.Wrapf(err, "trash: %s", dir)
+	}
+	return op, nil
+}
+
+// restore restores a file of an operation.
+func (e trashEntry) restore(dir string) error {
+	switch {
+	case e.Dir:
+		fmt.Fprintf(Output(), "restore %s\n", e.Path)
+		return os.MkdirAll(e.Path, 0755)
+	case e.Stored == "":
+		fmt.Fprintf(Output(), "remove %s\n", e.Path)
+		if err := os.Remove(e.Path); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		return nil
+	}
+	fmt.Fprintf(Output(), "restore %s\n", e.Path)
+	if err := os.MkdirAll(filepath.Dir(e.Path), 0755); err != nil {
+		return err
+	}
+	src := filepath.Join(dir, e.Stored)
+	fi, err := os.Stat(src)
+	if err != nil {
+		return err
+	}
+	return copyFile(e.Path, src, fi.Mode())
+}
@@ -0,0 +1,179 @@
+// Copyright (c) 2015, J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD-style license that can be found in the LICENSE file.
+
+package cmdapp
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestTrash(t *testing.T) {
+	trashMutex.Lock()
+	prevOn := trashOn
+	trashOn = true
+	trashMutex.Unlock()
+	defer func() {
+		trashMutex.Lock()
+		trashOn, trashOp = prevOn, nil
+		trashMutex.Unlock()
+	}()
+
+	tests := []struct {
+		name  string
+		files map[string]string
+		op    func(dir string) error
+		after map[string]string
+	}{
+		{
+			name: "new file",
+			op: func(dir string) error {
+				return FS().WriteFile(filepath.Join(dir, "new.txt"), []byte("new"), 0644)
+			},
+			after: map[string]string{"new.txt": "new"},
+		},
+		{
+			name:  "replace",
+			files: map[string]string{"a.txt": "old"},
+			op: func(dir string) error {
+				return FS().WriteFile(filepath.Join(dir, "a.txt"), []byte("new"), 0644)
+			},
+			after: map[string]string{"a.txt": "new"},
+		},
+		{
+			name:  "replace twice",
+			files: map[string]string{"a.txt": "old"},
+			op: func(dir string) error {
+				if err := FS().WriteFile(filepath.Join(dir, "a.txt"), []byte("new"), 0644); err != nil {
+					return err
+				}
+				return FS().WriteFile(filepath.Join(dir, "a.txt"), []byte("newer"), 0644)
+			},
+			after: map[string]string{"a.txt": "newer"},
+		},
+		{
+			name:  "remove",
+			files: map[string]string{"a.txt": "old", "b.txt": "keep"},
+			op: func(dir string) error {
+				return FS().Remove(filepath.Join(dir, "a.txt"))
+			},
+			after: map[string]string{"b.txt": "keep"},
+		},
+		{
+			name:  "rename",
+			files: map[string]string{"a.txt": "old", "b.txt": "replaced"},
+			op: func(dir string) error {
+				return FS().Rename(filepath.Join(dir, "a.txt"), filepath.Join(dir, "b.txt"))
+			},
+			after: map[string]string{"b.txt": "old"},
+		},
+	}
+	for _, test := range tests {
+		t.Setenv("XDG_DATA_HOME", t.TempDir())
+		dir := t.TempDir()
+		for nm, s := range test.files {
+			if err := os.WriteFile(filepath.Join(dir, nm), []byte(s), 0644); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		var out bytes.Buffer
+		a := NewApp("trashapp", "a test application")
+		a.Stdout, a.Stderr = &out, io.Discard
+		a.Add(&undoCmd{})
+		a.Add(&mountCmd{name: "change", run: func(c *mountCmd, args []string) error {
+			return test.op(dir)
+		}})
+
+		// each dispatch is a new run of the application
+		trashOp = nil
+		if code := a.Dispatch([]string{"change"}); code != 0 {
+			t.Fatalf("%s: change: exit code %d", test.name, code)
+		}
+		if got := readFiles(t, dir); !sameFiles(got, test.after) {
+			t.Errorf("%s: after the change: got %v, want %v", test.name, got, test.after)
+		}
+
+		trashOp = nil
+		out.Reset()
+		if code := a.Dispatch([]string{"undo", "-list"}); code != 0 {
+			t.Fatalf("%s: undo -list: exit code %d", test.name, code)
+		}
+		if n := strings.Count(out.String(), "\n"); n != 1 {
+			t.Errorf("%s: undo -list: %d operations, want 1:\n%s", test.name, n, out.String())
+		}
+
+		if code := a.Dispatch([]string{"undo"}); code != 0 {
+			t.Fatalf("%s: undo: exit code %d", test.name, code)
+		}
+		if got := readFiles(t, dir); !sameFiles(got, test.files) {
+			t.Errorf("%s: after undo: got %v, want %v", test.name, got, test.files)
+		}
+		if code := a.Dispatch([]string{"undo"}); code != 1 {
+			t.Errorf("%s: undo with an empty trash: exit code %d, want 1", test.name, code)
+		}
+	}
+}
+
+// readFiles returns the content of the files
+// in a directory.
+func readFiles(t *testing.T, dir string) map[string]string {
+	t.Helper()
+	ents, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	files := make(map[string]string)
+	for _, e := range ents {
+		b, err := os.ReadFile(filepath.Join(dir, e.Name()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		files[e.Name()] = string(b)
+	}
+	return files
+}
+
+// sameFiles reports whether two sets of files
+// have the same content.
+func sameFiles(a, b map[string]string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for nm, s := range a {
+		if v, ok := b[nm]; !ok || v != s {
+			return false
+		}
+	}
+	return true
+}
+
+func TestPruneTrash(t *testing.T) {
+	defer func(k int) { TrashKeep = k }(TrashKeep)
+	TrashKeep = 2
+
+	dir := t.TempDir()
+	for _, nm := range []string{"1", "2", "3", "10", "other"} {
+		if err := os.Mkdir(filepath.Join(dir, nm), 0700); err != nil {
+			t.Fatal(err)
+		}
+	}
+	pruneTrash(dir)
+
+	ents, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, e := range ents {
+		got = append(got, e.Name())
+	}
+	if want := "10 3 other"; strings.Join(got, " ") != want {
+		t.Errorf("after pruning: got %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,134 @@
+// Copyright (c) 2015, J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD-style license that can be found in the LICENSE file.
+
+// Package urfavecmd adapts urfave/cli (v2) commands
+// to be hosted by a cmdapp application,
+// so urfave/cli tools can be merged
+// into a cmdapp application.
+//
+// An urfave/cli command is added with:
+//
+//	cmdapp.Add(urfavecmd.New(cliCmd))
+//
+// Only the given command is added,
+// its sub-commands and aliases are ignored.
+package urfavecmd
+
+import (
+	"flag"
+	"strings"
+
+	"github.com/js-arias/cmdapp"
+	"github.com/pkg/errors"
+	"github.com/urfave/cli/v2"
+)
+
+// A Command is an urfave/cli command
+// hosted as a cmdapp command.
+type Command struct {
+	c  *cli.Command
+	fs *flag.FlagSet
+}
+
+// New returns a cmdapp command
+// that runs an urfave/cli command.
+func New(c *cli.Command) *Command {
+	return &Command{c: c}
+}
+
+func (uc *Command) Name() string   { return uc.c.Name }
+func (uc *Command) Args() string   { return uc.c.ArgsUsage }
+func (uc *Command) Short() string  { return uc.c.Usage }
+func (uc *Command) Runnable() bool { return uc.c.Action != nil }
+
+// Long returns the description of the command.
+func (uc *Command) Long() string {
+	long := uc.c.Description
+	if long == "" {
+		long = uc.c.Usage
+	}
+	if uc.c.UsageText != "" {
+		long = uc.c.UsageText + "\n\n" + long
+	}
+	return long
+}
+
+// Register sets the flags of the urfave/cli command.
+// The aliases of a flag share its value.
+func (uc *Command) Register(fs *flag.FlagSet) {
+	uc.fs = fs
+	for _, f := range uc.c.Flags {
+		// errors of flag definitions are reported
+		// by the flag set when parsing
+		f.Apply(fs)
+
+		names := f.Names()
+		ff := fs.Lookup(names[0])
+		if ff == nil {
+			continue
+		}
+		for _, nm := range names[1:] {
+			if af := fs.Lookup(nm); af != nil {
+				af.Value = ff.Value
+			}
+		}
+	}
+}
+
+// Run runs the urfave/cli command action,
+// with its Before and After functions.
+// The output of the command,
+// written to the context App Writer,
+// goes to the application output.
+func (uc *Command) Run(args []string) (err error) {
+	if uc.c.Action == nil {
+		return errors.Errorf("%s: not runnable", uc.c.Name)
+	}
+	if uc.fs == nil {
+		uc.fs = flag.NewFlagSet(uc.c.Name, flag.ContinueOnError)
+		uc.Register(uc.fs)
+		if err := uc.fs.Parse(args); err != nil {
+			return err
+		}
+	}
+	app := &cli.App{
+		Name:      cmdapp.Name,
+		Usage:     cmdapp.Short,
+		Writer:    cmdapp.Output(),
+		ErrWriter: cmdapp.Stderr,
+	}
+	ctx := cli.NewContext(app, uc.fs, nil)
+	ctx.Command = uc.c
+
+	for _, f := range uc.c.Flags {
+		rf, ok := f.(cli.RequiredFlag)
+		if !ok || !rf.IsRequired() {
+			continue
+		}
+		set := false
+		for _, nm := range f.Names() {
+			if ctx.IsSet(nm) {
+				set = true
+				break
+			}
+		}
+		if !set {
+			return errors.Errorf("required flag %q not set", strings.Join(f.Names(), ", "))
+		}
+	}
+
+	if uc.c.After != nil {
+		defer func() {
+			if aerr := uc.c.After(ctx); aerr != nil && err == nil {
+				err = aerr
+			}
+		}()
+	}
+	if uc.c.Before != nil {
+		if err := uc.c.Before(ctx); err != nil {
+			return err
+		}
+	}
+	return uc.c.Action(ctx)
+}
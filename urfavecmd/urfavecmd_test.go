@@ -0,0 +1,88 @@
+// Copyright (c) 2015, J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD-style license that can be found in the LICENSE file.
+
+package urfavecmd
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/js-arias/cmdapp"
+	"github.com/urfave/cli/v2"
+)
+
+func TestCommand(t *testing.T) {
+	greet := &cli.Command{
+		Name:      "greet",
+		Usage:     "a greeting",
+		ArgsUsage: "[<extra>...]",
+		Flags: []cli.Flag{
+			&cli.StringFlag{Name: "name", Aliases: []string{"n"}, Value: "world", Usage: "name to greet"},
+			&cli.BoolFlag{Name: "loud", Usage: "greet loudly"},
+		},
+		Before: func(c *cli.Context) error {
+			fmt.Fprintln(c.App.Writer, "before")
+			return nil
+		},
+		Action: func(c *cli.Context) error {
+			msg := "hello " + c.String("name")
+			if c.Bool("loud") {
+				msg = strings.ToUpper(msg)
+			}
+			fmt.Fprintln(c.App.Writer, msg, strings.Join(c.Args().Slice(), " "))
+			return nil
+		},
+		After: func(c *cli.Context) error {
+			fmt.Fprintln(c.App.Writer, "after")
+			return nil
+		},
+	}
+	need := &cli.Command{
+		Name:  "need",
+		Usage: "a command with a required flag",
+		Flags: []cli.Flag{
+			&cli.StringFlag{Name: "file", Required: true, Usage: "a file"},
+		},
+		Action: func(c *cli.Context) error {
+			fmt.Fprintln(c.App.Writer, "file", c.String("file"))
+			return nil
+		},
+	}
+
+	var out bytes.Buffer
+	a := cmdapp.NewApp("testapp", "a test application")
+	a.Stdout, a.Stderr = &out, io.Discard
+	a.Add(New(greet))
+	a.Add(New(need))
+
+	// the commands are run in order
+	// with the same application,
+	// so the flags must be reset on each run
+	tests := []struct {
+		args []string
+		code int
+		want string
+	}{
+		{args: []string{"greet"}, want: "before\nhello world \nafter\n"},
+		{args: []string{"greet", "-name", "you", "-loud", "a", "b"}, want: "before\nHELLO YOU a b\nafter\n"},
+		{args: []string{"greet", "-n", "them"}, want: "before\nhello them \nafter\n"},
+		{args: []string{"greet"}, want: "before\nhello world \nafter\n"},
+		{args: []string{"need", "-file", "x"}, want: "file x\n"},
+		{args: []string{"need"}, code: 1},
+		{args: []string{"greet", "-unknown"}, code: cmdapp.ExitUsage},
+	}
+	for _, test := range tests {
+		out.Reset()
+		if code := a.Dispatch(test.args); code != test.code {
+			t.Errorf("%v: exit code %d, want %d", test.args, code, test.code)
+			continue
+		}
+		if out.String() != test.want {
+			t.Errorf("%v: output %q, want %q", test.args, out.String(), test.want)
+		}
+	}
+}
@@ -0,0 +1,211 @@
+// Copyright (c) 2015, J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD-style license that can be found in the LICENSE file.
+
+// Package verify checks the checksums and signatures
+// of downloaded files,
+// for example the releases of an application,
+// or plugins provided by the user.
+//
+// Signatures are minisign signatures
+// (https://jedisct1.github.io/minisign/),
+// or plain ed25519 signatures.
+package verify
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/hex"
+	"hash"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/dchest/blake2b"
+	"github.com/pkg/errors"
+)
+
+// Checksum checks the checksum of a file.
+// The checksum is in the form '<algorithm>:<hex digest>',
+// with sha256 or sha512 as algorithm.
+// A digest without algorithm
+// is a sha256 digest.
+func Checksum(name, sum string) error {
+	algo, h, digest, err := parseChecksum(sum)
+	if err != nil {
+		return err
+	}
+	if err := hashFile(name, h); err != nil {
+		return err
+	}
+	got := hex.EncodeToString(h.Sum(nil))
+	if got != digest {
+		return errors.Errorf("verify %s: checksum mismatch: got %s:%s, want %s", name, algo, got, sum)
+	}
+	return nil
+}
+
+// ValidChecksum returns an error
+// if a checksum is not valid,
+// so it can be checked
+// before the file is available.
+func ValidChecksum(sum string) error {
+	_, _, _, err := parseChecksum(sum)
+	return err
+}
+
+// parseChecksum returns the algorithm,
+// a new hash,
+// and the lower case hex digest of a checksum.
+func parseChecksum(sum string) (string, hash.Hash, string, error) {
+	algo, digest, ok := strings.Cut(sum, ":")
+	if !ok {
+		algo, digest = "sha256", sum
+	}
+	var h hash.Hash
+	switch algo {
+	case "sha256":
+		h = sha256.New()
+	case "sha512":
+		h = sha512.New()
+	default:
+		return "", nil, "", errors.Errorf("verify: unknown checksum algorithm %q", algo)
+	}
+	digest = strings.ToLower(strings.TrimSpace(digest))
+	if b, err := hex.DecodeString(digest); err != nil || len(b) != h.Size() {
+		return "", nil, "", errors.Errorf("verify: invalid %s digest %q", algo, digest)
+	}
+	return algo, h, digest, nil
+}
+
+// ChecksumFile checks the checksum of a file
+// using a file of sha256 checksums,
+// in the format of the sha256sum program,
+// in which the file is listed by its base name.
+func ChecksumFile(name, sums string) error {
+	f, err := os.Open(sums)
+	if err != nil {
+		return errors.Wrap(err, "verify")
+	}
+	defer f.Close()
+
+	base := filepath.Base(name)
+	s := bufio.NewScanner(f)
+	for s.Scan() {
+		fields := strings.Fields(s.Text())
+		if len(fields) != 2 {
+			continue
+		}
+		if strings.TrimPrefix(fields[1], "*") == base {
+			return Checksum(name, "sha256:"+fields[0])
+		}
+	}
+	if err := s.Err(); err != nil {
+		return errors.Wrap(err, "verify")
+	}
+	return errors.Errorf("verify %s: not found in %s", name, sums)
+}
+
+// hashFile writes the content of a file in a hash.
+func hashFile(name string, h hash.Hash) error {
+	f, err := os.Open(name)
+	if err != nil {
+		return errors.Wrap(err, "verify")
+	}
+	defer f.Close()
+	if _, err := io.Copy(h, f); err != nil {
+		return errors.Wrap(err, "verify")
+	}
+	return nil
+}
+
+// Ed25519 checks an ed25519 signature of a file.
+func Ed25519(name string, sig []byte, key ed25519.PublicKey) error {
+	data, err := os.ReadFile(name)
+	if err != nil {
+		return errors.Wrap(err, "verify")
+	}
+	if len(key) != ed25519.PublicKeySize || !ed25519.Verify(key, data, sig) {
+		return errors.Errorf("verify %s: invalid signature", name)
+	}
+	return nil
+}
+
+// Minisign checks the minisign signature of a file,
+// and returns the trusted comment of the signature.
+// The signature is the content of a '.minisig' file,
+// and the public key is the content of a minisign public key file,
+// or its base64 encoded key.
+func Minisign(name string, sig []byte, pubKey string) (string, error) {
+	keyID, key, err := parseKey(pubKey)
+	if err != nil {
+		return "", err
+	}
+	lines := sigLines(sig)
+	if len(lines) != 4 || !strings.HasPrefix(lines[2], "trusted comment: ") {
+		return "", errors.Errorf("verify %s: invalid minisign signature", name)
+	}
+	s, err := base64.StdEncoding.DecodeString(lines[1])
+	if err != nil || len(s) != 2+8+ed25519.SignatureSize {
+		return "", errors.Errorf("verify %s: invalid minisign signature", name)
+	}
+	if !bytes.Equal(s[2:10], keyID) {
+		return "", errors.Errorf("verify %s: signed with a different key", name)
+	}
+	sigValue := s[10:]
+
+	data, err := os.ReadFile(name)
+	if err != nil {
+		return "", errors.Wrap(err, "verify")
+	}
+	switch string(s[:2]) {
+	case "Ed":
+	case "ED":
+		h := blake2b.Sum512(data)
+		data = h[:]
+	default:
+		return "", errors.Errorf("verify %s: unknown signature algorithm %q", name, s[:2])
+	}
+	if !ed25519.Verify(key, data, sigValue) {
+		return "", errors.Errorf("verify %s: invalid signature", name)
+	}
+
+	trusted := strings.TrimPrefix(lines[2], "trusted comment: ")
+	global, err := base64.StdEncoding.DecodeString(lines[3])
+	if err != nil || !ed25519.Verify(key, append(append([]byte(nil), sigValue...), trusted...), global) {
+		return "", errors.Errorf("verify %s: invalid trusted comment", name)
+	}
+	return trusted, nil
+}
+
+// parseKey returns the key ID and the public key
+// of a minisign public key.
+func parseKey(pubKey string) ([]byte, ed25519.PublicKey, error) {
+	var enc string
+	for _, ln := range sigLines([]byte(pubKey)) {
+		if !strings.HasPrefix(ln, "untrusted comment:") {
+			enc = ln
+		}
+	}
+	k, err := base64.StdEncoding.DecodeString(enc)
+	if err != nil || len(k) != 2+8+ed25519.PublicKeySize || string(k[:2]) != "Ed" {
+		return nil, nil, errors.New("verify: invalid minisign public key")
+	}
+	return k[2:10], ed25519.PublicKey(k[10:]), nil
+}
+
+// sigLines returns the non-empty lines of a text.
+func sigLines(b []byte) []string {
+	var lines []string
+	for _, ln := range strings.Split(string(b), "\n") {
+		if ln = strings.TrimSpace(ln); ln != "" {
+			lines = append(lines, ln)
+		}
+	}
+	return lines
+}
@@ -0,0 +1,227 @@
+// Copyright (c) 2015, J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD-style license that can be found in the LICENSE file.
+
+package verify
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/dchest/blake2b"
+)
+
+const data = "the content of a release\n"
+
+// writeData writes the test data in a file.
+func writeData(t *testing.T, content string) string {
+	t.Helper()
+	name := filepath.Join(t.TempDir(), "release.tar.gz")
+	if err := os.WriteFile(name, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return name
+}
+
+func TestChecksum(t *testing.T) {
+	s256 := sha256.Sum256([]byte(data))
+	s512 := sha512.Sum512([]byte(data))
+	d256 := hex.EncodeToString(s256[:])
+	d512 := hex.EncodeToString(s512[:])
+
+	tests := []struct {
+		name string
+		sum  string
+		err  string
+	}{
+		{name: "sha256", sum: "sha256:" + d256},
+		{name: "sha512", sum: "sha512:" + d512},
+		{name: "without algorithm", sum: d256},
+		{name: "upper case", sum: "sha256:" + strings.ToUpper(d256)},
+		{name: "mismatch", sum: "sha256:" + strings.Repeat("0", 64), err: "checksum mismatch"},
+		{name: "mismatch sha512", sum: "sha512:" + strings.Repeat("0", 128), err: "checksum mismatch"},
+		{name: "unknown algorithm", sum: "md5:" + strings.Repeat("0", 32), err: "unknown checksum algorithm"},
+		{name: "empty algorithm", sum: ":" + d256, err: "unknown checksum algorithm"},
+		{name: "not hex", sum: "sha256:" + strings.Repeat("x", 64), err: "invalid sha256 digest"},
+		{name: "short digest", sum: "sha256:" + d256[:10], err: "invalid sha256 digest"},
+		{name: "digest of other algorithm", sum: "sha512:" + d256, err: "invalid sha512 digest"},
+		{name: "empty", sum: "", err: "invalid sha256 digest"},
+	}
+	name := writeData(t, data)
+	for _, test := range tests {
+		err := Checksum(name, test.sum)
+		if test.err == "" {
+			if err != nil {
+				t.Errorf("%s: unexpected error: %v", test.name, err)
+			}
+			if err := ValidChecksum(test.sum); err != nil {
+				t.Errorf("%s: ValidChecksum: unexpected error: %v", test.name, err)
+			}
+			continue
+		}
+		if err == nil || !strings.Contains(err.Error(), test.err) {
+			t.Errorf("%s: error %v, want %q", test.name, err, test.err)
+		}
+		if test.err != "checksum mismatch" && ValidChecksum(test.sum) == nil {
+			t.Errorf("%s: ValidChecksum: expecting error", test.name)
+		}
+	}
+}
+
+func TestChecksumFile(t *testing.T) {
+	s := sha256.Sum256([]byte(data))
+	d := hex.EncodeToString(s[:])
+	zero := strings.Repeat("0", 64)
+
+	tests := []struct {
+		name string
+		sums string
+		err  string
+	}{
+		{name: "text mode", sums: zero + "  other.zip\n" + d + "  release.tar.gz\n"},
+		{name: "binary mode", sums: d + " *release.tar.gz\n"},
+		{name: "path in list", sums: d + "  dist/release.tar.gz\n", err: "not found"},
+		{name: "not listed", sums: d + "  other.zip\n", err: "not found"},
+		{name: "mismatch", sums: zero + "  release.tar.gz\n", err: "checksum mismatch"},
+		{name: "malformed line", sums: d + "\n" + d + " release.tar.gz extra\n", err: "not found"},
+	}
+	name := writeData(t, data)
+	for _, test := range tests {
+		sums := filepath.Join(t.TempDir(), "SHA256SUMS")
+		if err := os.WriteFile(sums, []byte(test.sums), 0644); err != nil {
+			t.Fatal(err)
+		}
+		err := ChecksumFile(name, sums)
+		if test.err == "" {
+			if err != nil {
+				t.Errorf("%s: unexpected error: %v", test.name, err)
+			}
+			continue
+		}
+		if err == nil || !strings.Contains(err.Error(), test.err) {
+			t.Errorf("%s: error %v, want %q", test.name, err, test.err)
+		}
+	}
+}
+
+// testKey is a fixed key for the tests.
+func testKey(seed byte) (ed25519.PublicKey, ed25519.PrivateKey) {
+	priv := ed25519.NewKeyFromSeed(bytes.Repeat([]byte{seed}, ed25519.SeedSize))
+	return priv.Public().(ed25519.PublicKey), priv
+}
+
+func TestEd25519(t *testing.T) {
+	pub, priv := testKey(1)
+	other, _ := testKey(2)
+	sig := ed25519.Sign(priv, []byte(data))
+
+	tests := []struct {
+		name    string
+		content string
+		sig     []byte
+		key     ed25519.PublicKey
+		ok      bool
+	}{
+		{name: "valid", content: data, sig: sig, key: pub, ok: true},
+		{name: "tampered file", content: data + "x", sig: sig, key: pub},
+		{name: "other key", content: data, sig: sig, key: other},
+		{name: "short key", content: data, sig: sig, key: pub[:16]},
+		{name: "short signature", content: data, sig: sig[:32], key: pub},
+	}
+	for _, test := range tests {
+		name := writeData(t, test.content)
+		if err := Ed25519(name, test.sig, test.key); (err == nil) != test.ok {
+			t.Errorf("%s: error %v", test.name, err)
+		}
+	}
+}
+
+// minisignKey returns a minisign public key file.
+func minisignKey(id []byte, pub ed25519.PublicKey) string {
+	k := append(append([]byte("Ed"), id...), pub...)
+	return "untrusted comment: minisign public key\n" + base64.StdEncoding.EncodeToString(k) + "\n"
+}
+
+// minisignSig returns a minisign signature file
+// of the data.
+func minisignSig(algo string, id []byte, priv ed25519.PrivateKey, content, trusted string) string {
+	msg := []byte(content)
+	if algo == "ED" {
+		h := blake2b.Sum512(msg)
+		msg = h[:]
+	}
+	sig := ed25519.Sign(priv, msg)
+	global := ed25519.Sign(priv, append(append([]byte(nil), sig...), trusted...))
+	s := append(append([]byte(algo), id...), sig...)
+	return "untrusted comment: signature from minisign secret key\n" +
+		base64.StdEncoding.EncodeToString(s) + "\n" +
+		"trusted comment: " + trusted + "\n" +
+		base64.StdEncoding.EncodeToString(global) + "\n"
+}
+
+func TestMinisign(t *testing.T) {
+	pub, priv := testKey(1)
+	_, otherPriv := testKey(2)
+	id := []byte{1, 2, 3, 4, 5, 6, 7, 8}
+	otherID := []byte{8, 7, 6, 5, 4, 3, 2, 1}
+	key := minisignKey(id, pub)
+	trusted := "timestamp:1700000000\tfile:release.tar.gz"
+	good := minisignSig("ED", id, priv, data, trusted)
+	lines := strings.Split(good, "\n")
+
+	tests := []struct {
+		name    string
+		content string
+		sig     string
+		key     string
+		err     string
+	}{
+		{name: "prehashed", content: data, sig: good, key: key},
+		{name: "legacy", content: data, sig: minisignSig("Ed", id, priv, data, trusted), key: key},
+		{name: "base64 key", content: data, sig: good, key: strings.Split(key, "\n")[1]},
+		{name: "crlf", content: data, sig: strings.ReplaceAll(good, "\n", "\r\n"), key: key},
+		{name: "tampered file", content: data + "x", sig: good, key: key, err: "invalid signature"},
+		{
+			name:    "tampered trusted comment",
+			content: data,
+			sig:     strings.Replace(good, "file:release", "file:evil", 1),
+			key:     key,
+			err:     "invalid trusted comment",
+		},
+		{name: "wrong key id", content: data, sig: minisignSig("ED", otherID, priv, data, trusted), key: key, err: "different key"},
+		{name: "other key with the same id", content: data, sig: minisignSig("ED", id, otherPriv, data, trusted), key: key, err: "invalid signature"},
+		{name: "unknown algorithm", content: data, sig: minisignSig("XX", id, priv, data, trusted), key: key, err: "unknown signature algorithm"},
+		{name: "missing line", content: data, sig: strings.Join(lines[:3], "\n"), key: key, err: "invalid minisign signature"},
+		{name: "without trusted comment", content: data, sig: strings.Replace(good, "\ntrusted comment: ", "\ncomment: ", 1), key: key, err: "invalid minisign signature"},
+		{name: "not base64", content: data, sig: strings.Replace(good, lines[1], "!!!", 1), key: key, err: "invalid minisign signature"},
+		{name: "short signature", content: data, sig: strings.Replace(good, lines[1], lines[1][:20], 1), key: key, err: "invalid minisign signature"},
+		{name: "bad global signature", content: data, sig: strings.Replace(good, lines[3], "!!!", 1), key: key, err: "invalid trusted comment"},
+		{name: "invalid key", content: data, sig: good, key: "untrusted comment: key\nAAAA\n", err: "invalid minisign public key"},
+		{name: "empty key", content: data, sig: good, key: "", err: "invalid minisign public key"},
+	}
+	for _, test := range tests {
+		name := writeData(t, test.content)
+		got, err := Minisign(name, []byte(test.sig), test.key)
+		if test.err == "" {
+			if err != nil {
+				t.Errorf("%s: unexpected error: %v", test.name, err)
+				continue
+			}
+			if got != trusted {
+				t.Errorf("%s: trusted comment %q, want %q", test.name, got, trusted)
+			}
+			continue
+		}
+		if err == nil || !strings.Contains(err.Error(), test.err) {
+			t.Errorf("%s: error %v, want %q", test.name, err, test.err)
+		}
+	}
+}
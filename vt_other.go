@@ -0,0 +1,13 @@
+// Copyright (c) 2015, J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD-style license that can be found in the LICENSE file.
+
+//go:build !windows
+
+package cmdapp
+
+// enableVT reports whether the terminal
+// processes escape sequences.
+func enableVT() bool {
+	return true
+}
@@ -0,0 +1,49 @@
+// Copyright (c) 2015, J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD-style license that can be found in the LICENSE file.
+
+package cmdapp
+
+import (
+	"os"
+	"sync"
+	"syscall"
+)
+
+// enableVirtualTerminalProcessing is the console mode
+// that processes escape sequences.
+const enableVirtualTerminalProcessing = 0x0004
+
+var procSetConsoleMode = syscall.NewLazyDLL("kernel32.dll").NewProc("SetConsoleMode")
+
+var (
+	vtOnce sync.Once
+	vtOK   bool
+)
+
+// enableVT enables the processing of escape sequences
+// in the consoles of the standard output and error,
+// and reports whether it is enabled.
+// Legacy consoles can not process escape sequences,
+// so colors and animations should not be used.
+func enableVT() bool {
+	vtOnce.Do(func() {
+		vtOK = true
+		for _, f := range []*os.File{os.Stdout, os.Stderr} {
+			h := syscall.Handle(f.Fd())
+			var mode uint32
+			if err := syscall.GetConsoleMode(h, &mode); err != nil {
+				// not a console
+				continue
+			}
+			if mode&enableVirtualTerminalProcessing != 0 {
+				continue
+			}
+			r, _, _ := procSetConsoleMode.Call(uintptr(h), uintptr(mode|enableVirtualTerminalProcessing))
+			if r == 0 {
+				vtOK = false
+			}
+		}
+	})
+	return vtOK
+}
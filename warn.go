@@ -0,0 +1,62 @@
+// Copyright (c) 2015, J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD-style license that can be found in the LICENSE file.
+
+package cmdapp
+
+import (
+	"flag"
+	"fmt"
+	"sync"
+)
+
+// warnings is the number of warnings
+// of the running command.
+var (
+	warnMutex sync.Mutex
+	warnings  int
+)
+
+// Strict is set if warnings
+// should make the command fail.
+// It can be set with the -strict flag.
+var Strict bool
+
+// registerWarnFlags sets the flags of the warnings.
+func registerWarnFlags(fs *flag.FlagSet) {
+	fs.BoolVar(&Strict, "strict", false, "fail if a command reports warnings")
+}
+
+// Warn reports a non-fatal issue of a command.
+// The message is printed in Stderr,
+// and the number of warnings is shown
+// when the command finishes.
+// If Strict is set,
+// the command fails if it reports any warning.
+func Warn(format string, a ...interface{}) {
+	msg := fmt.Sprintf(format, a...)
+	warnMutex.Lock()
+	warnings++
+	warnMutex.Unlock()
+	logger.Info("warning", "message", msg)
+	if gitHubActions() {
+		fmt.Fprintf(Stderr, "%s\n", workflowCommand("warning", Name, msg))
+		return
+	}
+	fmt.Fprintf(Stderr, "%s\n", Mark(Warning, fmt.Sprintf("%s: warning: %s", Name, msg)))
+}
+
+// Warnings returns the number of warnings
+// reported by the running command.
+func Warnings() int {
+	warnMutex.Lock()
+	defer warnMutex.Unlock()
+	return warnings
+}
+
+// resetWarnings sets the number of warnings to zero.
+func resetWarnings() {
+	warnMutex.Lock()
+	warnings = 0
+	warnMutex.Unlock()
+}